@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"go-gin-clean/internal/delivery/http/route"
+	"go-gin-clean/internal/gateway/security"
 	"go-gin-clean/internal/infrastructure"
 	"go-gin-clean/pkg/config"
 
@@ -53,13 +54,36 @@ func main() {
 
 	container := infrastructure.NewContainer(db, ch, cfg)
 
+	// Load the JWT signing key ring (creating the first key if needed) and keep it rotating
+	if err := container.SigningKeyUseCase.Initialize(rootCtx); err != nil {
+		log.Fatalf("Error initializing signing keys: %v", err)
+	}
+	go container.SigningKeyUseCase.StartRotation(rootCtx)
+
+	// Passwords are screened against the local breached-password corpus, if configured
+	if err := container.BreachedPasswords.Load(); err != nil {
+		log.Fatalf("Error loading breached password corpus: %v", err)
+	}
+
+	// Keep the optional in-process introspection cache coherent across instances
+	go container.IntrospectionUseCase.StartCacheInvalidation(rootCtx)
+
 	if cfg.Server.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	router := gin.Default()
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// With session JWTs enabled, requests behind Kong must carry a valid one
+	var sessionTokens *security.JWTService
+	if cfg.Introspection.IssueJWT {
+		sessionTokens = &container.JWTService
+	}
 
-	route.SetupRoutes(router, &container.UserHandler, &container.OauthHandler, &container.RegistrationHandler, &container.AuthHandler, &container.UserManagementHandler, &container.IntrospectionHandler, cfg.Server.AllowedOrigins)
+	route.SetupRoutes(router, &container.UserHandler, &container.OauthHandler, &container.RegistrationHandler, &container.AuthHandler, &container.UserManagementHandler, &container.IntrospectionHandler, &container.WellKnownHandler, &container.MFAHandler, &container.PasskeyHandler, &container.APITokenHandler, &container.OAuthClientHandler, &container.OIDCHandler, container.RateLimiter, sessionTokens, cfg.RateLimit, cfg.Server.AllowedOrigins)
 
 	srv := &http.Server{
 		Addr:    cfg.Server.Address(),
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-gin-clean/internal/delivery/http/response"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/usecase"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APITokenHandler struct {
+	apiTokenUseCase *usecase.APITokenUseCase
+}
+
+func NewAPITokenHandler(apiTokenUseCase *usecase.APITokenUseCase) *APITokenHandler {
+	return &APITokenHandler{
+		apiTokenUseCase: apiTokenUseCase,
+	}
+}
+
+// ListTokens handles GET /api/v1/profile/api-tokens
+// @Summary List API tokens
+// @Description Returns the current user's personal access tokens, without their secrets
+// @Tags Profile
+// @Produce json
+// @Success 200 {array} model.APITokenResponse "API tokens"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /profile/api-tokens [get]
+func (h *APITokenHandler) ListTokens(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.apiTokenUseCase.ListTokens(c.Request.Context(), userID.(int64))
+	if err != nil {
+		response.Error(c, "failed to list API tokens", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "API tokens retrieved successfully", tokens, http.StatusOK)
+}
+
+// CreateToken handles POST /api/v1/profile/api-tokens
+// @Summary Create API token
+// @Description Creates a personal access token bound to one tenant and a subset of the user's permissions there. The token is only shown in this response
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param request body model.CreateAPITokenRequest true "Name, tenant, permissions and expiry"
+// @Success 201 {object} model.CreateAPITokenResponse "API token created"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Permissions not held in the tenant"
+// @Failure 409 {object} response.ErrorResponse "Too many API tokens"
+// @Router /profile/api-tokens [post]
+func (h *APITokenHandler) CreateToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.apiTokenUseCase.CreateToken(c.Request.Context(), userID.(int64), &req)
+	if err == errors.ErrAPITokenExpiryTooLong {
+		response.Error(c, "failed to create API token", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err == errors.ErrAPITokenLimitReached {
+		response.Error(c, "failed to create API token", err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		response.Error(c, "failed to create API token", err.Error(), http.StatusForbidden)
+		return
+	}
+
+	response.Success(c, "API token created, copy it now as it will not be shown again", token, http.StatusCreated)
+}
+
+// RevokeToken handles DELETE /api/v1/profile/api-tokens/:id
+// @Summary Revoke API token
+// @Description Deletes one of the current user's personal access tokens
+// @Tags Profile
+// @Produce json
+// @Param id path int true "API token ID"
+// @Success 200 {object} response.SuccessResponse "API token revoked"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "API token not found"
+// @Router /profile/api-tokens/{id} [delete]
+func (h *APITokenHandler) RevokeToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokenID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, "invalid API token ID", "", http.StatusBadRequest)
+		return
+	}
+
+	err = h.apiTokenUseCase.RevokeToken(c.Request.Context(), userID.(int64), tokenID)
+	if err == errors.ErrAPITokenNotFound {
+		response.Error(c, "failed to revoke API token", err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		response.Error(c, "failed to revoke API token", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "API token revoked", nil, http.StatusOK)
+}
@@ -2,10 +2,12 @@ package http
 
 import (
 	"net/http"
+	"strings"
 
 	"go-gin-clean/internal/delivery/http/response"
 	"go-gin-clean/internal/model"
 	"go-gin-clean/internal/usecase"
+	"go-gin-clean/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 )
@@ -29,8 +31,10 @@ func NewAuthHandler(authUseCase *usecase.AuthUseCase) *AuthHandler {
 // @Param request body model.PhantomLoginRequest true "Login credentials"
 // @Success 200 {object} model.PhantomLoginResponse "Login successful"
 // @Success 200 {object} model.TenantSelectionResponse "Multiple tenants available - selection required"
+// @Success 200 {object} model.MFAChallengeResponse "Second factor required - complete with /auth/mfa/verify"
 // @Failure 400 {object} response.ErrorResponse "Invalid request"
 // @Failure 401 {object} response.ErrorResponse "Invalid credentials"
+// @Failure 429 {object} response.ErrorResponse "Too many failed attempts"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req model.PhantomLoginRequest
@@ -38,13 +42,24 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
 		return
 	}
+	req.UserAgent = c.Request.UserAgent()
+	req.ClientIP = c.ClientIP()
 
-	loginResp, tenantSelectionResp, err := h.authUseCase.Login(c.Request.Context(), &req)
+	loginResp, tenantSelectionResp, mfaChallenge, err := h.authUseCase.Login(c.Request.Context(), &req)
+	if err == errors.ErrAccountLocked || err == errors.ErrTooManyLoginAttempts {
+		response.Error(c, "Login failed", err.Error(), http.StatusTooManyRequests)
+		return
+	}
 	if err != nil {
 		response.Error(c, "Login failed", err.Error(), http.StatusUnauthorized)
 		return
 	}
 
+	if mfaChallenge != nil {
+		response.Success(c, "Two-factor authentication required", mfaChallenge, http.StatusOK)
+		return
+	}
+
 	// Check if tenant selection is required
 	if tenantSelectionResp != nil && tenantSelectionResp.RequiresChoice {
 		response.Success(c, "Tenant selection required", tenantSelectionResp, http.StatusOK)
@@ -62,8 +77,10 @@ func (h *AuthHandler) Login(c *gin.Context) {
 // @Produce json
 // @Param request body model.SelectTenantRequest true "Tenant selection"
 // @Success 200 {object} model.PhantomLoginResponse "Tenant selected successfully"
+// @Success 200 {object} model.MFAChallengeResponse "Second factor required - complete with /auth/mfa/verify"
 // @Failure 400 {object} response.ErrorResponse "Invalid request"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 429 {object} response.ErrorResponse "Too many failed attempts"
 // @Router /auth/select-tenant [post]
 func (h *AuthHandler) SelectTenant(c *gin.Context) {
 	var req model.SelectTenantRequest
@@ -71,16 +88,249 @@ func (h *AuthHandler) SelectTenant(c *gin.Context) {
 		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
 		return
 	}
+	req.UserAgent = c.Request.UserAgent()
+	req.ClientIP = c.ClientIP()
 
-	loginResp, err := h.authUseCase.SelectTenant(c.Request.Context(), &req)
+	loginResp, mfaChallenge, err := h.authUseCase.SelectTenant(c.Request.Context(), &req)
+	if err == errors.ErrAccountLocked || err == errors.ErrTooManyLoginAttempts {
+		response.Error(c, "Tenant selection failed", err.Error(), http.StatusTooManyRequests)
+		return
+	}
 	if err != nil {
 		response.Error(c, "Tenant selection failed", err.Error(), http.StatusUnauthorized)
 		return
 	}
 
+	if mfaChallenge != nil {
+		response.Success(c, "Two-factor authentication required", mfaChallenge, http.StatusOK)
+		return
+	}
+
 	response.Success(c, "Tenant selected successfully", loginResp, http.StatusOK)
 }
 
+// VerifyMFA completes a login with the second factor
+// @Summary Verify MFA
+// @Description Exchanges the MFA challenge token from login plus a TOTP or recovery code for a session
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body model.VerifyMFARequest true "Challenge token and code"
+// @Success 200 {object} model.PhantomLoginResponse "Login successful"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Invalid code or expired challenge"
+// @Failure 429 {object} response.ErrorResponse "Account locked"
+// @Router /auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req model.VerifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.UserAgent = c.Request.UserAgent()
+	req.ClientIP = c.ClientIP()
+
+	loginResp, err := h.authUseCase.VerifyMFA(c.Request.Context(), &req)
+	if err == errors.ErrAccountLocked || err == errors.ErrTooManyLoginAttempts {
+		response.Error(c, "Verification failed", err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if err != nil {
+		response.Error(c, "Verification failed", err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	response.Success(c, "Login successful", loginResp, http.StatusOK)
+}
+
+// BeginMFAPasskey starts answering an MFA challenge with a passkey
+// @Summary Begin MFA with passkey
+// @Description Returns WebAuthn options for navigator.credentials.get() to answer the MFA challenge from login with a passkey
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body model.BeginMFAPasskeyRequest true "Challenge token"
+// @Success 200 {object} model.PasskeyCeremonyResponse "Passkey options"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Expired challenge or no passkey registered"
+// @Router /auth/mfa/passkey/begin [post]
+func (h *AuthHandler) BeginMFAPasskey(c *gin.Context) {
+	var req model.BeginMFAPasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ceremony, err := h.authUseCase.BeginMFAPasskey(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, "Verification failed", err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	response.Success(c, "Passkey verification started", ceremony, http.StatusOK)
+}
+
+// VerifyMFAPasskey completes a login with a passkey as the second factor
+// @Summary Verify MFA with passkey
+// @Description Exchanges the MFA challenge token plus the passkey assertion for a session
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body model.VerifyMFAPasskeyRequest true "Challenge token, ceremony token and assertion"
+// @Success 200 {object} model.PhantomLoginResponse "Login successful"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Invalid passkey or expired challenge"
+// @Router /auth/mfa/passkey/verify [post]
+func (h *AuthHandler) VerifyMFAPasskey(c *gin.Context) {
+	var req model.VerifyMFAPasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.UserAgent = c.Request.UserAgent()
+	req.ClientIP = c.ClientIP()
+
+	loginResp, err := h.authUseCase.VerifyMFAPasskey(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, "Verification failed", err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	response.Success(c, "Login successful", loginResp, http.StatusOK)
+}
+
+// BeginPasskeyLogin starts a passwordless login
+// @Summary Begin passkey login
+// @Description Returns WebAuthn options for navigator.credentials.get(); the authenticator picks the account
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} model.PasskeyCeremonyResponse "Passkey options"
+// @Router /auth/passkey/login/begin [post]
+func (h *AuthHandler) BeginPasskeyLogin(c *gin.Context) {
+	ceremony, err := h.authUseCase.BeginPasskeyLogin(c.Request.Context())
+	if err != nil {
+		response.Error(c, "Failed to start passkey login", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "Passkey login started", ceremony, http.StatusOK)
+}
+
+// PasskeyLogin creates a phantom token session from a passkey assertion
+// @Summary Passkey login
+// @Description Logs in with a passkey instead of email and password. Multi-tenant users without tenant_id get the tenant list and repeat the ceremony with tenant_id.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body model.PasskeyLoginRequest true "Ceremony token and assertion"
+// @Success 200 {object} model.PhantomLoginResponse "Login successful"
+// @Success 200 {object} model.TenantSelectionResponse "Multiple tenants available - selection required"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Invalid passkey"
+// @Router /auth/passkey/login/finish [post]
+func (h *AuthHandler) PasskeyLogin(c *gin.Context) {
+	var req model.PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.UserAgent = c.Request.UserAgent()
+	req.ClientIP = c.ClientIP()
+
+	loginResp, tenantSelectionResp, err := h.authUseCase.PasskeyLogin(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, "Login failed", err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if tenantSelectionResp != nil && tenantSelectionResp.RequiresChoice {
+		response.Success(c, "Tenant selection required", tenantSelectionResp, http.StatusOK)
+		return
+	}
+
+	response.Success(c, "Login successful", loginResp, http.StatusOK)
+}
+
+// OAuthLogin exchanges the login code of an external identity provider for a session
+// @Summary External login
+// @Description Exchanges the one-time code the frontend received after logging in at an identity provider, together with the login_nonce returned by /auth/oauth2/url. Multi-tenant users without tenant_id get the tenant list and a new login_code to exchange with tenant_id.
+// @Tags OAuth
+// @Accept json
+// @Produce json
+// @Param request body model.OAuthExchangeRequest true "Login code"
+// @Success 200 {object} model.PhantomLoginResponse "Login successful"
+// @Success 200 {object} model.TenantSelectionResponse "Multiple tenants available - selection required"
+// @Success 200 {object} model.MFAChallengeResponse "Second factor required - complete with /auth/mfa/verify"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Invalid or expired login code"
+// @Router /auth/oauth2/exchange [post]
+func (h *AuthHandler) OAuthLogin(c *gin.Context) {
+	var req model.OAuthExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.UserAgent = c.Request.UserAgent()
+	req.ClientIP = c.ClientIP()
+
+	loginResp, tenantSelectionResp, mfaChallenge, err := h.authUseCase.OAuthLogin(c.Request.Context(), &req)
+	if err != nil {
+		response.Error(c, "Login failed", err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if mfaChallenge != nil {
+		response.Success(c, "Two-factor authentication required", mfaChallenge, http.StatusOK)
+		return
+	}
+
+	if tenantSelectionResp != nil && tenantSelectionResp.RequiresChoice {
+		response.Success(c, "Tenant selection required", tenantSelectionResp, http.StatusOK)
+		return
+	}
+
+	response.Success(c, "Login successful", loginResp, http.StatusOK)
+}
+
+// SwitchTenant changes the active tenant of the current session
+// @Summary Switch Tenant
+// @Description Switches the active tenant of an existing session without re-entering credentials
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body model.SwitchTenantRequest true "Target tenant"
+// @Success 200 {object} model.PhantomLoginResponse "Tenant switched successfully"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Not a member of the tenant"
+// @Router /auth/switch-tenant [post]
+func (h *AuthHandler) SwitchTenant(c *gin.Context) {
+	refToken, ok := bearerToken(c)
+	if !ok {
+		response.Error(c, "Missing authorization header", "", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.SwitchTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	loginResp, err := h.authUseCase.SwitchTenant(c.Request.Context(), refToken, &req)
+	if err == errors.ErrSessionNotFound || err == errors.ErrUserNotFound || err == errors.ErrUserInactive || err == errors.ErrSessionLifetimeExceeded {
+		response.Error(c, "Tenant switch failed", err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		response.Error(c, "Tenant switch failed", err.Error(), http.StatusForbidden)
+		return
+	}
+
+	response.Success(c, "Tenant switched successfully", loginResp, http.StatusOK)
+}
+
 // Logout handles session invalidation
 // @Summary Logout
 // @Description Invalidates the user's session
@@ -113,36 +363,33 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	response.Success(c, "Logout successful", nil, http.StatusOK)
 }
 
-// RefreshSession extends the session TTL
+// RefreshSession rotates the access and refresh tokens
 // @Summary Refresh Session
-// @Description Extends the TTL of the current session
+// @Description Exchanges a refresh token for a new access/refresh token pair. Each refresh token can be used once; reusing one revokes every session of that login
 // @Tags Authentication
 // @Accept json
 // @Produce json
-// @Param Authorization header string true "Bearer token"
-// @Success 200 {object} response.SuccessResponse "Session refreshed"
+// @Param request body model.RefreshSessionRequest true "Refresh token"
+// @Success 200 {object} model.PhantomLoginResponse "Session refreshed"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
 // @Router /auth/refresh [post]
 func (h *AuthHandler) RefreshSession(c *gin.Context) {
-	// Extract reference token from Authorization header
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		response.Error(c, "Missing authorization header", "", http.StatusUnauthorized)
+	var req model.RefreshSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
 		return
 	}
+	req.UserAgent = c.Request.UserAgent()
+	req.ClientIP = c.ClientIP()
 
-	// Remove "Bearer " prefix
-	refToken := authHeader
-	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-		refToken = authHeader[7:]
-	}
-
-	if err := h.authUseCase.RefreshSession(c.Request.Context(), refToken); err != nil {
+	loginResp, err := h.authUseCase.RefreshSession(c.Request.Context(), &req)
+	if err != nil {
 		response.Error(c, "Session refresh failed", err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	response.Success(c, "Session refreshed successfully", nil, http.StatusOK)
+	response.Success(c, "Session refreshed successfully", loginResp, http.StatusOK)
 }
 
 // GetSession retrieves the current session context
@@ -177,3 +424,183 @@ func (h *AuthHandler) GetSession(c *gin.Context) {
 
 	response.Success(c, "Session retrieved", sessionValue, http.StatusOK)
 }
+
+// ListSessions lists all active sessions of the current user
+// @Summary List My Sessions
+// @Description Lists all active sessions of the user owning the reference token
+// @Tags Authentication
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {array} model.ActiveSessionInfo "Active sessions"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /auth/sessions [get]
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	refToken, ok := bearerToken(c)
+	if !ok {
+		response.Error(c, "Missing authorization header", "", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.authUseCase.ListSessions(c.Request.Context(), refToken)
+	if err != nil {
+		response.Error(c, "Failed to list sessions", err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	response.Success(c, "Sessions retrieved", sessions, http.StatusOK)
+}
+
+// RevokeSession terminates one of the current user's sessions
+// @Summary Revoke Session
+// @Description Terminates one of the current user's sessions by its session ID
+// @Tags Authentication
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Session ID"
+// @Success 200 {object} response.SuccessResponse "Session revoked"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Session not found"
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	refToken, ok := bearerToken(c)
+	if !ok {
+		response.Error(c, "Missing authorization header", "", http.StatusUnauthorized)
+		return
+	}
+
+	err := h.authUseCase.RevokeSession(c.Request.Context(), refToken, c.Param("id"))
+	if err == errors.ErrSessionNotFound {
+		response.Error(c, "Session not found", err.Error(), http.StatusNotFound)
+		return
+	}
+	if err == errors.ErrImpersonationNotAllowed {
+		response.Error(c, "Failed to revoke session", err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		response.Error(c, "Failed to revoke session", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "Session revoked successfully", nil, http.StatusOK)
+}
+
+// RevokeAllSessions terminates all sessions of the current user
+// @Summary Revoke All Sessions
+// @Description Terminates all sessions of the current user. Pass keep_current=true to stay logged in on this device
+// @Tags Authentication
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param keep_current query bool false "Keep the session used for this request"
+// @Success 200 {object} response.SuccessResponse "Sessions revoked"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /auth/sessions [delete]
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	refToken, ok := bearerToken(c)
+	if !ok {
+		response.Error(c, "Missing authorization header", "", http.StatusUnauthorized)
+		return
+	}
+
+	keepCurrent := c.Query("keep_current") == "true"
+	err := h.authUseCase.RevokeAllSessions(c.Request.Context(), refToken, keepCurrent)
+	if err == errors.ErrImpersonationNotAllowed {
+		response.Error(c, "Failed to revoke sessions", err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		response.Error(c, "Failed to revoke sessions", err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	response.Success(c, "Sessions revoked successfully", nil, http.StatusOK)
+}
+
+// Impersonate starts a session acting as another user
+// @Summary Impersonate User
+// @Description Platform admins get a short-lived, non-refreshable session acting as the user in one of their tenants. Every impersonation is audited and visible to the user
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body model.ImpersonateRequest true "Target user, tenant and reason"
+// @Success 201 {object} model.PhantomLoginResponse "Impersonated session"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 403 {object} response.ErrorResponse "Not a platform admin, or the user cannot be impersonated"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Router /admin/impersonations [post]
+func (h *AuthHandler) Impersonate(c *gin.Context) {
+	requestorUserID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.UserAgent = c.Request.UserAgent()
+	req.ClientIP = c.ClientIP()
+
+	loginResp, err := h.authUseCase.Impersonate(c.Request.Context(), requestorUserID.(int64), &req)
+	if err == errors.ErrUserNotFound {
+		response.Error(c, "Impersonation failed", err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		response.Error(c, "Impersonation failed", err.Error(), http.StatusForbidden)
+		return
+	}
+
+	response.Success(c, "Impersonation started", loginResp, http.StatusCreated)
+}
+
+// ListImpersonations lists the admins who acted as the current user
+// @Summary List Impersonations Of Me
+// @Description Audit trail of platform admins who impersonated the current user, newest first
+// @Tags Authentication
+// @Produce json
+// @Param page query int false "Page"
+// @Param per_page query int false "Entries per page"
+// @Success 200 {array} model.ImpersonationLogResponse "Impersonations"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /profile/impersonations [get]
+func (h *AuthHandler) ListImpersonations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.PaginationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, "Failed to bind query", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PerPage <= 0 {
+		req.PerPage = 10
+	}
+
+	result, err := h.authUseCase.ListImpersonations(c.Request.Context(), userID.(int64), req.Page, req.PerPage)
+	if err != nil {
+		response.Error(c, "Failed to list impersonations", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response.SuccessPagination(c, result.Data, response.SetMeta(req.Page, req.PerPage, result.Total, result.TotalPages))
+}
+
+// bearerToken extracts the reference token from the Authorization header
+func bearerToken(c *gin.Context) (string, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		return "", false
+	}
+
+	refToken := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+	return refToken, refToken != ""
+}
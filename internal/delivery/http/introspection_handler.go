@@ -2,8 +2,10 @@ package http
 
 import (
 	"net/http"
+	"net/url"
 	"strings"
 
+	"go-gin-clean/internal/model"
 	"go-gin-clean/internal/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -21,11 +23,34 @@ func NewIntrospectionHandler(introspectionUseCase *usecase.IntrospectionUseCase)
 
 // Introspect handles POST /auth/introspect
 // This endpoint is called by Kong's auth-request plugin to validate phantom tokens
-// It returns session context as HTTP headers that Kong will inject into upstream requests
+// It returns session context as HTTP headers that Kong will inject into upstream requests.
+// Kong authenticates as an introspection client (HTTP Basic) and sends the token in the
+// JSON body; only then is the signed session JWT returned. Other callers send the token
+// as Bearer and get the session context without it.
 func (h *IntrospectionHandler) Introspect(c *gin.Context) {
-	// Get token from Authorization header
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
+	var token string
+	authenticated := false
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		if !h.introspectionUseCase.AuthenticateClient(clientID, clientSecret) {
+			c.Header("WWW-Authenticate", `Basic realm="introspect"`)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "invalid_client",
+			})
+			return
+		}
+		authenticated = true
+
+		var req model.IntrospectionRequest
+		if err := c.ShouldBindJSON(&req); err == nil {
+			token = req.Token
+		}
+	} else {
+		// Remove Bearer prefix
+		token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		token = strings.TrimSpace(token)
+	}
+
+	if token == "" {
 		// Return inactive response
 		c.JSON(http.StatusOK, gin.H{
 			"active": false,
@@ -33,10 +58,6 @@ func (h *IntrospectionHandler) Introspect(c *gin.Context) {
 		return
 	}
 
-	// Remove Bearer prefix
-	token := strings.TrimPrefix(authHeader, "Bearer ")
-	token = strings.TrimSpace(token)
-
 	// Introspect the token
 	resp, err := h.introspectionUseCase.IntrospectToken(c.Request.Context(), token)
 	if err != nil || !resp.Active {
@@ -45,6 +66,9 @@ func (h *IntrospectionHandler) Introspect(c *gin.Context) {
 		})
 		return
 	}
+	if !authenticated {
+		resp.Token = ""
+	}
 
 	// Get headers to inject
 	headers := h.introspectionUseCase.GetHeadersForUpstream(resp)
@@ -55,7 +79,7 @@ func (h *IntrospectionHandler) Introspect(c *gin.Context) {
 	}
 
 	// Return introspection response
-	c.JSON(http.StatusOK, gin.H{
+	body := gin.H{
 		"active":      resp.Active,
 		"sub":         resp.Sub,
 		"tenant_id":   resp.TenantID,
@@ -64,5 +88,118 @@ func (h *IntrospectionHandler) Introspect(c *gin.Context) {
 		"role_name":   resp.RoleName,
 		"permissions": resp.Permissions,
 		"exp":         resp.Exp,
-	})
+	}
+	if resp.Token != "" {
+		body["token"] = resp.Token
+	}
+	if resp.Restriction != "" {
+		body["restriction"] = resp.Restriction
+	}
+	if resp.ImpersonatedBy != 0 {
+		body["impersonated_by"] = resp.ImpersonatedBy
+	}
+	if resp.APITokenID != 0 {
+		body["api_token_id"] = resp.APITokenID
+	}
+	if resp.ClientID != "" {
+		body["client_id"] = resp.ClientID
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// OAuthIntrospect handles POST /oauth2/introspect (RFC 7662)
+// Callers authenticate with client credentials (HTTP Basic or client_id/client_secret
+// form fields) and pass the token as the "token" form parameter.
+// @Summary Token introspection (RFC 7662)
+// @Tags OAuth2
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200 {object} model.TokenIntrospectionResponse "Introspection result"
+// @Failure 400 {object} map[string]string "invalid_request"
+// @Failure 401 {object} map[string]string "invalid_client"
+// @Router /oauth2/introspect [post]
+func (h *IntrospectionHandler) OAuthIntrospect(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	clientID, clientSecret, ok := clientCredentials(c)
+	if !ok || !h.introspectionUseCase.AuthenticateClient(clientID, clientSecret) {
+		c.Header("WWW-Authenticate", `Basic realm="introspect"`)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "invalid_client",
+		})
+		return
+	}
+
+	var req model.TokenIntrospectionRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "token is required",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.introspectionUseCase.Introspect(c.Request.Context(), &req))
+}
+
+// CacheStats handles GET /oauth2/introspect/stats
+// Reports this instance's in-process introspection cache counters to introspection clients
+// @Summary Introspection cache statistics
+// @Tags OAuth2
+// @Produce json
+// @Success 200 {object} cache.LocalCacheStats "Cache statistics"
+// @Failure 401 {object} map[string]string "invalid_client"
+// @Failure 404 {object} map[string]string "Cache disabled"
+// @Router /oauth2/introspect/stats [get]
+func (h *IntrospectionHandler) CacheStats(c *gin.Context) {
+	clientID, clientSecret, ok := clientCredentials(c)
+	if !ok || !h.introspectionUseCase.AuthenticateClient(clientID, clientSecret) {
+		c.Header("WWW-Authenticate", `Basic realm="introspect"`)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "invalid_client",
+		})
+		return
+	}
+
+	stats := h.introspectionUseCase.CacheStats()
+	if stats == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "introspection cache is disabled",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// AuthenticatedClient returns the introspection client whose credentials the request
+// carries, for rate limiting before the handler runs
+func (h *IntrospectionHandler) AuthenticatedClient(c *gin.Context) (string, bool) {
+	clientID, clientSecret, ok := clientCredentials(c)
+	if !ok || !h.introspectionUseCase.AuthenticateClient(clientID, clientSecret) {
+		return "", false
+	}
+	return clientID, true
+}
+
+// clientCredentials reads OAuth client credentials from HTTP Basic auth
+// (client_secret_basic) or from the form body (client_secret_post)
+func clientCredentials(c *gin.Context) (string, string, bool) {
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		// RFC 6749 section 2.3.1: both parts are form-urlencoded before encoding
+		if decoded, err := url.QueryUnescape(id); err == nil {
+			id = decoded
+		}
+		if decoded, err := url.QueryUnescape(secret); err == nil {
+			secret = decoded
+		}
+		return id, secret, true
+	}
+
+	id := c.PostForm("client_id")
+	secret := c.PostForm("client_secret")
+	return id, secret, id != "" && secret != ""
 }
@@ -0,0 +1,196 @@
+package http
+
+import (
+	"net/http"
+
+	"go-gin-clean/internal/delivery/http/response"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/usecase"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	mfaUseCase *usecase.MFAUseCase
+}
+
+func NewMFAHandler(mfaUseCase *usecase.MFAUseCase) *MFAHandler {
+	return &MFAHandler{
+		mfaUseCase: mfaUseCase,
+	}
+}
+
+// GetStatus handles GET /api/v1/mfa
+// @Summary MFA status
+// @Description Returns whether TOTP is enabled and how many recovery codes are left
+// @Tags MFA
+// @Produce json
+// @Success 200 {object} model.MFAStatusResponse "MFA status"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /mfa [get]
+func (h *MFAHandler) GetStatus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status, err := h.mfaUseCase.GetStatus(c.Request.Context(), userID.(int64))
+	if err != nil {
+		response.Error(c, "failed to get MFA status", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "MFA status retrieved successfully", status, http.StatusOK)
+}
+
+// EnrollTOTP handles POST /api/v1/mfa/totp
+// @Summary Start TOTP enrollment
+// @Description Generates a TOTP secret and the otpauth URI to render as a QR code. Logins are not protected until the enrollment is confirmed.
+// @Tags MFA
+// @Produce json
+// @Success 200 {object} model.TOTPEnrollmentResponse "Enrollment started"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "TOTP already enabled"
+// @Router /mfa/totp [post]
+func (h *MFAHandler) EnrollTOTP(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := h.mfaUseCase.BeginTOTPEnrollment(c.Request.Context(), userID.(int64))
+	if err == errors.ErrMFAAlreadyEnabled {
+		response.Error(c, "failed to start TOTP enrollment", err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		response.Error(c, "failed to start TOTP enrollment", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "TOTP enrollment started", enrollment, http.StatusOK)
+}
+
+// ConfirmTOTP handles POST /api/v1/mfa/totp/confirm
+// @Summary Confirm TOTP enrollment
+// @Description Enables TOTP with a code from the authenticator app and returns one-time recovery codes
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param request body model.MFACodeRequest true "Current TOTP code"
+// @Success 200 {object} model.RecoveryCodesResponse "TOTP enabled"
+// @Failure 400 {object} response.ErrorResponse "Invalid code or no pending enrollment"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /mfa/totp/confirm [post]
+func (h *MFAHandler) ConfirmTOTP(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.mfaUseCase.ConfirmTOTPEnrollment(c.Request.Context(), userID.(int64), req.Code)
+	if err == errors.ErrMFACodeInvalid || err == errors.ErrMFANotEnrolling || err == errors.ErrMFAAlreadyEnabled {
+		response.Error(c, "failed to confirm TOTP", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		response.Error(c, "failed to confirm TOTP", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "TOTP enabled, store the recovery codes somewhere safe", codes, http.StatusOK)
+}
+
+// DisableTOTP handles POST /api/v1/mfa/totp/disable
+// @Summary Disable TOTP
+// @Description Turns off TOTP and deletes the recovery codes. Requires the password and a current TOTP or recovery code.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param request body model.DisableTOTPRequest true "Password and code"
+// @Success 200 {object} response.SuccessResponse "TOTP disabled"
+// @Failure 400 {object} response.ErrorResponse "Invalid password or code"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 429 {object} response.ErrorResponse "Too many failed attempts"
+// @Router /mfa/totp/disable [post]
+func (h *MFAHandler) DisableTOTP(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.DisableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := h.mfaUseCase.DisableTOTP(c.Request.Context(), userID.(int64), &req)
+	if err == errors.ErrAccountLocked || err == errors.ErrTooManyLoginAttempts {
+		response.Error(c, "failed to disable TOTP", err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if err == errors.ErrInvalidCredentials || err == errors.ErrMFACodeInvalid || err == errors.ErrMFANotEnabled {
+		response.Error(c, "failed to disable TOTP", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		response.Error(c, "failed to disable TOTP", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "TOTP disabled", nil, http.StatusOK)
+}
+
+// RegenerateRecoveryCodes handles POST /api/v1/mfa/recovery-codes
+// @Summary Regenerate recovery codes
+// @Description Replaces all recovery codes. Requires the password and a current TOTP or recovery code.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param request body model.RegenerateRecoveryCodesRequest true "Password and code"
+// @Success 200 {object} model.RecoveryCodesResponse "New recovery codes"
+// @Failure 400 {object} response.ErrorResponse "Invalid password or code"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 429 {object} response.ErrorResponse "Too many failed attempts"
+// @Router /mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.RegenerateRecoveryCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.mfaUseCase.RegenerateRecoveryCodes(c.Request.Context(), userID.(int64), &req)
+	if err == errors.ErrAccountLocked || err == errors.ErrTooManyLoginAttempts {
+		response.Error(c, "failed to regenerate recovery codes", err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if err == errors.ErrInvalidCredentials || err == errors.ErrMFACodeInvalid || err == errors.ErrMFANotEnabled {
+		response.Error(c, "failed to regenerate recovery codes", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		response.Error(c, "failed to regenerate recovery codes", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "Recovery codes regenerated", codes, http.StatusOK)
+}
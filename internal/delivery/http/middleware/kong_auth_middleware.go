@@ -2,7 +2,9 @@ package middleware
 
 import (
 	"go-gin-clean/internal/delivery/http/response"
+	"go-gin-clean/internal/gateway/security"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -12,14 +14,25 @@ import (
 // KongAuthMiddleware reads context from Kong-injected headers
 // This middleware is used for services behind Kong that use the phantom token pattern
 // Kong validates the token and injects headers (X-Tenant-ID, X-User-ID, etc.)
-type KongAuthMiddleware struct{}
+// When session JWTs are enabled, Kong also forwards the signed JWT as Authorization:
+// the user and tenant headers must match it, and the role, permissions and
+// impersonation are taken from it instead of the headers.
+type KongAuthMiddleware struct {
+	sessionTokens *security.JWTService // nil unless session JWTs are enabled
+}
 
-func NewKongAuthMiddleware() *KongAuthMiddleware {
-	return &KongAuthMiddleware{}
+func NewKongAuthMiddleware(sessionTokens *security.JWTService) *KongAuthMiddleware {
+	return &KongAuthMiddleware{
+		sessionTokens: sessionTokens,
+	}
 }
 
-// RequireAuth validates that Kong has injected the required headers
-func (m *KongAuthMiddleware) RequireAuth() gin.HandlerFunc {
+// RequireAuth validates that Kong has injected the required headers.
+// Limited sessions (X-Session-Restriction) are rejected unless their restriction is
+// listed in allowedRestrictions. API tokens (X-API-Token-ID) and OAuth client tokens
+// (X-Client-ID) are meant for the ERP services behind Kong; portal endpoints authorize
+// by the user's role and refuse them.
+func (m *KongAuthMiddleware) RequireAuth(allowedRestrictions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Check if request is authenticated (Kong sets this header)
 		authenticated := c.GetHeader("X-Authenticated")
@@ -29,6 +42,25 @@ func (m *KongAuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		restriction := c.GetHeader("X-Session-Restriction")
+		if restriction != "" && !slices.Contains(allowedRestrictions, restriction) {
+			response.Error(c, "session is restricted", "complete "+restriction+" before using this endpoint", http.StatusForbidden)
+			c.Abort()
+			return
+		}
+
+		if c.GetHeader("X-API-Token-ID") != "" {
+			response.Error(c, "API tokens are not accepted here", "log in to use this endpoint", http.StatusForbidden)
+			c.Abort()
+			return
+		}
+
+		if c.GetHeader("X-Client-ID") != "" {
+			response.Error(c, "client tokens are not accepted here", "this endpoint acts for a user", http.StatusForbidden)
+			c.Abort()
+			return
+		}
+
 		// Read tenant ID
 		tenantIDStr := c.GetHeader("X-Tenant-ID")
 		if tenantIDStr == "" {
@@ -75,18 +107,67 @@ func (m *KongAuthMiddleware) RequireAuth() gin.HandlerFunc {
 			permissions = strings.Split(permissionsStr, ",")
 		}
 
+		// Set by Kong on sessions of a platform admin acting as the user
+		impersonatedBy := int64(0)
+		if impersonatedByStr := c.GetHeader("X-Impersonated-By"); impersonatedByStr != "" {
+			impersonatedBy, _ = strconv.ParseInt(impersonatedByStr, 10, 64)
+		}
+
+		// Requests that bypass Kong cannot make up headers without a matching session JWT.
+		// The role, permissions and impersonation then come from the JWT alone.
+		if m.sessionTokens != nil {
+			claims, err := m.sessionTokens.ValidateSessionToken(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+			if err != nil || claims.UserID != userID || claims.TenantID != tenantID || claims.Restriction != restriction || claims.ClientID != "" {
+				response.Error(c, "authentication required", "missing or invalid session token from Kong", http.StatusUnauthorized)
+				c.Abort()
+				return
+			}
+
+			roleID = 0
+			roleName = ""
+			if len(claims.Roles) > 0 {
+				roleName = claims.Roles[0]
+			}
+			permissions = claims.Permissions
+			impersonatedBy = 0
+			if claims.Actor != "" {
+				impersonatedBy, err = strconv.ParseInt(strings.TrimPrefix(claims.Actor, "user_"), 10, 64)
+				if err != nil {
+					response.Error(c, "authentication required", "invalid session token from Kong", http.StatusUnauthorized)
+					c.Abort()
+					return
+				}
+			}
+		}
+
 		// Set context for downstream handlers
 		c.Set("tenant_id", tenantID)
 		c.Set("user_id", userID)
 		c.Set("role_id", roleID)
 		c.Set("role_name", roleName)
 		c.Set("permissions", permissions)
+		c.Set("session_restriction", restriction)
+		c.Set("impersonated_by", impersonatedBy)
 		c.Set("authenticated", true)
 
 		c.Next()
 	}
 }
 
+// DenyImpersonation rejects impersonated sessions, for endpoints only the user
+// themselves may use, e.g. changing credentials. Use it after RequireAuth.
+func (m *KongAuthMiddleware) DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetInt64("impersonated_by") != 0 {
+			response.Error(c, "access denied", "not allowed while impersonating a user", http.StatusForbidden)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // RequirePermission checks if user has a specific permission
 func (m *KongAuthMiddleware) RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
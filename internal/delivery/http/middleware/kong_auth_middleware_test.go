@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-gin-clean/internal/gateway/security"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestSessionTokens(t *testing.T) *security.JWTService {
+	t.Helper()
+
+	privateKey, err := security.GenerateSigningKey(security.AlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey() error = %v", err)
+	}
+	keyRing := security.NewKeyRing()
+	keyRing.Replace([]*security.SigningKey{{
+		KID:         "test",
+		Algorithm:   security.AlgorithmEdDSA,
+		PrivateKey:  privateKey,
+		ActivatedAt: time.Now().Add(-time.Minute),
+	}})
+
+	return security.NewJWTService(&config.JWTConfig{
+		JWTIssuer:            "portal",
+		SessionTokenExpiry:   time.Minute,
+		SessionTokenAudience: "erp",
+	}, keyRing)
+}
+
+// contextOf runs RequireAuth on a request with the given headers and returns the
+// response status and the context it set for handlers
+func contextOf(t *testing.T, m *KongAuthMiddleware, headers map[string]string) (int, map[string]any) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var keys map[string]any
+	router := gin.New()
+	router.GET("/", m.RequireAuth(), m.DenyImpersonation(), func(c *gin.Context) {
+		keys = c.Keys
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code, keys
+}
+
+func TestRequireAuthWithSessionToken(t *testing.T) {
+	sessionTokens := newTestSessionTokens(t)
+	m := NewKongAuthMiddleware(sessionTokens)
+
+	sign := func(claims *model.SessionTokenClaims) string {
+		t.Helper()
+		token, _, err := sessionTokens.GenerateSessionToken(claims)
+		if err != nil {
+			t.Fatalf("GenerateSessionToken() error = %v", err)
+		}
+		return "Bearer " + token
+	}
+	member := sign(&model.SessionTokenClaims{UserID: 1, TenantID: 10, Roles: []string{"member"}, Permissions: []string{"users.read"}})
+	impersonated := sign(&model.SessionTokenClaims{UserID: 1, TenantID: 10, Roles: []string{"member"}, Actor: "user_99"})
+	restricted := sign(&model.SessionTokenClaims{UserID: 1, TenantID: 10, Restriction: "password_change"})
+	client := sign(&model.SessionTokenClaims{TenantID: 10, ClientID: "erp-sync"})
+
+	kongHeaders := func(authorization string, extra map[string]string) map[string]string {
+		headers := map[string]string{
+			"X-Authenticated": "true",
+			"X-Tenant-ID":     "10",
+			"X-User-ID":       "1",
+			"X-Role-Name":     "member",
+			"Authorization":   authorization,
+		}
+		for k, v := range extra {
+			headers[k] = v
+		}
+		return headers
+	}
+
+	tests := []struct {
+		name            string
+		headers         map[string]string
+		wantStatus      int
+		wantRole        string
+		wantPermissions []string
+	}{
+		{
+			name:            "headers from Kong",
+			headers:         kongHeaders(member, nil),
+			wantStatus:      http.StatusOK,
+			wantRole:        "member",
+			wantPermissions: []string{"users.read"},
+		},
+		{
+			name:       "no session token",
+			headers:    kongHeaders("", nil),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "session token of another user",
+			headers:    kongHeaders(member, map[string]string{"X-User-ID": "2"}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "session token of another tenant",
+			headers:    kongHeaders(member, map[string]string{"X-Tenant-ID": "20"}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "client token",
+			headers:    kongHeaders(client, map[string]string{"X-User-ID": "0"}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:            "forged role and permissions are ignored",
+			headers:         kongHeaders(member, map[string]string{"X-Role-Name": "admin", "X-Permissions": "users.delete"}),
+			wantStatus:      http.StatusOK,
+			wantRole:        "member",
+			wantPermissions: []string{"users.read"},
+		},
+		{
+			name:       "restriction cannot be dropped",
+			headers:    kongHeaders(restricted, nil),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "impersonation cannot be dropped",
+			headers:    kongHeaders(impersonated, nil),
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, keys := contextOf(t, m, tt.headers)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if status != http.StatusOK {
+				return
+			}
+
+			if keys["role_name"] != tt.wantRole {
+				t.Errorf("role_name = %v, want %q", keys["role_name"], tt.wantRole)
+			}
+			permissions, _ := keys["permissions"].([]string)
+			if len(permissions) != len(tt.wantPermissions) || (len(permissions) > 0 && permissions[0] != tt.wantPermissions[0]) {
+				t.Errorf("permissions = %v, want %v", permissions, tt.wantPermissions)
+			}
+		})
+	}
+}
+
+func TestRequireAuthWithoutSessionToken(t *testing.T) {
+	m := NewKongAuthMiddleware(nil)
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{"not authenticated", map[string]string{"X-Tenant-ID": "10", "X-User-ID": "1"}, http.StatusUnauthorized},
+		{"no tenant", map[string]string{"X-Authenticated": "true", "X-User-ID": "1"}, http.StatusUnauthorized},
+		{"invalid user", map[string]string{"X-Authenticated": "true", "X-Tenant-ID": "10", "X-User-ID": "one"}, http.StatusBadRequest},
+		{"API token", map[string]string{"X-Authenticated": "true", "X-Tenant-ID": "10", "X-User-ID": "1", "X-API-Token-ID": "5"}, http.StatusForbidden},
+		{"restricted session", map[string]string{"X-Authenticated": "true", "X-Tenant-ID": "10", "X-User-ID": "1", "X-Session-Restriction": "mfa_enrollment"}, http.StatusForbidden},
+		{"impersonated session", map[string]string{"X-Authenticated": "true", "X-Tenant-ID": "10", "X-User-ID": "1", "X-Impersonated-By": "99"}, http.StatusForbidden},
+		{"user session", map[string]string{"X-Authenticated": "true", "X-Tenant-ID": "10", "X-User-ID": "1"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _ := contextOf(t, m, tt.headers); status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-gin-clean/internal/delivery/http/response"
+	"go-gin-clean/internal/gateway/cache"
+	"go-gin-clean/pkg/config"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRateLimitBodyPeek bounds how much of the request body is read to find the email or token
+const maxRateLimitBodyPeek = 64 << 10
+
+// RateLimitKeyFunc returns what a rule counts requests by. An empty key skips the
+// rule for the request, e.g. when the body carries no email.
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// RateLimitByIP counts requests per client IP
+func RateLimitByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimitByEmail counts requests per "email" field of the JSON body, so one account
+// cannot be targeted from many IPs
+func RateLimitByEmail(c *gin.Context) string {
+	email := peekJSONField(c, "email")
+	if email == "" {
+		return ""
+	}
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// RateLimitByClientToken counts the requests of authenticated introspection clients
+// per client and token being introspected (the "token" form or JSON field): Kong
+// introspects the tokens of all its users from one address, so a per-IP limit would
+// cap all traffic behind it. authenticate reports the client of valid credentials.
+// Other callers are counted per client IP, so guessing tokens does not get a fresh
+// limit for every guess.
+func RateLimitByClientToken(authenticate func(c *gin.Context) (string, bool)) RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		clientID, ok := authenticate(c)
+		if !ok {
+			return RateLimitByIP(c)
+		}
+
+		var token string
+		switch {
+		case strings.HasPrefix(c.ContentType(), "application/json"):
+			token = peekJSONField(c, "token")
+		case c.ContentType() == "application/x-www-form-urlencoded":
+			token = c.PostForm("token")
+		}
+
+		token = strings.TrimSpace(token)
+		if token == "" {
+			return "client:" + clientID
+		}
+		hash := sha256.Sum256([]byte(token))
+		return "client:" + clientID + ":token:" + hex.EncodeToString(hash[:])
+	}
+}
+
+// RateLimitByTenant counts requests per tenant from the X-Tenant-ID header Kong injects
+func RateLimitByTenant(c *gin.Context) string {
+	tenantID := c.GetHeader("X-Tenant-ID")
+	if tenantID == "" {
+		return ""
+	}
+	return "tenant:" + tenantID
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// peekJSONField returns a string field of the JSON body, leaving the body intact for
+// the handler. It returns "" for other content types and malformed bodies.
+func peekJSONField(c *gin.Context, field string) string {
+	if c.Request.Body == nil || !strings.HasPrefix(c.ContentType(), "application/json") {
+		return ""
+	}
+
+	// Read the start of the body and put it back for the handler
+	peeked, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRateLimitBodyPeek))
+	if err != nil {
+		return ""
+	}
+	c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(peeked), c.Request.Body), c.Request.Body}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(peeked, &body); err != nil {
+		return ""
+	}
+	var value string
+	if err := json.Unmarshal(body[field], &value); err != nil {
+		return ""
+	}
+	return value
+}
+
+// RateLimitMiddleware limits request rates with limits shared by all instances
+// through Redis
+type RateLimitMiddleware struct {
+	limiter *cache.RateLimiter
+	enabled bool
+}
+
+func NewRateLimitMiddleware(limiter *cache.RateLimiter, enabled bool) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		limiter: limiter,
+		enabled: enabled,
+	}
+}
+
+// Limit allows rule.Limit requests per rule.Window for each key returned by keyFunc.
+// name separates the counters of different route groups. Refused requests get 429 with
+// Retry-After; every response carries the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers of the tightest limit it passed through. When Redis is
+// unavailable requests are let through, login lockout still protects the passwords.
+func (m *RateLimitMiddleware) Limit(name string, rule config.RateLimitRule, keyFunc RateLimitKeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.enabled || rule.Limit <= 0 {
+			c.Next()
+			return
+		}
+
+		key := keyFunc(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		result, err := m.limiter.Allow(c.Request.Context(), name+":"+key, rule.Limit, rule.Window)
+		if err != nil {
+			log.Println("Rate limit check failed:", err)
+			c.Next()
+			return
+		}
+
+		resetSeconds := strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds())))
+		setRateLimitHeaders(c, result, resetSeconds)
+
+		if !result.Allowed {
+			c.Header("Retry-After", resetSeconds)
+			response.Error(c, "too many requests", "rate limit exceeded, retry after "+resetSeconds+" seconds", http.StatusTooManyRequests)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// setRateLimitHeaders reports result unless an earlier limit on the route has fewer
+// requests left
+func setRateLimitHeaders(c *gin.Context, result *cache.RateLimitResult, resetSeconds string) {
+	if current := c.Writer.Header().Get("RateLimit-Remaining"); current != "" {
+		if remaining, err := strconv.Atoi(current); err == nil && remaining <= result.Remaining && result.Allowed {
+			return
+		}
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", resetSeconds)
+}
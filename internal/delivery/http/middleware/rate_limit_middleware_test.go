@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitByClientToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Only kong:secret is a valid introspection client
+	keyFunc := RateLimitByClientToken(func(c *gin.Context) (string, bool) {
+		id, secret, ok := c.Request.BasicAuth()
+		return id, ok && id == "kong" && secret == "secret"
+	})
+
+	keyOf := func(authenticated bool, contentType, body string) string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("Content-Type", contentType)
+		if authenticated {
+			req.SetBasicAuth("kong", "secret")
+		} else {
+			req.SetBasicAuth("kong", "guess")
+		}
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = req
+		return keyFunc(c)
+	}
+
+	tests := []struct {
+		name          string
+		authenticated bool
+		contentType   string
+		body          string
+		wantPrefix    string
+	}{
+		{"client with JSON token", true, "application/json", `{"token":"abc"}`, "client:kong:token:"},
+		{"client with form token", true, "application/x-www-form-urlencoded", "token=abc", "client:kong:token:"},
+		{"client without token", true, "application/json", `{}`, "client:kong"},
+		{"wrong credentials", false, "application/json", `{"token":"abc"}`, "ip:192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keyOf(tt.authenticated, tt.contentType, tt.body); !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("key = %q, want prefix %q", got, tt.wantPrefix)
+			}
+		})
+	}
+
+	// Guessing tokens without credentials stays in the caller's IP bucket
+	if keyOf(false, "application/json", `{"token":"a"}`) != keyOf(false, "application/json", `{"token":"b"}`) {
+		t.Error("unauthenticated guesses got separate keys")
+	}
+	// The same token has the same key whatever the encoding, and tokens don't share one
+	if keyOf(true, "application/json", `{"token":"abc"}`) != keyOf(true, "application/x-www-form-urlencoded", "token=abc") {
+		t.Error("JSON and form requests for the same token got separate keys")
+	}
+	if keyOf(true, "application/json", `{"token":"a"}`) == keyOf(true, "application/json", `{"token":"b"}`) {
+		t.Error("different tokens share a key")
+	}
+}
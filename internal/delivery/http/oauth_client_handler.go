@@ -0,0 +1,244 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-gin-clean/internal/delivery/http/response"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/usecase"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OAuthClientHandler struct {
+	oauthClientUseCase *usecase.OAuthClientUseCase
+}
+
+func NewOAuthClientHandler(oauthClientUseCase *usecase.OAuthClientUseCase) *OAuthClientHandler {
+	return &OAuthClientHandler{
+		oauthClientUseCase: oauthClientUseCase,
+	}
+}
+
+// ListClients handles GET /api/v1/admin/oauth-clients
+// @Summary List OAuth clients
+// @Description Registered OAuth clients, newest first (platform admins only)
+// @Tags Platform Admin
+// @Produce json
+// @Param page query int false "Page"
+// @Param per_page query int false "Entries per page"
+// @Success 200 {array} model.OAuthClientResponse "OAuth clients"
+// @Failure 403 {object} response.ErrorResponse "Not a platform admin"
+// @Router /admin/oauth-clients [get]
+func (h *OAuthClientHandler) ListClients(c *gin.Context) {
+	requestorUserID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.PaginationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.Error(c, "Failed to bind query", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PerPage <= 0 {
+		req.PerPage = 10
+	}
+
+	result, err := h.oauthClientUseCase.ListClients(c.Request.Context(), requestorUserID.(int64), req.Page, req.PerPage)
+	if err == errors.ErrNotPlatformAdmin {
+		response.Error(c, "Failed to list OAuth clients", err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		response.Error(c, "Failed to list OAuth clients", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response.SuccessPagination(c, result.Data, response.SetMeta(req.Page, req.PerPage, result.Total, result.TotalPages))
+}
+
+// CreateClient handles POST /api/v1/admin/oauth-clients
+// @Summary Register OAuth client
+// @Description Registers a client for a tenant or, without tenant_id, for the whole platform. The client secret is only shown in this response; public clients get none
+// @Tags Platform Admin
+// @Accept json
+// @Produce json
+// @Param request body model.OAuthClientRequest true "Client details"
+// @Success 201 {object} model.OAuthClientResponse "Client registered"
+// @Failure 400 {object} response.ErrorResponse "Invalid request"
+// @Failure 403 {object} response.ErrorResponse "Not a platform admin"
+// @Failure 404 {object} response.ErrorResponse "Tenant not found"
+// @Router /admin/oauth-clients [post]
+func (h *OAuthClientHandler) CreateClient(c *gin.Context) {
+	requestorUserID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.OAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client, err := h.oauthClientUseCase.CreateClient(c.Request.Context(), requestorUserID.(int64), &req)
+	if err == errors.ErrNotPlatformAdmin {
+		response.Error(c, "Failed to register OAuth client", err.Error(), http.StatusForbidden)
+		return
+	}
+	if err == errors.ErrOAuthClientScopesInvalid || err == errors.ErrOAuthRedirectURIRequired {
+		response.Error(c, "Failed to register OAuth client", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err == errors.ErrTenantNotFound {
+		response.Error(c, "Failed to register OAuth client", err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		response.Error(c, "Failed to register OAuth client", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "OAuth client registered, copy the secret now as it will not be shown again", client, http.StatusCreated)
+}
+
+// RotateSecret handles POST /api/v1/admin/oauth-clients/:id/rotate-secret
+// @Summary Rotate OAuth client secret
+// @Description Issues a new client secret and revokes the client's access tokens. The secret is only shown in this response
+// @Tags Platform Admin
+// @Produce json
+// @Param id path int true "Client PKID"
+// @Success 200 {object} model.OAuthClientResponse "New secret"
+// @Failure 400 {object} response.ErrorResponse "Public client"
+// @Failure 403 {object} response.ErrorResponse "Not a platform admin"
+// @Failure 404 {object} response.ErrorResponse "Client not found"
+// @Router /admin/oauth-clients/{id}/rotate-secret [post]
+func (h *OAuthClientHandler) RotateSecret(c *gin.Context) {
+	requestorUserID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, "invalid client ID", "", http.StatusBadRequest)
+		return
+	}
+
+	client, err := h.oauthClientUseCase.RotateSecret(c.Request.Context(), requestorUserID.(int64), id)
+	if err == errors.ErrNotPlatformAdmin {
+		response.Error(c, "Failed to rotate client secret", err.Error(), http.StatusForbidden)
+		return
+	}
+	if err == errors.ErrOAuthClientNotFound {
+		response.Error(c, "Failed to rotate client secret", err.Error(), http.StatusNotFound)
+		return
+	}
+	if err == errors.ErrOAuthPublicClient {
+		response.Error(c, "Failed to rotate client secret", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		response.Error(c, "Failed to rotate client secret", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "Client secret rotated, copy it now as it will not be shown again", client, http.StatusOK)
+}
+
+// UpdateStatus handles PUT /api/v1/admin/oauth-clients/:id/status
+// @Summary Enable or disable OAuth client
+// @Description Disabling a client revokes its access tokens
+// @Tags Platform Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Client PKID"
+// @Param request body model.OAuthClientStatusRequest true "New status"
+// @Success 200 {object} response.SuccessResponse "Status updated"
+// @Failure 403 {object} response.ErrorResponse "Not a platform admin"
+// @Failure 404 {object} response.ErrorResponse "Client not found"
+// @Router /admin/oauth-clients/{id}/status [put]
+func (h *OAuthClientHandler) UpdateStatus(c *gin.Context) {
+	requestorUserID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, "invalid client ID", "", http.StatusBadRequest)
+		return
+	}
+
+	var req model.OAuthClientStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.oauthClientUseCase.UpdateStatus(c.Request.Context(), requestorUserID.(int64), id, *req.IsActive)
+	if err == errors.ErrNotPlatformAdmin {
+		response.Error(c, "Failed to update client status", err.Error(), http.StatusForbidden)
+		return
+	}
+	if err == errors.ErrOAuthClientNotFound {
+		response.Error(c, "Failed to update client status", err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		response.Error(c, "Failed to update client status", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "Client status updated", nil, http.StatusOK)
+}
+
+// DeleteClient handles DELETE /api/v1/admin/oauth-clients/:id
+// @Summary Delete OAuth client
+// @Description Removes the client and revokes its access tokens
+// @Tags Platform Admin
+// @Produce json
+// @Param id path int true "Client PKID"
+// @Success 200 {object} response.SuccessResponse "Client deleted"
+// @Failure 403 {object} response.ErrorResponse "Not a platform admin"
+// @Failure 404 {object} response.ErrorResponse "Client not found"
+// @Router /admin/oauth-clients/{id} [delete]
+func (h *OAuthClientHandler) DeleteClient(c *gin.Context) {
+	requestorUserID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, "invalid client ID", "", http.StatusBadRequest)
+		return
+	}
+
+	err = h.oauthClientUseCase.DeleteClient(c.Request.Context(), requestorUserID.(int64), id)
+	if err == errors.ErrNotPlatformAdmin {
+		response.Error(c, "Failed to delete OAuth client", err.Error(), http.StatusForbidden)
+		return
+	}
+	if err == errors.ErrOAuthClientNotFound {
+		response.Error(c, "Failed to delete OAuth client", err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		response.Error(c, "Failed to delete OAuth client", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "OAuth client deleted", nil, http.StatusOK)
+}
@@ -4,6 +4,7 @@ import (
 	"go-gin-clean/internal/delivery/http/response"
 	"go-gin-clean/internal/model"
 	"go-gin-clean/internal/usecase"
+	"go-gin-clean/pkg/errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +20,17 @@ func NewOAuthHandler(userUseCase *usecase.UserUseCase) *OAuthHandler {
 	}
 }
 
+// ListProviders handles GET /api/v1/auth/oauth2/providers
+// @Summary List identity providers
+// @Description Names of the configured external identity providers, for login buttons
+// @Tags OAuth
+// @Produce json
+// @Success 200 {object} model.OAuthProvidersResponse "Configured providers"
+// @Router /auth/oauth2/providers [get]
+func (h *OAuthHandler) ListProviders(c *gin.Context) {
+	response.Success(c, "OAuth providers retrieved", h.userUseCase.ListOAuthProviders(), http.StatusOK)
+}
+
 func (h *OAuthHandler) GetLoginURL(c *gin.Context) {
 	var req model.OAuthLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -27,6 +39,10 @@ func (h *OAuthHandler) GetLoginURL(c *gin.Context) {
 	}
 
 	urlResp, err := h.userUseCase.GetOAuthLoginURL(c.Request.Context(), req.Provider, req.AppID)
+	if err == errors.ErrInvalidOAuthProvider {
+		response.Error(c, "Failed to generate OAuth URL", err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		response.Error(c, "Failed to generate OAuth URL", err.Error(), http.StatusInternalServerError)
 		return
@@ -35,33 +51,24 @@ func (h *OAuthHandler) GetLoginURL(c *gin.Context) {
 	response.Success(c, "OAuth URL generated successfully", urlResp, http.StatusOK)
 }
 
+// CallBack handles GET /api/v1/auth/oauth2/:provider/callback
+// @Summary External login callback
+// @Description Redirect target of the identity provider. Sends the browser to the app's frontend with a one-time login code to exchange at /auth/oauth2/exchange, or with error and error_description
+// @Tags OAuth
+// @Param provider path string true "Provider name"
+// @Param code query string false "Authorization code"
+// @Param state query string true "State from the login URL"
+// @Param error query string false "Set by the provider when the login failed"
+// @Success 302 "Redirect to the frontend"
+// @Router /auth/oauth2/{provider}/callback [get]
 func (h *OAuthHandler) CallBack(c *gin.Context) {
-	provider := c.Param("provider")
-	code := c.Query("code")
-	state := c.Query("state")
-	appId := c.Query("app_id")
-
-	if code == "" || state == "" {
-		response.Error(c, "Invalid callback", "Missing code or state in callback", http.StatusBadRequest)
-		return
-	}
-
 	req := &model.OAuthCallbackRequest{
-		Provider: provider,
-		Code:     code,
-		State:    state,
-		AppID:    appId,
-	}
-
-	loginResp, appID, err := h.userUseCase.HandleOAuthCallback(c.Request.Context(), req)
-	if err != nil {
-		response.Error(c, "OAuth authentication failed", err.Error(), http.StatusUnauthorized)
-		return
+		Provider: c.Param("provider"),
+		Code:     c.Query("code"),
+		State:    c.Query("state"),
+		AppID:    c.Query("app_id"),
+		Error:    c.Query("error"),
 	}
 
-	response.Success(c, "OAuth authenticated successfully", gin.H{
-		"login_response": loginResp,
-		"app_id":         appID,
-		"redirect_to":    "/dashboard",
-	}, http.StatusOK)
+	c.Redirect(http.StatusFound, h.userUseCase.HandleOAuthCallback(c.Request.Context(), req))
 }
@@ -0,0 +1,276 @@
+package http
+
+import (
+	"net/http"
+
+	"go-gin-clean/internal/delivery/http/response"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/usecase"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCHandler serves the portal's OAuth2 / OpenID Connect provider endpoints
+type OIDCHandler struct {
+	oidcUseCase *usecase.OIDCUseCase
+}
+
+func NewOIDCHandler(oidcUseCase *usecase.OIDCUseCase) *OIDCHandler {
+	return &OIDCHandler{
+		oidcUseCase: oidcUseCase,
+	}
+}
+
+// Authorize handles GET /oauth2/authorize (OpenID Connect authorization code flow)
+// The browser is sent to the consent page of the portal frontend, or back to the
+// client with an error. Unknown clients and redirect URIs get a 400 instead.
+// @Summary Authorization endpoint
+// @Tags OAuth2
+// @Produce json
+// @Param response_type query string true "code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param scope query string true "Space separated, must include openid"
+// @Param state query string false "Opaque value returned to the client"
+// @Param nonce query string false "Echoed in the ID token"
+// @Param prompt query string false "consent to ask the user again"
+// @Param code_challenge query string true "PKCE challenge"
+// @Param code_challenge_method query string true "S256"
+// @Success 302 "Redirect to the consent page or the client"
+// @Failure 400 {object} model.OAuthErrorResponse "Unknown client or redirect URI"
+// @Router /oauth2/authorize [get]
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	var req model.OAuthAuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.OAuthErrorResponse{
+			Error:            "invalid_request",
+			ErrorDescription: "response_type, client_id and redirect_uri are required",
+		})
+		return
+	}
+
+	target, err := h.oidcUseCase.Authorize(c.Request.Context(), &req)
+	if err == errors.ErrOAuthClientInvalid || err == errors.ErrOAuthRedirectURIInvalid {
+		c.JSON(http.StatusBadRequest, model.OAuthErrorResponse{
+			Error:            "invalid_request",
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.OAuthErrorResponse{
+			Error: "server_error",
+		})
+		return
+	}
+
+	c.Redirect(http.StatusFound, target)
+}
+
+// GetAuthorizationRequest handles GET /oauth2/authorize/requests/:id
+// @Summary Get pending authorization request
+// @Description Shown on the consent page. Uses the portal session of the user approving the client
+// @Tags OAuth2
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Request ID from the consent page URL"
+// @Success 200 {object} model.AuthorizationRequestInfo "Client and scopes"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Limited or impersonated session, or not a member of the client's tenant"
+// @Failure 404 {object} response.ErrorResponse "Request expired"
+// @Router /oauth2/authorize/requests/{id} [get]
+func (h *OIDCHandler) GetAuthorizationRequest(c *gin.Context) {
+	refToken, ok := bearerToken(c)
+	if !ok {
+		response.Error(c, "Missing authorization header", "", http.StatusUnauthorized)
+		return
+	}
+
+	info, err := h.oidcUseCase.GetAuthorizationRequest(c.Request.Context(), refToken, c.Param("id"))
+	if err == errors.ErrSessionNotFound {
+		response.Error(c, "Failed to load authorization request", err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err == errors.ErrMFAEnrollmentRequired || err == errors.ErrPasswordExpired || err == errors.ErrImpersonationNotAllowed || err == errors.ErrOAuthAccessDenied {
+		response.Error(c, "Failed to load authorization request", err.Error(), http.StatusForbidden)
+		return
+	}
+	if err == errors.ErrOAuthAuthorizationRequestInvalid || err == errors.ErrTenantNotFound {
+		response.Error(c, "Failed to load authorization request", err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		response.Error(c, "Failed to load authorization request", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "Authorization request retrieved", info, http.StatusOK)
+}
+
+// DecideAuthorization handles POST /oauth2/authorize/requests/:id
+// @Summary Approve or deny authorization request
+// @Description Returns the client URL to send the browser to, carrying an authorization code or access_denied
+// @Tags OAuth2
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "Request ID from the consent page URL"
+// @Param request body model.AuthorizationDecisionRequest true "Decision"
+// @Success 200 {object} model.AuthorizationDecisionResponse "Where to redirect"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Limited or impersonated session, or not a member of the client's tenant"
+// @Failure 404 {object} response.ErrorResponse "Request expired or already decided"
+// @Router /oauth2/authorize/requests/{id} [post]
+func (h *OIDCHandler) DecideAuthorization(c *gin.Context) {
+	refToken, ok := bearerToken(c)
+	if !ok {
+		response.Error(c, "Missing authorization header", "", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.AuthorizationDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	decision, err := h.oidcUseCase.DecideAuthorization(c.Request.Context(), refToken, c.Param("id"), *req.Approve)
+	if err == errors.ErrSessionNotFound {
+		response.Error(c, "Failed to decide authorization request", err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err == errors.ErrMFAEnrollmentRequired || err == errors.ErrPasswordExpired || err == errors.ErrImpersonationNotAllowed || err == errors.ErrOAuthAccessDenied {
+		response.Error(c, "Failed to decide authorization request", err.Error(), http.StatusForbidden)
+		return
+	}
+	if err == errors.ErrOAuthAuthorizationRequestInvalid || err == errors.ErrTenantNotFound {
+		response.Error(c, "Failed to decide authorization request", err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		response.Error(c, "Failed to decide authorization request", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "Authorization request decided", decision, http.StatusOK)
+}
+
+// Token handles POST /oauth2/token (RFC 6749)
+// Confidential clients authenticate with HTTP Basic or client_id/client_secret form
+// fields; public clients send client_id only and prove the code with PKCE.
+// @Summary Token endpoint
+// @Tags OAuth2
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code, refresh_token or client_credentials"
+// @Param code formData string false "Authorization code"
+// @Param redirect_uri formData string false "Redirect URI of the authorization request"
+// @Param code_verifier formData string false "PKCE verifier"
+// @Param refresh_token formData string false "Refresh token"
+// @Param scope formData string false "client_credentials: space separated scopes, defaults to all allowed"
+// @Success 200 {object} model.OAuthTokenResponse "Access token"
+// @Failure 400 {object} model.OAuthErrorResponse "invalid_request, invalid_grant, unsupported_grant_type or invalid_scope"
+// @Failure 401 {object} model.OAuthErrorResponse "invalid_client"
+// @Router /oauth2/token [post]
+func (h *OIDCHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req model.OAuthTokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.OAuthErrorResponse{
+			Error:            "invalid_request",
+			ErrorDescription: "grant_type is required",
+		})
+		return
+	}
+	req.UserAgent = c.GetHeader("User-Agent")
+	req.ClientIP = c.ClientIP()
+
+	clientID, clientSecret, _ := clientCredentials(c)
+	token, err := h.oidcUseCase.Token(c.Request.Context(), clientID, clientSecret, &req)
+	if err == errors.ErrOAuthClientInvalid {
+		c.Header("WWW-Authenticate", `Basic realm="token"`)
+		c.JSON(http.StatusUnauthorized, model.OAuthErrorResponse{
+			Error:            "invalid_client",
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+	if err == errors.ErrOAuthGrantUnsupported {
+		c.JSON(http.StatusBadRequest, model.OAuthErrorResponse{
+			Error:            "unsupported_grant_type",
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+	if err == errors.ErrOAuthScopeInvalid {
+		c.JSON(http.StatusBadRequest, model.OAuthErrorResponse{
+			Error:            "invalid_scope",
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+	// The code or refresh token can no longer produce a session for the user
+	if err == errors.ErrOAuthGrantInvalid ||
+		err == errors.ErrRefreshTokenInvalid ||
+		err == errors.ErrRefreshTokenReused ||
+		err == errors.ErrSessionLifetimeExceeded ||
+		err == errors.ErrUserInactive ||
+		err == errors.ErrOAuthAccessDenied ||
+		err == errors.ErrPasswordExpired ||
+		err == errors.ErrMFAEnrollmentRequired {
+		c.JSON(http.StatusBadRequest, model.OAuthErrorResponse{
+			Error:            "invalid_grant",
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.OAuthErrorResponse{
+			Error: "server_error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// UserInfo handles GET and POST /oauth2/userinfo (OpenID Connect Core section 5.3)
+// @Summary UserInfo endpoint
+// @Tags OAuth2
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} model.OAuthUserInfoResponse "Claims about the user"
+// @Failure 401 {object} model.OAuthErrorResponse "invalid_token"
+// @Router /oauth2/userinfo [get]
+func (h *OIDCHandler) UserInfo(c *gin.Context) {
+	accessToken, ok := bearerToken(c)
+	if !ok {
+		c.Header("WWW-Authenticate", `Bearer realm="userinfo"`)
+		c.JSON(http.StatusUnauthorized, model.OAuthErrorResponse{
+			Error: "invalid_token",
+		})
+		return
+	}
+
+	userInfo, err := h.oidcUseCase.UserInfo(c.Request.Context(), accessToken)
+	if err == errors.ErrOAuthTokenInvalid {
+		c.Header("WWW-Authenticate", `Bearer realm="userinfo", error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, model.OAuthErrorResponse{
+			Error:            "invalid_token",
+			ErrorDescription: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.OAuthErrorResponse{
+			Error: "server_error",
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, userInfo)
+}
@@ -0,0 +1,185 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"go-gin-clean/internal/delivery/http/response"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/usecase"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasskeyHandler struct {
+	passkeyUseCase *usecase.PasskeyUseCase
+}
+
+func NewPasskeyHandler(passkeyUseCase *usecase.PasskeyUseCase) *PasskeyHandler {
+	return &PasskeyHandler{
+		passkeyUseCase: passkeyUseCase,
+	}
+}
+
+// ListPasskeys handles GET /api/v1/mfa/passkeys
+// @Summary List passkeys
+// @Description Returns the passkeys registered by the current user
+// @Tags MFA
+// @Produce json
+// @Success 200 {array} model.PasskeyResponse "Passkeys"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /mfa/passkeys [get]
+func (h *PasskeyHandler) ListPasskeys(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	passkeys, err := h.passkeyUseCase.ListPasskeys(c.Request.Context(), userID.(int64))
+	if err != nil {
+		response.Error(c, "failed to list passkeys", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "Passkeys retrieved successfully", passkeys, http.StatusOK)
+}
+
+// BeginRegistration handles POST /api/v1/mfa/passkeys/register/begin
+// @Summary Start passkey registration
+// @Description Returns WebAuthn options for navigator.credentials.create(). Requires the password.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param request body model.BeginPasskeyRegistrationRequest true "Password"
+// @Success 200 {object} model.PasskeyCeremonyResponse "Registration options"
+// @Failure 400 {object} response.ErrorResponse "Invalid password"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 429 {object} response.ErrorResponse "Too many failed attempts"
+// @Router /mfa/passkeys/register/begin [post]
+func (h *PasskeyHandler) BeginRegistration(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.BeginPasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ceremony, err := h.passkeyUseCase.BeginRegistration(c.Request.Context(), userID.(int64), req.Password)
+	if err == errors.ErrAccountLocked || err == errors.ErrTooManyLoginAttempts {
+		response.Error(c, "failed to start passkey registration", err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if err == errors.ErrInvalidCredentials {
+		response.Error(c, "failed to start passkey registration", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		response.Error(c, "failed to start passkey registration", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "Passkey registration started", ceremony, http.StatusOK)
+}
+
+// FinishRegistration handles POST /api/v1/mfa/passkeys/register/finish
+// @Summary Finish passkey registration
+// @Description Verifies the credential created by the authenticator and stores the passkey
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param request body model.FinishPasskeyRegistrationRequest true "Ceremony token, name and credential"
+// @Success 201 {object} model.PasskeyResponse "Passkey registered"
+// @Failure 400 {object} response.ErrorResponse "Invalid or expired registration"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 409 {object} response.ErrorResponse "Passkey already registered"
+// @Router /mfa/passkeys/register/finish [post]
+func (h *PasskeyHandler) FinishRegistration(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.FinishPasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	passkey, err := h.passkeyUseCase.FinishRegistration(c.Request.Context(), userID.(int64), &req)
+	if err == errors.ErrPasskeyInvalid || err == errors.ErrPasskeyCeremonyInvalid {
+		response.Error(c, "failed to register passkey", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err == errors.ErrPasskeyExists {
+		response.Error(c, "failed to register passkey", err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		response.Error(c, "failed to register passkey", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "Passkey registered", passkey, http.StatusCreated)
+}
+
+// DeletePasskey handles DELETE /api/v1/mfa/passkeys/:id
+// @Summary Delete passkey
+// @Description Removes one of the current user's passkeys. Requires the password.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param id path int true "Passkey ID"
+// @Param request body model.DeletePasskeyRequest true "Password"
+// @Success 200 {object} response.SuccessResponse "Passkey deleted"
+// @Failure 400 {object} response.ErrorResponse "Invalid password"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Passkey not found"
+// @Failure 429 {object} response.ErrorResponse "Too many failed attempts"
+// @Router /mfa/passkeys/{id} [delete]
+func (h *PasskeyHandler) DeletePasskey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	passkeyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, "invalid passkey ID", "", http.StatusBadRequest)
+		return
+	}
+
+	var req model.DeletePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, "Invalid request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.passkeyUseCase.DeletePasskey(c.Request.Context(), userID.(int64), passkeyID, req.Password)
+	if err == errors.ErrAccountLocked || err == errors.ErrTooManyLoginAttempts {
+		response.Error(c, "failed to delete passkey", err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if err == errors.ErrInvalidCredentials {
+		response.Error(c, "failed to delete passkey", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err == errors.ErrPasskeyNotFound {
+		response.Error(c, "failed to delete passkey", err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		response.Error(c, "failed to delete passkey", err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response.Success(c, "Passkey deleted", nil, http.StatusOK)
+}
@@ -3,6 +3,10 @@ package route
 import (
 	"go-gin-clean/internal/delivery/http"
 	"go-gin-clean/internal/delivery/http/middleware"
+	"go-gin-clean/internal/gateway/cache"
+	"go-gin-clean/internal/gateway/security"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/config"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,49 +19,126 @@ func SetupRoutes(
 	authHandler *http.AuthHandler,
 	userManagementHandler *http.UserManagementHandler,
 	introspectionHandler *http.IntrospectionHandler,
+	wellKnownHandler *http.WellKnownHandler,
+	mfaHandler *http.MFAHandler,
+	passkeyHandler *http.PasskeyHandler,
+	apiTokenHandler *http.APITokenHandler,
+	oauthClientHandler *http.OAuthClientHandler,
+	oidcHandler *http.OIDCHandler,
+	rateLimiter *cache.RateLimiter,
+	sessionTokens *security.JWTService,
+	rateLimits config.RateLimitConfig,
 	allowedOrigins []string,
 ) {
 	// Setup Kong auth middleware (reads headers injected by Kong)
-	kongAuth := middleware.NewKongAuthMiddleware()
+	kongAuth := middleware.NewKongAuthMiddleware(sessionTokens)
+
+	// Setup rate limiting (limits are declared per route group below)
+	rateLimit := middleware.NewRateLimitMiddleware(rateLimiter, rateLimits.Enabled)
+	introspectLimit := rateLimit.Limit("introspect", rateLimits.Introspect, middleware.RateLimitByClientToken(introspectionHandler.AuthenticatedClient))
+	tenantLimit := rateLimit.Limit("tenant", rateLimits.Tenant, middleware.RateLimitByTenant)
 
 	// Setup CORS
 	router.Use(middleware.CORS(allowedOrigins))
 
+	// Public discovery documents (served at the root, as verifiers expect)
+	wellKnown := router.Group("/.well-known")
+	{
+		wellKnown.GET("/jwks.json", wellKnownHandler.JWKS)
+		wellKnown.GET("/openid-configuration", wellKnownHandler.OpenIDConfiguration)
+	}
+
 	// API routes
 	api := router.Group("/api/v1")
 	{
 		// Public routes (auth)
 		auth := api.Group("/auth")
 		{
-			// Legacy JWT-based login (keep for backward compatibility)
-			auth.POST("/login", userHandler.Login)
+			// Password, second factor and passkey logins
+			// (limited per client IP, and per account where the body names one)
+			login := auth.Group("",
+				rateLimit.Limit("login", rateLimits.Login, middleware.RateLimitByIP),
+				rateLimit.Limit("login_account", rateLimits.LoginAccount, middleware.RateLimitByEmail),
+			)
+			{
+				// Legacy JWT-based login (keep for backward compatibility)
+				login.POST("/login", userHandler.Login)
 
-			// New phantom token authentication endpoints
-			auth.POST("/phantom-login", authHandler.Login)
-			auth.POST("/select-tenant", authHandler.SelectTenant)
+				// New phantom token authentication endpoints
+				login.POST("/phantom-login", authHandler.Login)
+				login.POST("/select-tenant", authHandler.SelectTenant)
+				login.POST("/mfa/verify", authHandler.VerifyMFA)
+				login.POST("/mfa/passkey/begin", authHandler.BeginMFAPasskey)
+				login.POST("/mfa/passkey/verify", authHandler.VerifyMFAPasskey)
+				login.POST("/passkey/login/begin", authHandler.BeginPasskeyLogin)
+				login.POST("/passkey/login/finish", authHandler.PasskeyLogin)
+			}
+
+			auth.POST("/switch-tenant", authHandler.SwitchTenant)
 			auth.POST("/logout", authHandler.Logout)
-			auth.POST("/refresh", authHandler.RefreshSession)
 			auth.GET("/session", authHandler.GetSession)
 
+			// Self-service session management (list / revoke own sessions)
+			auth.GET("/sessions", authHandler.ListSessions)
+			auth.DELETE("/sessions", authHandler.RevokeAllSessions)
+			auth.DELETE("/sessions/:id", authHandler.RevokeSession)
+
 			// Kong introspection endpoint (called by Kong to validate phantom tokens)
 			// This endpoint validates the reference token and returns session context as headers
-			auth.POST("/introspect", introspectionHandler.Introspect)
+			auth.POST("/introspect", introspectLimit, introspectionHandler.Introspect)
 
 			// Registration
-			auth.POST("/register", registrationHandler.RegisterWithTenant)
-
-			// Other auth endpoints
-			auth.POST("/refresh-token", userHandler.RefreshToken)
-			auth.POST("/verify-email", userHandler.VerifyEmail)
-			auth.POST("/reset-password", userHandler.ResetPassword)
-			auth.POST("/send-reset-password", userHandler.SendResetPassword)
-			auth.POST("/resend-verification", userHandler.SendVerifyEmail)
+			register := auth.Group("", rateLimit.Limit("register", rateLimits.Register, middleware.RateLimitByIP))
+			{
+				register.POST("/register", registrationHandler.RegisterWithTenant)
+			}
+
+			// Endpoints redeeming a refresh, verification or reset token
+			token := auth.Group("", rateLimit.Limit("token", rateLimits.Token, middleware.RateLimitByIP))
+			{
+				token.POST("/refresh", authHandler.RefreshSession)
+				token.POST("/refresh-token", userHandler.RefreshToken)
+				token.POST("/verify-email", userHandler.VerifyEmail)
+				token.POST("/reset-password", userHandler.ResetPassword)
+			}
+
+			// Endpoints sending email (limited per client IP and per recipient)
+			email := auth.Group("",
+				rateLimit.Limit("email", rateLimits.Email, middleware.RateLimitByIP),
+				rateLimit.Limit("email_account", rateLimits.EmailAccount, middleware.RateLimitByEmail),
+			)
+			{
+				email.POST("/send-reset-password", userHandler.SendResetPassword)
+				email.POST("/resend-verification", userHandler.SendVerifyEmail)
+			}
+		}
+
+		// OAuth2 endpoints for resource servers and standard clients
+		oauth2 := api.Group("/oauth2")
+		{
+			// RFC 7662 token introspection, callers authenticate with client credentials
+			oauth2.POST("/introspect", introspectLimit, introspectionHandler.OAuthIntrospect)
+			oauth2.GET("/introspect/stats", introspectionHandler.CacheStats)
+
+			// Token endpoint for registered clients (authorization code, refresh token and
+			// client credentials grants)
+			oauth2.POST("/token", rateLimit.Limit("oauth_token", rateLimits.Token, middleware.RateLimitByIP), oidcHandler.Token)
+
+			// OpenID Connect provider for ERP frontends and third-party tools. The consent
+			// page calls the request endpoints with the user's own session.
+			oauth2.GET("/authorize", oidcHandler.Authorize)
+			oauth2.GET("/authorize/requests/:id", oidcHandler.GetAuthorizationRequest)
+			oauth2.POST("/authorize/requests/:id", oidcHandler.DecideAuthorization)
+			oauth2.GET("/userinfo", oidcHandler.UserInfo)
+			oauth2.POST("/userinfo", oidcHandler.UserInfo)
 		}
 
 		oauth := auth.Group("/oauth2")
 		{
+			oauth.GET("/providers", oauthHandler.ListProviders)
 			oauth.POST("/url", oauthHandler.GetLoginURL)
 			oauth.GET("/:provider/callback", oauthHandler.CallBack)
+			oauth.POST("/exchange", rateLimit.Limit("oauth_exchange", rateLimits.Token, middleware.RateLimitByIP), authHandler.OAuthLogin)
 		}
 
 		profile := api.Group("/profile")
@@ -65,8 +146,45 @@ func SetupRoutes(
 		{
 			profile.GET("", userHandler.Profile)
 			profile.PUT("", userHandler.UpdateProfile)
-			profile.PUT("/change-password", userHandler.ChangePassword)
 			profile.POST("/logout", userHandler.Logout)
+
+			// Audit trail of platform admins who impersonated me
+			profile.GET("/impersonations", authHandler.ListImpersonations)
+		}
+
+		// Password change
+		// (also reachable with the limited session of users whose password expired,
+		// never by an admin impersonating the user)
+		password := api.Group("/profile")
+		password.Use(kongAuth.RequireAuth(model.SessionRestrictionPasswordChange), kongAuth.DenyImpersonation())
+		{
+			password.PUT("/change-password", userHandler.ChangePassword)
+		}
+
+		// Personal access tokens for integrations
+		// (never managed by an admin impersonating the user)
+		apiTokens := api.Group("/profile/api-tokens")
+		apiTokens.Use(kongAuth.RequireAuth(), kongAuth.DenyImpersonation())
+		{
+			apiTokens.GET("", apiTokenHandler.ListTokens)
+			apiTokens.POST("", apiTokenHandler.CreateToken)
+			apiTokens.DELETE("/:id", apiTokenHandler.RevokeToken)
+		}
+
+		// Self-service second factor management
+		// (also reachable with the limited session of members who must enroll first)
+		mfa := api.Group("/mfa")
+		mfa.Use(kongAuth.RequireAuth(model.SessionRestrictionMFAEnrollment), kongAuth.DenyImpersonation())
+		{
+			mfa.GET("", mfaHandler.GetStatus)
+			mfa.POST("/totp", mfaHandler.EnrollTOTP)
+			mfa.POST("/totp/confirm", mfaHandler.ConfirmTOTP)
+			mfa.POST("/totp/disable", mfaHandler.DisableTOTP)
+			mfa.POST("/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+			mfa.GET("/passkeys", passkeyHandler.ListPasskeys)
+			mfa.POST("/passkeys/register/begin", passkeyHandler.BeginRegistration)
+			mfa.POST("/passkeys/register/finish", passkeyHandler.FinishRegistration)
+			mfa.DELETE("/passkeys/:id", passkeyHandler.DeletePasskey)
 		}
 
 		// ====================
@@ -74,7 +192,7 @@ func SetupRoutes(
 		// All requests must come through Kong API Gateway
 		// ====================
 		users := api.Group("/users")
-		users.Use(kongAuth.RequireAuth())
+		users.Use(kongAuth.RequireAuth(), tenantLimit)
 		{
 			// User management - Get own profile
 			users.GET("/me", userManagementHandler.GetMyProfile)
@@ -92,7 +210,7 @@ func SetupRoutes(
 
 		// Membership management
 		memberships := api.Group("/memberships")
-		memberships.Use(kongAuth.RequireAuth())
+		memberships.Use(kongAuth.RequireAuth(), tenantLimit)
 		{
 			memberships.POST("", userManagementHandler.AssignUserToTenant)
 			memberships.DELETE("", userManagementHandler.RemoveUserFromTenant)
@@ -101,11 +219,30 @@ func SetupRoutes(
 
 		// Tenant management
 		tenants := api.Group("/tenants")
-		tenants.Use(kongAuth.RequireAuth())
+		tenants.Use(kongAuth.RequireAuth(), tenantLimit)
 		{
 			tenants.GET("/:id/members", userManagementHandler.GetTenantMembers)
 			tenants.GET("/:id/roles", userManagementHandler.GetTenantRoles)
 			tenants.PUT("/:id", userManagementHandler.UpdateTenant)
+			tenants.PUT("/:id/mfa-policy", userManagementHandler.UpdateMFAPolicy)
+			tenants.GET("/:id/mfa-compliance", userManagementHandler.GetMFACompliance)
+			tenants.GET("/:id/password-policy", userManagementHandler.GetPasswordPolicy)
+			tenants.PUT("/:id/password-policy", userManagementHandler.UpdatePasswordPolicy)
+			tenants.POST("/:id/members/:user_id/unlock", userManagementHandler.UnlockMember)
+		}
+
+		// Platform administration (Super Administrators of the system tenant)
+		admin := api.Group("/admin")
+		admin.Use(kongAuth.RequireAuth(), kongAuth.DenyImpersonation())
+		{
+			admin.POST("/impersonations", authHandler.Impersonate)
+
+			// OAuth clients, e.g. ERP service accounts
+			admin.GET("/oauth-clients", oauthClientHandler.ListClients)
+			admin.POST("/oauth-clients", oauthClientHandler.CreateClient)
+			admin.POST("/oauth-clients/:id/rotate-secret", oauthClientHandler.RotateSecret)
+			admin.PUT("/oauth-clients/:id/status", oauthClientHandler.UpdateStatus)
+			admin.DELETE("/oauth-clients/:id", oauthClientHandler.DeleteClient)
 		}
 	}
 
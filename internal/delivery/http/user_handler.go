@@ -4,6 +4,7 @@ import (
 	"go-gin-clean/internal/delivery/http/response"
 	"go-gin-clean/internal/model"
 	"go-gin-clean/internal/usecase"
+	"go-gin-clean/pkg/errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -25,8 +26,13 @@ func (h *UserHandler) Login(c *gin.Context) {
 		response.Error(c, "Failed to bind body", err.Error(), http.StatusBadRequest)
 		return
 	}
+	req.ClientIP = c.ClientIP()
 
 	result, err := h.userUseCase.Login(c.Request.Context(), &req)
+	if err == errors.ErrAccountLocked || err == errors.ErrTooManyLoginAttempts {
+		response.Error(c, "Login failed", err.Error(), http.StatusTooManyRequests)
+		return
+	}
 	if err != nil {
 		response.Error(c, "Login failed", err.Error(), http.StatusUnauthorized)
 		return
@@ -259,7 +265,8 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 }
 
 func (h *UserHandler) ChangePassword(c *gin.Context) {
-	userPKID, exist := c.Get("user_pkid")
+	// Set by the Kong auth middleware in front of this route
+	userPKID, exist := c.Get("user_id")
 	if !exist {
 		response.Error(c, "Unauthorized", "user credentials not found", http.StatusUnauthorized)
 		return
@@ -272,6 +279,10 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	}
 
 	err := h.userUseCase.ChangePassword(c.Request.Context(), userPKID.(int64), &req)
+	if err == errors.ErrAccountLocked || err == errors.ErrTooManyLoginAttempts {
+		response.Error(c, "Password change failed", err.Error(), http.StatusTooManyRequests)
+		return
+	}
 	if err != nil {
 		response.Error(c, "Password change failed", err.Error(), http.StatusBadRequest)
 		return
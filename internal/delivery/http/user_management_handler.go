@@ -7,6 +7,7 @@ import (
 	"go-gin-clean/internal/delivery/http/response"
 	"go-gin-clean/internal/model"
 	"go-gin-clean/internal/usecase"
+	"go-gin-clean/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 )
@@ -56,6 +57,10 @@ func (h *UserManagementHandler) CreateUser(c *gin.Context) {
 	}
 
 	user, err := h.userManagementUseCase.CreateUser(c.Request.Context(), &req)
+	if err == errors.ErrEmailAlreadyExists || err == errors.ErrInvalidPasswordLength || err == errors.ErrPasswordWeak || err == errors.ErrPasswordBanned || err == errors.ErrPasswordBreached {
+		response.Error(c, err.Error(), "", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		response.Error(c, err.Error(), "", http.StatusInternalServerError)
 		return
@@ -233,3 +238,155 @@ func (h *UserManagementHandler) GetTenantRoles(c *gin.Context) {
 
 	response.Success(c, "tenant roles retrieved successfully", roles, http.StatusOK)
 }
+
+// UpdateMFAPolicy handles PUT /api/v1/tenants/:id/mfa-policy
+func (h *UserManagementHandler) UpdateMFAPolicy(c *gin.Context) {
+	// Get requestor user ID from context
+	requestorUserID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "", http.StatusUnauthorized)
+		return
+	}
+
+	// Get tenant ID from URL
+	tenantIDStr := c.Param("id")
+	tenantID, err := strconv.ParseInt(tenantIDStr, 10, 64)
+	if err != nil {
+		response.Error(c, "invalid tenant ID", "", http.StatusBadRequest)
+		return
+	}
+
+	var req model.UpdateMFAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err.Error(), "", http.StatusBadRequest)
+		return
+	}
+
+	err = h.userManagementUseCase.UpdateMFAPolicy(c.Request.Context(), tenantID, &req, requestorUserID.(int64))
+	if err != nil {
+		response.Error(c, err.Error(), "", http.StatusForbidden)
+		return
+	}
+
+	response.Success(c, "tenant MFA policy updated successfully", nil, http.StatusOK)
+}
+
+// GetMFACompliance handles GET /api/v1/tenants/:id/mfa-compliance
+func (h *UserManagementHandler) GetMFACompliance(c *gin.Context) {
+	// Get requestor user ID from context
+	requestorUserID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "", http.StatusUnauthorized)
+		return
+	}
+
+	// Get tenant ID from URL
+	tenantIDStr := c.Param("id")
+	tenantID, err := strconv.ParseInt(tenantIDStr, 10, 64)
+	if err != nil {
+		response.Error(c, "invalid tenant ID", "", http.StatusBadRequest)
+		return
+	}
+
+	// Only list members without MFA (query param)
+	unenrolledOnly := c.Query("unenrolled") == "true"
+
+	compliance, err := h.userManagementUseCase.GetMFACompliance(c.Request.Context(), tenantID, requestorUserID.(int64), unenrolledOnly)
+	if err != nil {
+		response.Error(c, err.Error(), "", http.StatusForbidden)
+		return
+	}
+
+	response.Success(c, "tenant MFA compliance retrieved successfully", compliance, http.StatusOK)
+}
+
+// GetPasswordPolicy handles GET /api/v1/tenants/:id/password-policy
+func (h *UserManagementHandler) GetPasswordPolicy(c *gin.Context) {
+	// Get requestor user ID from context
+	requestorUserID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "", http.StatusUnauthorized)
+		return
+	}
+
+	// Get tenant ID from URL
+	tenantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, "invalid tenant ID", "", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := h.userManagementUseCase.GetPasswordPolicy(c.Request.Context(), tenantID, requestorUserID.(int64))
+	if err != nil {
+		response.Error(c, err.Error(), "", http.StatusForbidden)
+		return
+	}
+
+	response.Success(c, "tenant password policy retrieved successfully", policy, http.StatusOK)
+}
+
+// UpdatePasswordPolicy handles PUT /api/v1/tenants/:id/password-policy
+func (h *UserManagementHandler) UpdatePasswordPolicy(c *gin.Context) {
+	// Get requestor user ID from context
+	requestorUserID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "", http.StatusUnauthorized)
+		return
+	}
+
+	// Get tenant ID from URL
+	tenantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, "invalid tenant ID", "", http.StatusBadRequest)
+		return
+	}
+
+	var req model.UpdatePasswordPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err.Error(), "", http.StatusBadRequest)
+		return
+	}
+
+	err = h.userManagementUseCase.UpdatePasswordPolicy(c.Request.Context(), tenantID, &req, requestorUserID.(int64))
+	if err != nil {
+		response.Error(c, err.Error(), "", http.StatusForbidden)
+		return
+	}
+
+	response.Success(c, "tenant password policy updated successfully", nil, http.StatusOK)
+}
+
+// UnlockMember handles POST /api/v1/tenants/:id/members/:user_id/unlock
+func (h *UserManagementHandler) UnlockMember(c *gin.Context) {
+	// Get requestor user ID from context
+	requestorUserID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, "user not authenticated", "", http.StatusUnauthorized)
+		return
+	}
+
+	// Get tenant and user IDs from URL
+	tenantID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, "invalid tenant ID", "", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		response.Error(c, "invalid user ID", "", http.StatusBadRequest)
+		return
+	}
+
+	err = h.userManagementUseCase.UnlockMember(c.Request.Context(), tenantID, userID, requestorUserID.(int64))
+	if err == errors.ErrAccountNotLocked {
+		response.Error(c, err.Error(), "", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		response.Error(c, err.Error(), "", http.StatusForbidden)
+		return
+	}
+
+	response.Success(c, "account unlocked successfully", nil, http.StatusOK)
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"net/http"
+
+	"go-gin-clean/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WellKnownHandler serves public discovery documents under /.well-known
+type WellKnownHandler struct {
+	signingKeyUseCase *usecase.SigningKeyUseCase
+	oidcUseCase       *usecase.OIDCUseCase
+}
+
+func NewWellKnownHandler(signingKeyUseCase *usecase.SigningKeyUseCase, oidcUseCase *usecase.OIDCUseCase) *WellKnownHandler {
+	return &WellKnownHandler{
+		signingKeyUseCase: signingKeyUseCase,
+		oidcUseCase:       oidcUseCase,
+	}
+}
+
+// JWKS handles GET /.well-known/jwks.json
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying JWTs issued by the portal
+// @Tags Discovery
+// @Produce json
+// @Success 200 {object} model.JWKS "Published signing keys"
+// @Router /.well-known/jwks.json [get]
+func (h *WellKnownHandler) JWKS(c *gin.Context) {
+	// Verifiers may cache briefly; new keys are published well ahead of use
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.signingKeyUseCase.GetJWKS())
+}
+
+// OpenIDConfiguration handles GET /.well-known/openid-configuration
+// @Summary OpenID Connect discovery
+// @Description Endpoints and capabilities of the portal as an OpenID Connect provider
+// @Tags Discovery
+// @Produce json
+// @Success 200 {object} model.OpenIDConfiguration "Provider metadata"
+// @Router /.well-known/openid-configuration [get]
+func (h *WellKnownHandler) OpenIDConfiguration(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, h.oidcUseCase.Discovery())
+}
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// APIToken is a personal access token for non-interactive clients such as
+// integrations. It acts for its user in one tenant with a subset of the user's
+// permissions there. Only the SHA-256 hash of the secret is stored.
+type APIToken struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement;column:id"`
+	UserID      int64      `gorm:"not null;column:user_id"`
+	TenantID    int64      `gorm:"not null;column:tenant_id"`
+	Name        string     `gorm:"type:varchar(100);not null;column:name"` // Label chosen by the user, e.g. "EDI importer"
+	TokenHash   string     `gorm:"type:varchar(64);not null;unique;column:token_hash"`
+	TokenHint   string     `gorm:"type:varchar(16);not null;column:token_hint"` // Start of the token, to tell tokens apart
+	Permissions string     `gorm:"type:text;not null;column:permissions"`       // Comma separated, e.g. "invoice:read,invoice:create"
+	ExpiresAt   time.Time  `gorm:"type:timestamp;not null;column:expires_at"`
+	LastUsedAt  *time.Time `gorm:"type:timestamp;column:last_used_at"`
+
+	Audit
+}
+
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// PermissionList returns the permissions as a slice
+func (t *APIToken) PermissionList() []string {
+	if t.Permissions == "" {
+		return []string{}
+	}
+	return strings.Split(t.Permissions, ",")
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// ImpersonationLog records a platform admin logging in as a user. The impersonated
+// user can list the entries about them.
+type ImpersonationLog struct {
+	ID             int64     `gorm:"primaryKey;autoIncrement;column:id"`
+	ImpersonatorID int64     `gorm:"not null;column:impersonator_id"`
+	UserID         int64     `gorm:"not null;column:user_id"`
+	TenantID       int64     `gorm:"not null;column:tenant_id"`
+	Reason         string    `gorm:"type:varchar(500);not null;column:reason"`
+	SessionID      string    `gorm:"type:varchar(64);not null;column:session_id"` // Public ID of the impersonated session
+	IPAddress      string    `gorm:"type:varchar(45);column:ip_address"`
+	UserAgent      string    `gorm:"type:varchar(500);column:user_agent"`
+	ExpiresAt      time.Time `gorm:"column:expires_at;type:timestamp;not null"`
+	CreatedAt      time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (ImpersonationLog) TableName() string {
+	return "impersonation_logs"
+}
@@ -0,0 +1,36 @@
+package entity
+
+import "strings"
+
+// OAuthClient is an application registered with the portal's OAuth server, such as
+// an ERP backend calling other services with its own identity. Clients belong to
+// one tenant, or to the whole platform when TenantID is nil.
+type OAuthClient struct {
+	ID           int64  `gorm:"primaryKey;autoIncrement;column:id"`
+	ClientID     string `gorm:"type:varchar(64);not null;unique;column:client_id"`
+	ClientSecret string `gorm:"type:varchar(255);not null;column:client_secret"` // SHA-256 hash, the secret is shown once; empty for public clients
+	Name         string `gorm:"type:varchar(100);not null;column:name"`
+	Description  string `gorm:"type:varchar(500);column:description"`
+	RedirectURIs string `gorm:"type:text;column:redirect_uris"` // Space separated
+	Scopes       string `gorm:"type:text;column:scopes"`        // Space separated scopes the client may request
+	TenantID     *int64 `gorm:"column:tenant_id"`
+	IsPublic     bool   `gorm:"default:false;not null;column:is_public"` // Browser apps without a secret, PKCE only
+	IsActive     bool   `gorm:"default:true;not null;column:is_active"`
+	CreatedBy    *int64 `gorm:"column:created_by"`
+
+	Audit
+}
+
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+// ScopeList returns the allowed scopes as a slice
+func (c *OAuthClient) ScopeList() []string {
+	return strings.Fields(c.Scopes)
+}
+
+// RedirectURIList returns the registered redirect URIs as a slice
+func (c *OAuthClient) RedirectURIList() []string {
+	return strings.Fields(c.RedirectURIs)
+}
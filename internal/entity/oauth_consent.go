@@ -0,0 +1,23 @@
+package entity
+
+import "strings"
+
+// OAuthConsent records the scopes a user approved for an OpenID Connect client, so
+// the consent screen is only shown again when the client asks for more
+type OAuthConsent struct {
+	ID       int64  `gorm:"primaryKey;autoIncrement;column:id"`
+	UserID   int64  `gorm:"not null;column:user_id"`
+	ClientID int64  `gorm:"not null;column:client_id"` // oauth_clients.id
+	Scopes   string `gorm:"type:text;column:scopes"`   // Space separated
+
+	Audit
+}
+
+func (OAuthConsent) TableName() string {
+	return "oauth_consents"
+}
+
+// ScopeList returns the approved scopes as a slice
+func (c *OAuthConsent) ScopeList() []string {
+	return strings.Fields(c.Scopes)
+}
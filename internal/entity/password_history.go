@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// PasswordHistory keeps the hash of a password the user has replaced, so the password
+// policy can refuse reusing it
+type PasswordHistory struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement;column:id"`
+	UserID       int64     `gorm:"not null;column:user_id"`
+	PasswordHash string    `gorm:"type:varchar(255);not null;column:password_hash"`
+	CreatedAt    time.Time `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
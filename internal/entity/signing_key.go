@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// SigningKey is one key of the JWT signing key ring.
+// A key is published in the JWKS as soon as it exists, signs from ActivatedAt until
+// RetiredAt, and is dropped from the JWKS once ExpiresAt has passed.
+type SigningKey struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement;column:id"`
+	KID         string     `gorm:"not null;unique;column:kid"`
+	Algorithm   string     `gorm:"not null;column:algorithm"`
+	PrivateKey  string     `gorm:"not null;column:private_key"` // AES-encrypted PKCS#8 PEM
+	PublicKey   string     `gorm:"not null;column:public_key"`  // PKIX PEM
+	ActivatedAt time.Time  `gorm:"not null;type:timestamp;column:activated_at"`
+	RetiredAt   *time.Time `gorm:"type:timestamp;column:retired_at"`
+	ExpiresAt   *time.Time `gorm:"type:timestamp;column:expires_at"`
+
+	Audit
+}
+
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
@@ -1,6 +1,9 @@
 package entity
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Tenant represents a company/organization in the multi-tenant system
 type Tenant struct {
@@ -20,6 +23,130 @@ func (Tenant) TableName() string {
 	return "tenants"
 }
 
+// TenantConfig is the typed view of the tenant's JSONB Config column
+type TenantConfig struct {
+	Session        *TenantSessionConfig  `json:"session,omitempty"`
+	MFA            *TenantMFAConfig      `json:"mfa,omitempty"`
+	PasswordPolicy *TenantPasswordPolicy `json:"password_policy,omitempty"`
+}
+
+// TenantSessionConfig overrides the platform session lifetime for a tenant.
+// Values are Go duration strings such as "15m" or "12h".
+type TenantSessionConfig struct {
+	IdleTimeout string `json:"idle_timeout,omitempty"`
+	MaxLifetime string `json:"max_lifetime,omitempty"`
+}
+
+// MFA enforcement levels of a tenant
+const (
+	MFAEnforcementOff      = "off"      // Default, MFA is up to each user
+	MFAEnforcementOptional = "optional" // Members without MFA are encouraged to enroll
+	MFAEnforcementAdmins   = "admins"   // Required for admin roles
+	MFAEnforcementAll      = "all"      // Required for every member
+)
+
+// TenantMFAConfig is the tenant's MFA policy. Members who must use MFA get GracePeriod
+// (a Go duration string) to enroll, counted from EnforcedAt or from when they joined,
+// whichever is later.
+type TenantMFAConfig struct {
+	Enforcement string     `json:"enforcement,omitempty"`
+	GracePeriod string     `json:"grace_period,omitempty"`
+	EnforcedAt  *time.Time `json:"enforced_at,omitempty"`
+}
+
+// RequiresMFA reports whether members with the given role must use MFA
+func (c *TenantMFAConfig) RequiresMFA(roleName string) bool {
+	if c == nil {
+		return false
+	}
+
+	switch c.Enforcement {
+	case MFAEnforcementAll:
+		return true
+	case MFAEnforcementAdmins:
+		return IsAdminRoleName(roleName)
+	default:
+		return false
+	}
+}
+
+// EnrollmentDeadline returns when a member who joined at joinedAt must have MFA enabled
+func (c *TenantMFAConfig) EnrollmentDeadline(joinedAt time.Time) time.Time {
+	start := joinedAt
+	if c.EnforcedAt != nil && c.EnforcedAt.After(start) {
+		start = *c.EnforcedAt
+	}
+
+	grace, err := time.ParseDuration(c.GracePeriod)
+	if err != nil || grace < 0 {
+		grace = 0
+	}
+	return start.Add(grace)
+}
+
+// TenantPasswordPolicy overrides the platform password policy for a tenant. Unset
+// fields keep the platform default, BannedWords are added to the platform list.
+type TenantPasswordPolicy struct {
+	MinLength        *int     `json:"min_length,omitempty"`
+	RequireUppercase *bool    `json:"require_uppercase,omitempty"`
+	RequireLowercase *bool    `json:"require_lowercase,omitempty"`
+	RequireDigit     *bool    `json:"require_digit,omitempty"`
+	RequireSymbol    *bool    `json:"require_symbol,omitempty"`
+	BannedWords      []string `json:"banned_words,omitempty"`
+	HistorySize      *int     `json:"history_size,omitempty"`
+	MaxAge           string   `json:"max_age,omitempty"` // Go duration string, "0" turns expiry off
+}
+
+// IsValidMFAEnforcement reports whether level is a known enforcement level
+func IsValidMFAEnforcement(level string) bool {
+	switch level {
+	case MFAEnforcementOff, MFAEnforcementOptional, MFAEnforcementAdmins, MFAEnforcementAll:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetConfig parses the tenant's Config column. Invalid or empty JSON yields an empty config.
+func (t *Tenant) GetConfig() TenantConfig {
+	var cfg TenantConfig
+	if t.Config != "" {
+		_ = json.Unmarshal([]byte(t.Config), &cfg)
+	}
+	return cfg
+}
+
+// SetConfig stores cfg in the Config column, keeping keys this type does not know about
+func (t *Tenant) SetConfig(cfg TenantConfig) error {
+	raw := map[string]json.RawMessage{}
+	if t.Config != "" {
+		_ = json.Unmarshal([]byte(t.Config), &raw)
+	}
+
+	known, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	var knownFields map[string]json.RawMessage
+	if err := json.Unmarshal(known, &knownFields); err != nil {
+		return err
+	}
+
+	delete(raw, "session")
+	delete(raw, "mfa")
+	delete(raw, "password_policy")
+	for key, value := range knownFields {
+		raw[key] = value
+	}
+
+	merged, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	t.Config = string(merged)
+	return nil
+}
+
 // TenantRole represents a role within a specific tenant
 type TenantRole struct {
 	ID          int64     `gorm:"primaryKey;autoIncrement;column:id"`
@@ -38,6 +165,18 @@ func (TenantRole) TableName() string {
 	return "roles"
 }
 
+// Members of the system tenant with the Super Administrator role administer the
+// platform itself, across all tenants
+const (
+	SystemTenantSlug      = "system"
+	PlatformAdminRoleName = "Super Administrator"
+)
+
+// IsAdminRoleName reports whether a role name grants tenant administration
+func IsAdminRoleName(name string) bool {
+	return name == "Tenant Owner" || name == "Administrator" || name == "Super Administrator"
+}
+
 // Membership represents the relationship between users, tenants, and roles
 type Membership struct {
 	ID        int64     `gorm:"primaryKey;autoIncrement;column:id"`
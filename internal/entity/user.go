@@ -2,6 +2,7 @@ package entity
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -40,6 +41,8 @@ type User struct {
 	IsActive   bool   `gorm:"default:true;not null"`
 	IsVerified bool   `gorm:"default:false;not null"`
 
+	PasswordChangedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;column:password_changed_at"`
+
 	OAuthProvider string `gorm:"type:varchar(50);column:oauth_provider"`
 	OAuthID       string `gorm:"type:varchar(255);column:oauth_id"`
 
@@ -90,6 +93,7 @@ func (u *User) SetEmail(email string) {
 
 func (u *User) SetPassword(hashedPassword string) {
 	u.Password = hashedPassword
+	u.PasswordChangedAt = time.Now()
 }
 
 func (u *User) Activate() {
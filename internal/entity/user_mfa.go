@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// UserMFA holds a user's TOTP second factor. The row is created with a pending
+// secret when enrollment starts and only protects logins once TOTPEnabled is set.
+type UserMFA struct {
+	ID              int64      `gorm:"primaryKey;autoIncrement;column:id"`
+	UserID          int64      `gorm:"not null;unique;column:user_id"`
+	TOTPSecret      string     `gorm:"not null;column:totp_secret"` // AES-encrypted base32 secret
+	TOTPEnabled     bool       `gorm:"default:false;not null;column:totp_enabled"`
+	TOTPConfirmedAt *time.Time `gorm:"type:timestamp;column:totp_confirmed_at"`
+	TOTPLastStep    int64      `gorm:"default:0;not null;column:totp_last_step"` // Last accepted time step, rejects replays
+
+	Audit
+}
+
+func (UserMFA) TableName() string {
+	return "user_mfa"
+}
+
+// MFARecoveryCode is a one-time code that can replace a TOTP code, e.g. after losing the device
+type MFARecoveryCode struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement;column:id"`
+	UserID    int64      `gorm:"not null;column:user_id"`
+	CodeHash  string     `gorm:"not null;column:code_hash"` // Hex SHA-256 of the normalized code
+	UsedAt    *time.Time `gorm:"type:timestamp;column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// WebAuthnCredential is a FIDO2 passkey registered by a user. It can replace the
+// password at login or serve as the second factor after it.
+type WebAuthnCredential struct {
+	ID              int64      `gorm:"primaryKey;autoIncrement;column:id"`
+	UserID          int64      `gorm:"not null;column:user_id"`
+	Name            string     `gorm:"type:varchar(100);not null;column:name"` // Label chosen by the user, e.g. "Warehouse tablet"
+	CredentialID    []byte     `gorm:"not null;unique;column:credential_id"`
+	PublicKey       []byte     `gorm:"not null;column:public_key"` // COSE encoded
+	AttestationType string     `gorm:"type:varchar(50);not null;column:attestation_type"`
+	Transports      string     `gorm:"type:varchar(255);not null;column:transports"` // Comma separated, e.g. "internal,hybrid"
+	AAGUID          []byte     `gorm:"column:aaguid"`                                // Authenticator model
+	SignCount       int64      `gorm:"default:0;not null;column:sign_count"`
+	CloneWarning    bool       `gorm:"default:false;not null;column:clone_warning"` // The sign counter went backwards once
+	BackupEligible  bool       `gorm:"default:false;not null;column:backup_eligible"`
+	BackupState     bool       `gorm:"default:false;not null;column:backup_state"` // Synced to a passkey provider
+	LastUsedAt      *time.Time `gorm:"type:timestamp;column:last_used_at"`
+
+	Audit
+}
+
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+// TransportList returns the transports as a slice
+func (c *WebAuthnCredential) TransportList() []string {
+	if c.Transports == "" {
+		return []string{}
+	}
+	return strings.Split(c.Transports, ",")
+}
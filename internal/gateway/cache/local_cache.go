@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LocalCache is a bounded in-process LRU cache whose entries expire after a fixed TTL.
+// It is safe for concurrent use. Values are returned as stored, so callers must not
+// mutate them after Set.
+type LocalCache[V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[string]*list.Element
+	order    *list.List // Front is most recently used
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+type localCacheEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// LocalCacheStats is a snapshot of a LocalCache's counters
+type LocalCacheStats struct {
+	Size      int    `json:"size"`
+	Capacity  int    `json:"capacity"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+func NewLocalCache[V any](capacity int, ttl time.Duration) *LocalCache[V] {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &LocalCache[V]{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+// Get returns the cached value for key if present and not expired
+func (c *LocalCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return zero, false
+	}
+
+	entry := elem.Value.(*localCacheEntry[V])
+	if time.Now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		c.misses.Add(1)
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits.Add(1)
+	return entry.value, true
+}
+
+// Set stores value under key, evicting the least recently used entry when full
+func (c *LocalCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*localCacheEntry[V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	if c.order.Len() >= c.capacity {
+		if oldest := c.order.Back(); oldest != nil {
+			c.removeElement(oldest)
+			c.evictions.Add(1)
+		}
+	}
+
+	c.items[key] = c.order.PushFront(&localCacheEntry[V]{key: key, value: value, expiresAt: expiresAt})
+}
+
+// Delete removes key from the cache
+func (c *LocalCache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Stats returns the current size and counters
+func (c *LocalCache[V]) Stats() LocalCacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	return LocalCacheStats{
+		Size:      size,
+		Capacity:  c.capacity,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+func (c *LocalCache[V]) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*localCacheEntry[V]).key)
+}
@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-gin-clean/pkg/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	LoginFailuresKeyPrefix = "login_failures:" // Failed attempts within the window
+	LoginLockKeyPrefix     = "login_lock:"     // Present while locked, expires on its own
+	LoginDelayKeyPrefix    = "login_delay:"    // Present while the next attempt must wait
+)
+
+// LoginBlock tells why a login attempt is refused and for how long
+type LoginBlock struct {
+	Locked     bool // Locked after too many failures, otherwise only throttled
+	RetryAfter time.Duration
+}
+
+// LoginFailure is the state after recording a failed attempt
+type LoginFailure struct {
+	AccountFailures int64
+	AccountLocked   bool // The account was locked by this failure
+	IPLocked        bool // The source IP was locked by this failure
+}
+
+// LoginGuard counts failed password attempts per account and per source IP in Redis.
+// Each failure delays the next attempt on the account a little longer, and reaching
+// a threshold locks the account or IP for a while. Source IPs come from gin's ClientIP,
+// which only believes X-Forwarded-For from TRUSTED_PROXIES; if Kong is missing there,
+// all logins share Kong's address and one IP lock blocks everyone.
+type LoginGuard struct {
+	client *redis.Client
+	cfg    *config.LockoutConfig
+}
+
+func NewLoginGuard(client *redis.Client, cfg *config.LockoutConfig) *LoginGuard {
+	return &LoginGuard{client: client, cfg: cfg}
+}
+
+// Check returns a block when the account or IP is locked or the account is still
+// in its delay after the last failure. An empty ip skips the IP check.
+func (g *LoginGuard) Check(ctx context.Context, account string, ip string) (*LoginBlock, error) {
+	account = accountKey(account)
+
+	pipe := g.client.Pipeline()
+	accountLock := pipe.PTTL(ctx, LoginLockKeyPrefix+account)
+	delay := pipe.PTTL(ctx, LoginDelayKeyPrefix+account)
+	var ipLock *redis.DurationCmd
+	if ip != "" {
+		ipLock = pipe.PTTL(ctx, LoginLockKeyPrefix+ipKey(ip))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to check login lockout: %w", err)
+	}
+
+	lockTTL := accountLock.Val()
+	if ipLock != nil && ipLock.Val() > lockTTL {
+		lockTTL = ipLock.Val()
+	}
+	if lockTTL > 0 {
+		return &LoginBlock{Locked: true, RetryAfter: lockTTL}, nil
+	}
+	if delay.Val() > 0 {
+		return &LoginBlock{RetryAfter: delay.Val()}, nil
+	}
+	return nil, nil
+}
+
+// RecordFailure counts a failed attempt, locks the account or IP once its threshold
+// is reached, and otherwise sets the delay before the next attempt
+func (g *LoginGuard) RecordFailure(ctx context.Context, account string, ip string) (*LoginFailure, error) {
+	account = accountKey(account)
+
+	pipe := g.client.TxPipeline()
+	accountFailures := pipe.Incr(ctx, LoginFailuresKeyPrefix+account)
+	pipe.Expire(ctx, LoginFailuresKeyPrefix+account, g.cfg.FailureWindow)
+	var ipFailures *redis.IntCmd
+	if ip != "" {
+		ipFailures = pipe.Incr(ctx, LoginFailuresKeyPrefix+ipKey(ip))
+		pipe.Expire(ctx, LoginFailuresKeyPrefix+ipKey(ip), g.cfg.FailureWindow)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	failure := &LoginFailure{AccountFailures: accountFailures.Val()}
+
+	if failure.AccountFailures >= int64(g.cfg.MaxAccountFailures) {
+		locked, err := g.lock(ctx, account)
+		if err != nil {
+			return nil, err
+		}
+		failure.AccountLocked = locked
+	} else if delay := g.delay(failure.AccountFailures); delay > 0 {
+		if err := g.client.Set(ctx, LoginDelayKeyPrefix+account, "1", delay).Err(); err != nil {
+			return nil, fmt.Errorf("failed to set login delay: %w", err)
+		}
+	}
+
+	if ipFailures != nil && ipFailures.Val() >= int64(g.cfg.MaxIPFailures) {
+		locked, err := g.lock(ctx, ipKey(ip))
+		if err != nil {
+			return nil, err
+		}
+		failure.IPLocked = locked
+	}
+
+	return failure, nil
+}
+
+// RecordSuccess forgets the account's failures after a correct password. IP failures
+// are kept, a successful login does not vouch for other guesses from the same IP.
+func (g *LoginGuard) RecordSuccess(ctx context.Context, account string) error {
+	account = accountKey(account)
+	return g.client.Del(ctx, LoginFailuresKeyPrefix+account, LoginDelayKeyPrefix+account).Err()
+}
+
+// Unlock lifts the account's lock and failures. It returns false when it was not locked.
+func (g *LoginGuard) Unlock(ctx context.Context, account string) (bool, error) {
+	account = accountKey(account)
+
+	pipe := g.client.TxPipeline()
+	lock := pipe.Del(ctx, LoginLockKeyPrefix+account)
+	pipe.Del(ctx, LoginFailuresKeyPrefix+account, LoginDelayKeyPrefix+account)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to unlock account: %w", err)
+	}
+	return lock.Val() == 1, nil
+}
+
+// lock locks key for the lockout duration and resets its failures. It returns false
+// when key was already locked.
+func (g *LoginGuard) lock(ctx context.Context, key string) (bool, error) {
+	locked, err := g.client.SetNX(ctx, LoginLockKeyPrefix+key, time.Now().Unix(), g.cfg.LockoutDuration).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to lock login: %w", err)
+	}
+	if err := g.client.Del(ctx, LoginFailuresKeyPrefix+key, LoginDelayKeyPrefix+key).Err(); err != nil {
+		return false, fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	return locked, nil
+}
+
+// delay returns the wait after the given number of failures: BaseDelay, doubled
+// for every further failure, capped at MaxDelay
+func (g *LoginGuard) delay(failures int64) time.Duration {
+	if failures <= 0 || g.cfg.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := g.cfg.BaseDelay
+	for i := int64(1); i < failures && delay < g.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > g.cfg.MaxDelay {
+		delay = g.cfg.MaxDelay
+	}
+	return delay
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-gin-clean/pkg/config"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestLoginGuard(t *testing.T, cfg *config.LockoutConfig) (*LoginGuard, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return NewLoginGuard(client, cfg), mr
+}
+
+var testLockoutConfig = config.LockoutConfig{
+	MaxAccountFailures: 3,
+	MaxIPFailures:      5,
+	FailureWindow:      15 * time.Minute,
+	LockoutDuration:    15 * time.Minute,
+	BaseDelay:          time.Second,
+	MaxDelay:           4 * time.Second,
+}
+
+func TestLoginGuardDelay(t *testing.T) {
+	g := NewLoginGuard(nil, &testLockoutConfig)
+
+	tests := []struct {
+		failures int64
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 4 * time.Second},
+		{50, 4 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := g.delay(tt.failures); got != tt.want {
+			t.Errorf("delay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+
+	if got := NewLoginGuard(nil, &config.LockoutConfig{}).delay(3); got != 0 {
+		t.Errorf("delay() without a base delay = %v, want 0", got)
+	}
+}
+
+func TestLoginGuardLocksAccount(t *testing.T) {
+	ctx := context.Background()
+	g, mr := newTestLoginGuard(t, &testLockoutConfig)
+
+	for i := 1; i < testLockoutConfig.MaxAccountFailures; i++ {
+		failure, err := g.RecordFailure(ctx, "Jane@Example.com", "10.0.0.1")
+		if err != nil {
+			t.Fatalf("RecordFailure() error = %v", err)
+		}
+		if failure.AccountLocked {
+			t.Fatalf("account locked after %d failures", i)
+		}
+
+		// Each failure throttles the next attempt, whatever the email's case
+		block, err := g.Check(ctx, "jane@example.com ", "")
+		if err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		if block == nil || block.Locked {
+			t.Fatalf("Check() after %d failures = %+v, want a delay", i, block)
+		}
+		mr.FastForward(block.RetryAfter)
+	}
+
+	failure, err := g.RecordFailure(ctx, "jane@example.com", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("RecordFailure() error = %v", err)
+	}
+	if !failure.AccountLocked {
+		t.Fatal("account not locked at the threshold")
+	}
+
+	block, err := g.Check(ctx, "jane@example.com", "10.0.0.2")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if block == nil || !block.Locked || block.RetryAfter > testLockoutConfig.LockoutDuration {
+		t.Fatalf("Check() of a locked account = %+v, want locked for at most %v", block, testLockoutConfig.LockoutDuration)
+	}
+
+	// Another account behind the same IP is not affected
+	if block, _ := g.Check(ctx, "john@example.com", "10.0.0.1"); block != nil {
+		t.Errorf("Check() of another account = %+v, want nil", block)
+	}
+
+	// Locks lift on their own
+	mr.FastForward(testLockoutConfig.LockoutDuration)
+	if block, _ := g.Check(ctx, "jane@example.com", ""); block != nil {
+		t.Errorf("Check() after the lockout = %+v, want nil", block)
+	}
+}
+
+func TestLoginGuardLocksIP(t *testing.T) {
+	ctx := context.Background()
+	g, _ := newTestLoginGuard(t, &testLockoutConfig)
+
+	// Spraying one password over many accounts locks the IP, not the accounts
+	var failure *LoginFailure
+	for i := 0; i < testLockoutConfig.MaxIPFailures; i++ {
+		var err error
+		failure, err = g.RecordFailure(ctx, string(rune('a'+i))+"@example.com", "10.0.0.1")
+		if err != nil {
+			t.Fatalf("RecordFailure() error = %v", err)
+		}
+	}
+	if !failure.IPLocked || failure.AccountLocked {
+		t.Fatalf("last failure = %+v, want only the IP locked", failure)
+	}
+
+	tests := []struct {
+		name       string
+		ip         string
+		wantLocked bool
+	}{
+		{"locked IP", "10.0.0.1", true},
+		{"other IP", "10.0.0.2", false},
+		{"IP not checked", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, err := g.Check(ctx, "z@example.com", tt.ip)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if locked := block != nil && block.Locked; locked != tt.wantLocked {
+				t.Errorf("Check() = %+v, want locked %v", block, tt.wantLocked)
+			}
+		})
+	}
+}
+
+func TestLoginGuardSuccessAndUnlock(t *testing.T) {
+	ctx := context.Background()
+	g, _ := newTestLoginGuard(t, &testLockoutConfig)
+
+	if _, err := g.RecordFailure(ctx, "jane@example.com", ""); err != nil {
+		t.Fatalf("RecordFailure() error = %v", err)
+	}
+	if err := g.RecordSuccess(ctx, "jane@example.com"); err != nil {
+		t.Fatalf("RecordSuccess() error = %v", err)
+	}
+	if block, _ := g.Check(ctx, "jane@example.com", ""); block != nil {
+		t.Errorf("Check() after a success = %+v, want nil", block)
+	}
+
+	// A success resets the count, so the lock needs the full number of failures again
+	for i := 1; i < testLockoutConfig.MaxAccountFailures; i++ {
+		failure, _ := g.RecordFailure(ctx, "jane@example.com", "")
+		if failure.AccountLocked {
+			t.Fatalf("account locked after %d failures following a success", i)
+		}
+	}
+	if failure, _ := g.RecordFailure(ctx, "jane@example.com", ""); !failure.AccountLocked {
+		t.Fatal("account not locked at the threshold")
+	}
+
+	unlocked, err := g.Unlock(ctx, "jane@example.com")
+	if err != nil || !unlocked {
+		t.Fatalf("Unlock() = %v, %v, want true", unlocked, err)
+	}
+	if block, _ := g.Check(ctx, "jane@example.com", ""); block != nil {
+		t.Errorf("Check() after Unlock() = %+v, want nil", block)
+	}
+	if unlocked, _ := g.Unlock(ctx, "jane@example.com"); unlocked {
+		t.Error("Unlock() of an unlocked account returned true")
+	}
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const RateLimitKeyPrefix = "rate_limit:"
+
+// slidingWindowScript keeps one sorted-set entry per request, scored by its time in
+// milliseconds. Entries older than the window are dropped first, so the set always
+// holds exactly the requests of the last window. It returns whether the request was
+// admitted, how many requests the window now holds and the score of the oldest one.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+
+local allowed = 0
+local count = redis.call('ZCARD', key)
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	allowed = 1
+	count = count + 1
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+return {allowed, count, tonumber(oldest[2]) or now}
+`)
+
+// RateLimitResult is the outcome of one rate limit check
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // Until the oldest counted request leaves the window
+}
+
+// RateLimiter counts requests per key in a sliding window stored in Redis, so every
+// instance of the service shares the same limits
+type RateLimiter struct {
+	client *redis.Client
+}
+
+func NewRateLimiter(client *redis.Client) *RateLimiter {
+	return &RateLimiter{client: client}
+}
+
+// Allow records a request for key and reports whether it fits within limit requests
+// per window. Refused requests are not counted.
+func (r *RateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, fmt.Errorf("failed to generate request ID: %w", err)
+	}
+
+	now := time.Now().UnixMilli()
+	member := fmt.Sprintf("%d-%s", now, hex.EncodeToString(bytes))
+
+	values, err := slidingWindowScript.Run(ctx, r.client, []string{RateLimitKeyPrefix + key},
+		now, window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	result := &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  max(limit-int(values[1]), 0),
+		ResetAfter: time.Duration(values[2]+window.Milliseconds()-now) * time.Millisecond,
+	}
+	return result, nil
+}
@@ -9,6 +9,7 @@ import (
 type UserPublisher struct {
 	registerPublisher      Publisher[model.RegisterEvent]
 	resetPasswordPublisher Publisher[model.ResetPasswordEvent]
+	accountLockedPublisher Publisher[model.AccountLockedEvent]
 }
 
 func NewUserPublisher(ch *amqp091.Channel) *UserPublisher {
@@ -21,6 +22,10 @@ func NewUserPublisher(ch *amqp091.Channel) *UserPublisher {
 			ch:       ch,
 			exchange: "pc_main_event_bus",
 		},
+		accountLockedPublisher: Publisher[model.AccountLockedEvent]{
+			ch:       ch,
+			exchange: "pc_main_event_bus",
+		},
 	}
 }
 
@@ -31,3 +36,7 @@ func (p *UserPublisher) RegisterEventPublish(event model.RegisterEvent) error {
 func (p *UserPublisher) ResetPasswordEventPublish(event model.ResetPasswordEvent) error {
 	return p.resetPasswordPublisher.Publish("user.reset_password", event)
 }
+
+func (p *UserPublisher) AccountLockedEventPublish(event model.AccountLockedEvent) error {
+	return p.accountLockedPublisher.Publish("user.account_locked", event)
+}
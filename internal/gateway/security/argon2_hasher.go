@@ -0,0 +1,118 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"go-gin-clean/pkg/errors"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// argon2Params are the cost parameters recorded in an argon2id PHC string
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// Argon2idHasher hashes passwords with argon2id and encodes them as PHC strings:
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+type Argon2idHasher struct {
+	params argon2Params
+}
+
+func NewArgon2idHasher(memory uint32, iterations uint32, parallelism uint8) *Argon2idHasher {
+	parallelism = max(parallelism, 1)
+	return &Argon2idHasher{
+		params: argon2Params{
+			memory:      max(memory, 8*uint32(parallelism)), // The minimum argon2 accepts
+			iterations:  max(iterations, 1),
+			parallelism: parallelism,
+		},
+	}
+}
+
+func (h *Argon2idHasher) Algorithm() string {
+	return "argon2id"
+}
+
+func (h *Argon2idHasher) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	p := h.params
+	key := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(encoded string, password string) error {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return errors.ErrPasswordNotMatch
+	}
+	return nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params != h.params || len(key) != argon2KeyLength
+}
+
+// decodeArgon2id parses a PHC string produced by Hash
+func decodeArgon2id(encoded string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters")
+	}
+	if params.iterations == 0 || params.parallelism == 0 {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,51 @@
+package security
+
+import (
+	"strings"
+
+	"go-gin-clean/pkg/errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher hashes passwords with bcrypt. Its hashes use the modular crypt format
+// ("$2a$10$..."), which PHC strings extend, so they are stored as they are.
+type BcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{cost: cost}
+}
+
+func (h *BcryptHasher) Algorithm() string {
+	return "bcrypt"
+}
+
+func (h *BcryptHasher) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Verify(encoded string, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return errors.ErrPasswordNotMatch
+	}
+	return err
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < h.cost
+}
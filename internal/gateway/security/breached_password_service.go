@@ -0,0 +1,178 @@
+package security
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go-gin-clean/pkg/config"
+)
+
+// Have I Been Pwned range format: files are named after the first 5 hex characters of
+// the SHA-1 hash and list the remaining 35 characters with a count, "SUFFIX:COUNT".
+const (
+	hibpPrefixLength = 5
+	hibpHashLength   = sha1.Size * 2
+)
+
+// BreachedPasswordService screens passwords against a local copy of a breached-password
+// corpus, so no password or hash ever leaves the network
+type BreachedPasswordService struct {
+	path     string
+	minCount int
+	rangeDir string            // Directory of range files, read at check time
+	hashes   [][sha1.Size]byte // Single-file corpus, sorted for binary search
+}
+
+func NewBreachedPasswordService(cfg *config.PasswordPolicyConfig) *BreachedPasswordService {
+	return &BreachedPasswordService{
+		path:     cfg.BreachedCorpus,
+		minCount: cfg.BreachedMinCount,
+	}
+}
+
+// Load prepares the corpus. The path is either a directory of range files, one per hash
+// prefix as downloaded from the HIBP range API, which stays on disk and is read one
+// range file per check, or a single file of full "HASH:COUNT" lines, which is read into
+// memory and meant for small lists. Without a configured path screening is off. It must
+// be called once at startup, before any password is checked.
+func (s *BreachedPasswordService) Load() error {
+	if s.path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open breached password corpus: %w", err)
+	}
+
+	if info.IsDir() {
+		s.rangeDir = s.path
+		log.Printf("Screening passwords against the breached password ranges in %s", s.path)
+		return nil
+	}
+
+	if err := s.loadFile(s.path); err != nil {
+		return err
+	}
+
+	slices.SortFunc(s.hashes, func(a, b [sha1.Size]byte) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	s.hashes = slices.Compact(s.hashes)
+
+	log.Printf("Loaded %d breached password hashes from %s", len(s.hashes), s.path)
+	return nil
+}
+
+// IsBreached reports whether password appears in the corpus at least minCount times.
+// A range file that cannot be read is logged and the password let through.
+func (s *BreachedPasswordService) IsBreached(password string) bool {
+	hash := sha1.Sum([]byte(password))
+
+	if s.rangeDir != "" {
+		breached, err := s.searchRange(strings.ToUpper(hex.EncodeToString(hash[:])))
+		if err != nil {
+			log.Println("Failed to check breached password corpus:", err)
+		}
+		return breached
+	}
+
+	if len(s.hashes) == 0 {
+		return false
+	}
+	_, found := slices.BinarySearchFunc(s.hashes, hash, func(a, b [sha1.Size]byte) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	return found
+}
+
+// searchRange scans the range file of the hash's prefix for its suffix. Range files are
+// named after the prefix, with or without a .txt extension; a missing one has no hashes.
+func (s *BreachedPasswordService) searchRange(hash string) (bool, error) {
+	prefix, suffix := hash[:hibpPrefixLength], hash[hibpPrefixLength:]
+
+	file, err := os.Open(filepath.Join(s.rangeDir, prefix+".txt"))
+	if os.IsNotExist(err) {
+		file, err = os.Open(filepath.Join(s.rangeDir, prefix))
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		lineSuffix, countText, hasCount := strings.Cut(line, ":")
+		if !strings.EqualFold(lineSuffix, suffix) {
+			continue
+		}
+
+		// Padding entries of the range API have a count of 0
+		if hasCount {
+			count, err := strconv.Atoi(countText)
+			if err != nil || count < s.minCount {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return false, scanner.Err()
+}
+
+// loadFile reads "HASH:COUNT" lines. The count is optional so plain lists of hashes
+// work too.
+func (s *BreachedPasswordService) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open breached password corpus: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		full, countText, hasCount := strings.Cut(line, ":")
+		if hasCount {
+			count, err := strconv.Atoi(countText)
+			if err != nil {
+				return fmt.Errorf("invalid breached password count in %s line %d", path, lineNumber)
+			}
+			if count < s.minCount {
+				continue
+			}
+		}
+
+		if len(full) != hibpHashLength {
+			return fmt.Errorf("invalid breached password hash in %s line %d", path, lineNumber)
+		}
+
+		var hash [sha1.Size]byte
+		if _, err := hex.Decode(hash[:], []byte(full)); err != nil {
+			return fmt.Errorf("invalid breached password hash in %s line %d", path, lineNumber)
+		}
+		s.hashes = append(s.hashes, hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read breached password corpus: %w", err)
+	}
+	return nil
+}
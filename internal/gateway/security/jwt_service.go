@@ -1,6 +1,8 @@
 package security
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"go-gin-clean/internal/entity"
 	"go-gin-clean/internal/model"
 	"go-gin-clean/pkg/config"
@@ -12,11 +14,12 @@ import (
 )
 
 type JWTService struct {
-	cfg *config.JWTConfig
+	cfg     *config.JWTConfig
+	keyRing *KeyRing
 }
 
-func NewJWTService(cfg *config.JWTConfig) *JWTService {
-	return &JWTService{cfg: cfg}
+func NewJWTService(cfg *config.JWTConfig, keyRing *KeyRing) *JWTService {
+	return &JWTService{cfg: cfg, keyRing: keyRing}
 }
 
 func (j *JWTService) GenerateAccessToken(user *entity.User) (string, time.Time, error) {
@@ -211,3 +214,213 @@ func (j *JWTService) ValidateRefreshToken(tokenString string) (*model.RefreshTok
 		Subject:   sub,
 	}, nil
 }
+
+// GenerateSessionToken mints a short-lived JWT carrying a phantom session's context,
+// signed with the key ring's active key so it can be verified against the JWKS.
+// A non-zero claims.ExpiresAt (the session's own expiry) caps the token lifetime.
+func (j *JWTService) GenerateSessionToken(claims *model.SessionTokenClaims) (string, time.Time, error) {
+	key, err := j.keyRing.Active()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiryAt := now.Add(j.cfg.SessionTokenExpiry)
+	if !claims.ExpiresAt.IsZero() && claims.ExpiresAt.Before(expiryAt) {
+		expiryAt = claims.ExpiresAt
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", time.Time{}, err
+	}
+
+	mapClaims := jwt.MapClaims{
+		"sid":         claims.SessionID,
+		"uid":         claims.UserID,
+		"uuid":        claims.UserUUID,
+		"email":       claims.Email,
+		"tid":         claims.TenantID,
+		"tenant_slug": claims.TenantSlug,
+		"roles":       claims.Roles,
+		"permissions": claims.Permissions,
+		"scope":       claims.Scope,
+		"token_type":  "session",
+		"exp":         expiryAt.Unix(),
+		"iat":         now.Unix(),
+		"nbf":         now.Unix(),
+		"iss":         j.cfg.JWTIssuer,
+		"aud":         j.cfg.SessionTokenAudience,
+		"sub":         claims.Subject,
+		"jti":         hex.EncodeToString(jti),
+	}
+	if claims.Actor != "" {
+		mapClaims["act"] = map[string]string{"sub": claims.Actor}
+	}
+	if claims.ClientID != "" {
+		mapClaims["client_id"] = claims.ClientID
+	}
+	if claims.Restriction != "" {
+		mapClaims["restriction"] = claims.Restriction
+	}
+
+	token := jwt.NewWithClaims(key.Method(), mapClaims)
+	token.Header["kid"] = key.KID
+	tokenString, err := token.SignedString(key.PrivateKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expiryAt, nil
+}
+
+// GenerateIDToken mints an OpenID Connect ID token, signed with the key ring's active
+// key like session tokens so clients verify it against the JWKS
+func (j *JWTService) GenerateIDToken(claims *model.IDTokenClaims) (string, error) {
+	key, err := j.keyRing.Active()
+	if err != nil {
+		return "", err
+	}
+
+	mapClaims := jwt.MapClaims{
+		"iss":         claims.Issuer,
+		"sub":         claims.Subject,
+		"aud":         claims.Audience,
+		"exp":         claims.ExpiresAt.Unix(),
+		"iat":         time.Now().Unix(),
+		"auth_time":   claims.AuthTime,
+		"tid":         claims.TenantID,
+		"tenant_slug": claims.TenantSlug,
+		"roles":       claims.Roles,
+	}
+	if claims.Nonce != "" {
+		mapClaims["nonce"] = claims.Nonce
+	}
+	if len(claims.AMR) > 0 {
+		mapClaims["amr"] = claims.AMR
+	}
+	if claims.Name != "" {
+		mapClaims["name"] = claims.Name
+	}
+	if claims.Picture != "" {
+		mapClaims["picture"] = claims.Picture
+	}
+	if claims.Email != "" {
+		mapClaims["email"] = claims.Email
+	}
+	if claims.EmailVerified != nil {
+		mapClaims["email_verified"] = *claims.EmailVerified
+	}
+
+	token := jwt.NewWithClaims(key.Method(), mapClaims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.PrivateKey)
+}
+
+// SigningAlgorithm is the JWS algorithm of the keys the service signs with
+func (j *JWTService) SigningAlgorithm() string {
+	return j.cfg.SigningAlgorithm
+}
+
+func (j *JWTService) ValidateSessionToken(tokenString string) (*model.SessionTokenClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := j.keyRing.Get(kid)
+		if !ok {
+			return nil, errors.ErrTokenInvalid
+		}
+		// The algorithm is pinned by the key, never taken from the token
+		if token.Method.Alg() != key.Method().Alg() {
+			return nil, errors.ErrUnexpectedSigningMethod
+		}
+		return key.PrivateKey.Public(), nil
+	})
+
+	if err != nil {
+		return nil, errors.ErrTokenInvalid
+	}
+
+	if !token.Valid {
+		return nil, errors.ErrTokenInvalid
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.ErrInvalidClaims
+	}
+
+	tokenType, ok := claims["token_type"].(string)
+	if !ok || tokenType != "session" {
+		return nil, errors.ErrTokenInvalid
+	}
+
+	if !claims.VerifyAudience(j.cfg.SessionTokenAudience, true) {
+		return nil, errors.ErrTokenInvalid
+	}
+
+	userID, ok := claims["uid"].(float64)
+	if !ok {
+		return nil, errors.ErrInvalidClaims
+	}
+
+	tenantID, ok := claims["tid"].(float64)
+	if !ok {
+		return nil, errors.ErrInvalidClaims
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, errors.ErrInvalidClaims
+	}
+
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return nil, errors.ErrInvalidClaims
+	}
+
+	sessionID, _ := claims["sid"].(string)
+	userUUID, _ := claims["uuid"].(string)
+	email, _ := claims["email"].(string)
+	tenantSlug, _ := claims["tenant_slug"].(string)
+	scope, _ := claims["scope"].(string)
+	iss, _ := claims["iss"].(string)
+	sub, _ := claims["sub"].(string)
+	clientID, _ := claims["client_id"].(string)
+	var actor string
+	if act, ok := claims["act"].(map[string]interface{}); ok {
+		actor, _ = act["sub"].(string)
+	}
+	restriction, _ := claims["restriction"].(string)
+
+	return &model.SessionTokenClaims{
+		SessionID:   sessionID,
+		UserID:      int64(userID),
+		UserUUID:    userUUID,
+		Email:       email,
+		TenantID:    int64(tenantID),
+		TenantSlug:  tenantSlug,
+		Roles:       stringSliceClaim(claims["roles"]),
+		Permissions: stringSliceClaim(claims["permissions"]),
+		Scope:       scope,
+		ExpiresAt:   time.Unix(int64(exp), 0),
+		IssuedAt:    time.Unix(int64(iat), 0),
+		Issuer:      iss,
+		Audience:    j.cfg.SessionTokenAudience,
+		Subject:     sub,
+		Actor:       actor,
+		ClientID:    clientID,
+		Restriction: restriction,
+	}, nil
+}
+
+// stringSliceClaim converts a decoded JSON array claim into a string slice
+func stringSliceClaim(value interface{}) []string {
+	items, _ := value.([]interface{})
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,218 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"go-gin-clean/internal/model"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Supported asymmetric signing algorithms
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey is a parsed key of the key ring
+type SigningKey struct {
+	KID         string
+	Algorithm   string
+	PrivateKey  crypto.Signer
+	ActivatedAt time.Time
+	RetiredAt   *time.Time
+}
+
+// Method returns the JWT signing method matching the key's algorithm
+func (k *SigningKey) Method() jwt.SigningMethod {
+	switch k.Algorithm {
+	case AlgorithmES256:
+		return jwt.SigningMethodES256
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return jwt.SigningMethodRS256
+	}
+}
+
+func (k *SigningKey) canSignAt(t time.Time) bool {
+	return !k.ActivatedAt.After(t) && (k.RetiredAt == nil || k.RetiredAt.After(t))
+}
+
+// KeyRing holds the published signing keys. It is safe for concurrent use and is
+// swapped wholesale whenever keys are reloaded from storage.
+type KeyRing struct {
+	mu   sync.RWMutex
+	keys []*SigningKey // Oldest first
+}
+
+func NewKeyRing() *KeyRing {
+	return &KeyRing{}
+}
+
+// Replace swaps the ring's keys for the given set
+func (r *KeyRing) Replace(keys []*SigningKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys = keys
+}
+
+// Active returns the key that signs right now: the most recently activated key
+// that is not retired yet. Keys published ahead of activation are skipped.
+func (r *KeyRing) Active() (*SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		if r.keys[i].canSignAt(now) {
+			return r.keys[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no active signing key")
+}
+
+// NewestActivation returns when the most recently added key activates (zero when empty)
+func (r *KeyRing) NewestActivation() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.keys) == 0 {
+		return time.Time{}
+	}
+	return r.keys[len(r.keys)-1].ActivatedAt
+}
+
+// Get returns the published key with the given kid
+func (r *KeyRing) Get(kid string) (*SigningKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, k := range r.keys {
+		if k.KID == kid {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// JWKS returns the public halves of all published keys
+func (r *KeyRing) JWKS() *model.JWKS {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jwks := &model.JWKS{Keys: make([]model.JWK, 0, len(r.keys))}
+	for _, k := range r.keys {
+		jwk, err := publicJWK(k)
+		if err != nil {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, *jwk)
+	}
+	return jwks
+}
+
+// GenerateSigningKey creates a new private key for the algorithm
+func GenerateSigningKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgorithmEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+}
+
+// KeyID derives a stable kid from the public key (base64url SHA-256 of its DER form)
+func KeyID(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:16]), nil
+}
+
+// EncodePrivateKeyPEM encodes a private key as PKCS#8 PEM
+func EncodePrivateKeyPEM(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// EncodePublicKeyPEM encodes the public half of a key as PKIX PEM
+func EncodePublicKeyPEM(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// ParsePrivateKeyPEM decodes a PKCS#8 PEM private key
+func ParsePrivateKeyPEM(data string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key PEM")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("private key cannot sign")
+	}
+	return signer, nil
+}
+
+func publicJWK(k *SigningKey) (*model.JWK, error) {
+	jwk := &model.JWK{
+		KID: k.KID,
+		Alg: k.Algorithm,
+		Use: "sig",
+	}
+
+	switch pub := k.PrivateKey.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	return jwk, nil
+}
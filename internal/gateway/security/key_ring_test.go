@@ -0,0 +1,148 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/config"
+)
+
+func newTestSigningKey(t *testing.T, algorithm string, activatedAt time.Time, retiredAt *time.Time) *SigningKey {
+	t.Helper()
+
+	privateKey, err := GenerateSigningKey(algorithm)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey(%s) error = %v", algorithm, err)
+	}
+	kid, err := KeyID(privateKey)
+	if err != nil {
+		t.Fatalf("KeyID() error = %v", err)
+	}
+
+	return &SigningKey{
+		KID:         kid,
+		Algorithm:   algorithm,
+		PrivateKey:  privateKey,
+		ActivatedAt: activatedAt,
+		RetiredAt:   retiredAt,
+	}
+}
+
+func kidOf(key *SigningKey) string {
+	if key == nil {
+		return "<none>"
+	}
+	return key.KID
+}
+
+func TestKeyRingActive(t *testing.T) {
+	now := time.Now()
+	retired := now.Add(-time.Minute)
+
+	old := newTestSigningKey(t, AlgorithmEdDSA, now.Add(-48*time.Hour), nil)
+	oldRetired := newTestSigningKey(t, AlgorithmEdDSA, now.Add(-48*time.Hour), &retired)
+	current := newTestSigningKey(t, AlgorithmEdDSA, now.Add(-time.Hour), nil)
+	upcoming := newTestSigningKey(t, AlgorithmEdDSA, now.Add(time.Hour), nil)
+
+	tests := []struct {
+		name    string
+		keys    []*SigningKey
+		want    *SigningKey
+		wantErr bool
+	}{
+		{"empty ring", nil, nil, true},
+		{"single key", []*SigningKey{old}, old, false},
+		{"newest activated key signs", []*SigningKey{old, current}, current, false},
+		{"published ahead of activation", []*SigningKey{current, upcoming}, current, false},
+		{"retired key falls back", []*SigningKey{current, oldRetired}, current, false},
+		{"only retired and upcoming keys", []*SigningKey{oldRetired, upcoming}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := NewKeyRing()
+			ring.Replace(tt.keys)
+
+			got, err := ring.Active()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Active() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Active() = %s, want %s", kidOf(got), kidOf(tt.want))
+			}
+		})
+	}
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	now := time.Now()
+	old := newTestSigningKey(t, AlgorithmES256, now.Add(-time.Hour), nil)
+	next := newTestSigningKey(t, AlgorithmRS256, now.Add(-time.Minute), nil)
+
+	ring := NewKeyRing()
+	ring.Replace([]*SigningKey{old})
+	jwtService := NewJWTService(&config.JWTConfig{
+		SessionTokenExpiry:   time.Minute,
+		SessionTokenAudience: "erp",
+	}, ring)
+
+	token, _, err := jwtService.GenerateSessionToken(&model.SessionTokenClaims{UserID: 1, TenantID: 10})
+	if err != nil {
+		t.Fatalf("GenerateSessionToken() error = %v", err)
+	}
+
+	// The next key takes over; tokens of the previous one stay valid while it is published
+	retired := now
+	old.RetiredAt = &retired
+	ring.Replace([]*SigningKey{old, next})
+	if active, _ := ring.Active(); active != next {
+		t.Fatalf("Active() = %s, want the next key %s", kidOf(active), next.KID)
+	}
+	if _, err := jwtService.ValidateSessionToken(token); err != nil {
+		t.Errorf("ValidateSessionToken() with the previous key error = %v", err)
+	}
+
+	// Once the previous key is dropped from the JWKS its tokens are refused
+	ring.Replace([]*SigningKey{next})
+	if _, err := jwtService.ValidateSessionToken(token); err == nil {
+		t.Error("ValidateSessionToken() accepted a token of an unpublished key")
+	}
+}
+
+func TestSigningKeyEncoding(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmES256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			key := newTestSigningKey(t, algorithm, time.Now(), nil)
+
+			encoded, err := EncodePrivateKeyPEM(key.PrivateKey)
+			if err != nil {
+				t.Fatalf("EncodePrivateKeyPEM() error = %v", err)
+			}
+			decoded, err := ParsePrivateKeyPEM(encoded)
+			if err != nil {
+				t.Fatalf("ParsePrivateKeyPEM() error = %v", err)
+			}
+			if kid, _ := KeyID(decoded); kid != key.KID {
+				t.Errorf("KeyID() after a PEM round trip = %q, want %q", kid, key.KID)
+			}
+
+			ring := NewKeyRing()
+			ring.Replace([]*SigningKey{key})
+			jwks := ring.JWKS()
+			if len(jwks.Keys) != 1 {
+				t.Fatalf("JWKS() has %d keys, want 1", len(jwks.Keys))
+			}
+			if jwk := jwks.Keys[0]; jwk.KID != key.KID || jwk.Alg != algorithm || jwk.Use != "sig" || jwk.Kty == "" {
+				t.Errorf("JWKS() key = %+v", jwk)
+			}
+		})
+	}
+
+	if _, err := GenerateSigningKey("HS256"); err == nil {
+		t.Error("GenerateSigningKey() accepted a symmetric algorithm")
+	}
+	if _, err := ParsePrivateKeyPEM("not a key"); err == nil {
+		t.Error("ParsePrivateKeyPEM() accepted garbage")
+	}
+}
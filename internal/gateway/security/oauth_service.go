@@ -3,172 +3,168 @@ package security
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"sync"
 
 	"go-gin-clean/internal/entity"
 	"go-gin-clean/internal/model"
 	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/golang-jwt/jwt/v4"
 )
 
+// OAuthService logs users in with the external identity providers configured in
+// OAUTH_PROVIDERS, such as Google, Microsoft Entra ID or Keycloak. Logins in progress
+// are kept by the caller, see NewState.
 type OAuthService struct {
-	googleConfig   *GoogleOAuthConfig
-	stateGenerator *StateGenerator
-	frontendURLs   map[string]string
-	defaultAppID   string
+	providers    map[string]*OAuthProvider
+	frontendURLs map[string]string
+	defaultAppID string
 }
 
-type StateGenerator struct {
-	secret string
-	states map[string]stateInfo
-}
+// OAuthProvider is one configured identity provider. Endpoints missing from its
+// configuration are discovered from the issuer on first use and then cached.
+type OAuthProvider struct {
+	cfg        config.OAuthProviderConfig
+	httpClient *http.Client
 
-type stateInfo struct {
-	appID     string
-	createdAt time.Time
+	mu        sync.Mutex
+	endpoints *model.OpenIDConfiguration
 }
 
-func NewStateGenerator(secret string) *StateGenerator {
-	if secret == "" {
-		b := make([]byte, 32)
-		rand.Read(b)
-		secret = base64.StdEncoding.EncodeToString(b)
+func NewOAuthService(cfg *config.OAuthConfig) *OAuthService {
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	providers := make(map[string]*OAuthProvider, len(cfg.Providers))
+	for _, providerCfg := range cfg.Providers {
+		providers[providerCfg.Name] = &OAuthProvider{
+			cfg:        providerCfg,
+			httpClient: httpClient,
+		}
 	}
-	return &StateGenerator{
-		secret: secret,
-		states: make(map[string]stateInfo),
+
+	return &OAuthService{
+		providers:    providers,
+		frontendURLs: cfg.FrontendURLs,
+		defaultAppID: cfg.DefaultAppID,
 	}
 }
 
-func (sg *StateGenerator) Generate(appID string) string {
-	b := make([]byte, 16)
-	rand.Read(b)
-	state := base64.StdEncoding.EncodeToString(b)
-
-	sg.states[state] = stateInfo{
-		appID:     appID,
-		createdAt: time.Now(),
+// Providers returns the names of the configured identity providers, sorted
+func (s *OAuthService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
 	}
-
-	return state
+	slices.Sort(names)
+	return names
 }
 
-func (sg *StateGenerator) Validate(state string) (string, bool) {
-	info, exists := sg.states[state]
-	if !exists {
-		return "", false
+// NewState starts a login at a provider: it picks the PKCE code verifier and, for
+// OpenID Connect providers, the nonce the ID token must carry. The caller stores the
+// value under the state parameter until the callback.
+func (s *OAuthService) NewState(providerName string, appID string) (*model.OAuthStateValue, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, errors.ErrInvalidOAuthProvider
 	}
 
-	if time.Since(info.createdAt) > 10*time.Minute {
-		delete(sg.states, state)
-		return "", false
+	if appID == "" {
+		appID = s.defaultAppID
 	}
 
-	delete(sg.states, state)
-	return info.appID, true
-}
+	codeVerifier, err := randomURLSafe(32)
+	if err != nil {
+		return nil, err
+	}
 
-// CleanupExpiredStates removes expired states
-func (sg *StateGenerator) CleanupExpiredStates() {
-	for state, info := range sg.states {
-		if time.Since(info.createdAt) > 10*time.Minute {
-			delete(sg.states, state)
+	var nonce string
+	if provider.isOpenID() {
+		nonce, err = randomURLSafe(16)
+		if err != nil {
+			return nil, err
 		}
 	}
-}
 
-// OAuth provider configurations
-type GoogleOAuthConfig struct {
-	ClientID       string
-	ClientSecret   string
-	RedirectURL    string
-	AuthURL        string
-	TokenURL       string
-	UserInfoURL    string
-	AllowedOrigins []string
+	return &model.OAuthStateValue{
+		Provider:     providerName,
+		AppID:        appID,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+	}, nil
 }
 
-func NewOAuthService(cfg *config.OAuthConfig) *OAuthService {
-	stateGenerator := NewStateGenerator(cfg.OAuthStateString)
-	go func() {
-		for {
-			time.Sleep(5 * time.Minute)
-			stateGenerator.CleanupExpiredStates()
-		}
-	}()
-
-	googleConfig := &GoogleOAuthConfig{
-		ClientID:       cfg.GoogleClientID,
-		ClientSecret:   cfg.GoogleClientSecret,
-		RedirectURL:    cfg.GoogleRedirectURL,
-		AuthURL:        "https://accounts.google.com/o/oauth2/auth",
-		TokenURL:       "https://oauth2.googleapis.com/token",
-		UserInfoURL:    "https://www.googleapis.com/oauth2/v2/userinfo",
-		AllowedOrigins: cfg.GoogleAllowedOrigins,
+// GetAuthURL returns the provider's authorization URL to send the browser to
+func (s *OAuthService) GetAuthURL(ctx context.Context, state string, value *model.OAuthStateValue) (string, error) {
+	provider, ok := s.providers[value.Provider]
+	if !ok {
+		return "", errors.ErrInvalidOAuthProvider
 	}
 
-	return &OAuthService{
-		googleConfig:   googleConfig,
-		stateGenerator: stateGenerator,
-		frontendURLs:   cfg.FrontendURLs,
-		defaultAppID:   cfg.DefaultAppID,
-	}
-}
-
-func (s *OAuthService) GetGoogleAuthURL(appID string) string {
-	if appID == "" {
-		appID = s.defaultAppID
+	endpoints, err := provider.resolveEndpoints(ctx)
+	if err != nil {
+		return "", err
 	}
 
-	state := s.stateGenerator.Generate(appID)
+	challenge := sha256.Sum256([]byte(value.CodeVerifier))
 
 	params := url.Values{}
-	params.Add("client_id", s.googleConfig.ClientID)
-	params.Add("redirect_uri", s.googleConfig.RedirectURL)
+	params.Add("client_id", provider.cfg.ClientID)
+	params.Add("redirect_uri", provider.cfg.RedirectURL)
 	params.Add("response_type", "code")
-	params.Add("scope", "https://www.googleapis.com/auth/userinfo.email https://www.googleapis.com/auth/userinfo.profile")
+	params.Add("scope", strings.Join(provider.cfg.Scopes, " "))
 	params.Add("state", state)
-	params.Add("access_type", "offline")
-	params.Add("prompt", "consent")
+	params.Add("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	params.Add("code_challenge_method", model.CodeChallengeMethodS256)
+	if value.Nonce != "" {
+		params.Add("nonce", value.Nonce)
+	}
 
-	return s.googleConfig.AuthURL + "?" + params.Encode()
+	return appendQuery(endpoints.AuthorizationEndpoint, params), nil
 }
 
-func (s *OAuthService) HandleGoogleCallback(ctx context.Context, state string, code string) (*entity.User, string, error) {
-	appID, valid := s.stateGenerator.Validate(state)
-	if !valid {
-		return nil, "", errors.New("invalid oauth state")
+// HandleCallback exchanges the authorization code of the login and maps the provider's
+// user info to a user. The email must be verified, when the provider says so, and
+// belong to one of the provider's allowed domains.
+func (s *OAuthService) HandleCallback(ctx context.Context, value *model.OAuthStateValue, code string) (*entity.User, error) {
+	provider, ok := s.providers[value.Provider]
+	if !ok {
+		return nil, errors.ErrInvalidOAuthProvider
 	}
 
-	tokenResp, err := s.exchangeGoogleCode(ctx, code)
+	endpoints, err := provider.resolveEndpoints(ctx)
 	if err != nil {
-		return nil, "", fmt.Errorf("code exchange failed: %v", err)
+		return nil, err
 	}
 
-	userData, err := s.getGoogleUserData(ctx, tokenResp.AccessToken)
+	tokenResp, err := provider.exchangeCode(ctx, endpoints.TokenEndpoint, code, value.CodeVerifier)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get user info from Google: %v", err)
+		return nil, fmt.Errorf("code exchange failed: %v", err)
 	}
 
-	user, err := entity.NewUserFromOAuth(
-		userData.Name,
-		userData.Email,
-		"google",
-		userData.ID,
-		userData.Picture,
-	)
+	if value.Nonce != "" {
+		if err := provider.verifyIDToken(endpoints, tokenResp.IDToken, value.Nonce); err != nil {
+			return nil, err
+		}
+	}
+
+	userInfo, err := provider.getUserInfo(ctx, endpoints.UserInfoEndpoint, tokenResp.AccessToken)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to create user from Google data: %v", err)
+		return nil, fmt.Errorf("failed to get user info from %s: %v", provider.cfg.Name, err)
 	}
 
-	return user, appID, nil
+	return provider.mapUser(userInfo)
 }
 
 func (s *OAuthService) GetFrontendURL(appID string) string {
@@ -184,98 +180,257 @@ func (s *OAuthService) GetFrontendURL(appID string) string {
 	return url
 }
 
-func (s *OAuthService) exchangeGoogleCode(ctx context.Context, code string) (*model.TokenResponse, error) {
-	data := url.Values{}
-	data.Set("code", code)
-	data.Set("client_id", s.googleConfig.ClientID)
-	data.Set("client_secret", s.googleConfig.ClientSecret)
-	data.Set("redirect_uri", s.googleConfig.RedirectURL)
-	data.Set("grant_type", "authorization_code")
+// TrustsEmail reports whether logins from the provider may be linked to an existing
+// account with the same email
+func (s *OAuthService) TrustsEmail(providerName string) bool {
+	provider, ok := s.providers[providerName]
+	return ok && provider.cfg.TrustEmail
+}
 
-	return s.exchangeCode(ctx, s.googleConfig.TokenURL, data)
+func (s *OAuthService) IsOriginAllowed(providerName, origin string) bool {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return false
+	}
+
+	allowedOrigins := provider.cfg.AllowedOrigins
+	if len(allowedOrigins) == 0 {
+		return false
+	}
+
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+
+	return false
 }
 
-func (s *OAuthService) exchangeCode(ctx context.Context, tokenURL string, data url.Values) (*model.TokenResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
-	if err != nil {
-		return nil, err
+// resolveEndpoints combines the configured endpoints with those discovered from the
+// issuer. A failed discovery is not cached, so the next login tries again.
+func (p *OAuthProvider) resolveEndpoints(ctx context.Context) (*model.OpenIDConfiguration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.endpoints != nil {
+		return p.endpoints, nil
 	}
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	endpoints := &model.OpenIDConfiguration{
+		Issuer:                p.cfg.Issuer,
+		AuthorizationEndpoint: p.cfg.AuthURL,
+		TokenEndpoint:         p.cfg.TokenURL,
+		UserInfoEndpoint:      p.cfg.UserInfoURL,
+	}
+
+	if p.cfg.Issuer != "" && (endpoints.AuthorizationEndpoint == "" || endpoints.TokenEndpoint == "" || endpoints.UserInfoEndpoint == "") {
+		discovered, err := p.discover(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover %s endpoints: %w", p.cfg.Name, err)
+		}
+		if endpoints.AuthorizationEndpoint == "" {
+			endpoints.AuthorizationEndpoint = discovered.AuthorizationEndpoint
+		}
+		if endpoints.TokenEndpoint == "" {
+			endpoints.TokenEndpoint = discovered.TokenEndpoint
+		}
+		if endpoints.UserInfoEndpoint == "" {
+			endpoints.UserInfoEndpoint = discovered.UserInfoEndpoint
+		}
+		endpoints.JWKSURI = discovered.JWKSURI
+	}
+
+	if endpoints.AuthorizationEndpoint == "" || endpoints.TokenEndpoint == "" || endpoints.UserInfoEndpoint == "" {
+		return nil, fmt.Errorf("identity provider %s needs an issuer or auth, token and user info URLs", p.cfg.Name)
+	}
+
+	p.endpoints = endpoints
+	return endpoints, nil
+}
+
+// discover fetches the issuer's OpenID Connect discovery document
+func (p *OAuthProvider) discover(ctx context.Context) (*model.OpenIDConfiguration, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.cfg.Issuer+"/.well-known/openid-configuration", nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
+	var doc model.OpenIDConfiguration
+	if err := p.doJSON(req, &doc); err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("token exchange failed with status %d: %s", resp.StatusCode, string(body))
+	// OpenID Connect Discovery section 4.3: the document must be about the configured issuer
+	if strings.TrimSuffix(doc.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("issuer mismatch: got %q", doc.Issuer)
 	}
 
+	return &doc, nil
+}
+
+func (p *OAuthProvider) exchangeCode(ctx context.Context, tokenURL string, code string, codeVerifier string) (*model.TokenResponse, error) {
+	data := url.Values{}
+	data.Set("code", code)
+	data.Set("code_verifier", codeVerifier)
+	data.Set("client_id", p.cfg.ClientID)
+	data.Set("client_secret", p.cfg.ClientSecret)
+	data.Set("redirect_uri", p.cfg.RedirectURL)
+	data.Set("grant_type", "authorization_code")
+
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Accept", "application/json")
+
 	var tokenResp model.TokenResponse
-	if err := json.Unmarshal(body, &tokenResp); err != nil {
+	if err := p.doJSON(req, &tokenResp); err != nil {
 		return nil, err
 	}
+	if tokenResp.AccessToken == "" {
+		return nil, errors.ErrOAuthCodeExchange
+	}
 
 	return &tokenResp, nil
 }
 
-func (s *OAuthService) getGoogleUserData(ctx context.Context, accessToken string) (*model.GoogleUserData, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", s.googleConfig.UserInfoURL, nil)
+// isOpenID reports whether the provider is asked for an ID token
+func (p *OAuthProvider) isOpenID() bool {
+	return slices.Contains(p.cfg.Scopes, "openid")
+}
+
+// verifyIDToken checks that the ID token was issued to this client for this login.
+// It comes straight from the token endpoint over TLS, so the signature is not checked
+// (OpenID Connect Core section 3.1.3.7).
+func (p *OAuthProvider) verifyIDToken(endpoints *model.OpenIDConfiguration, idToken string, nonce string) error {
+	if idToken == "" {
+		return errors.ErrOAuthIDTokenInvalid
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(idToken, claims); err != nil {
+		return errors.ErrOAuthIDTokenInvalid
+	}
+
+	if endpoints.Issuer != "" && !claims.VerifyIssuer(endpoints.Issuer, true) {
+		return errors.ErrOAuthIDTokenInvalid
+	}
+	if !claims.VerifyAudience(p.cfg.ClientID, true) || !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return errors.ErrOAuthIDTokenInvalid
+	}
+	if claimNonce, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(claimNonce), []byte(nonce)) != 1 {
+		return errors.ErrOAuthIDTokenInvalid
+	}
+
+	return nil
+}
+
+func (p *OAuthProvider) getUserInfo(ctx context.Context, userInfoURL string, accessToken string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", userInfoURL, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Authorization", "Bearer "+accessToken)
+	req.Header.Add("Accept", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
+	var userInfo map[string]any
+	if err := p.doJSON(req, &userInfo); err != nil {
 		return nil, err
 	}
+
+	return userInfo, nil
+}
+
+// mapUser builds a user from the provider's user info using the configured claims
+func (p *OAuthProvider) mapUser(userInfo map[string]any) (*entity.User, error) {
+	subject := claimString(userInfo, p.cfg.SubjectClaim)
+	if subject == "" {
+		return nil, errors.ErrOAuthUserInfo
+	}
+
+	email := strings.ToLower(claimString(userInfo, p.cfg.EmailClaim))
+	if email == "" {
+		return nil, errors.ErrOAuthEmailMissing
+	}
+	// Only trusted providers may leave out email_verified, an explicit false is always refused
+	verified, ok := userInfo["email_verified"].(bool)
+	if (ok && !verified) || (!ok && !p.cfg.TrustEmail) {
+		return nil, errors.ErrOAuthEmailNotVerified
+	}
+	if len(p.cfg.AllowedDomains) > 0 {
+		_, domain, _ := strings.Cut(email, "@")
+		if !slices.Contains(p.cfg.AllowedDomains, domain) {
+			return nil, errors.ErrOAuthEmailDomainNotAllowed
+		}
+	}
+
+	name := claimString(userInfo, p.cfg.NameClaim)
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+
+	user, err := entity.NewUserFromOAuth(
+		name,
+		email,
+		p.cfg.Name,
+		subject,
+		claimString(userInfo, p.cfg.PictureClaim),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user from %s data: %v", p.cfg.Name, err)
+	}
+
+	return user, nil
+}
+
+// doJSON sends the request and decodes a successful JSON response into out
+func (p *OAuthProvider) doJSON(req *http.Request, out any) error {
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get user info with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var userData model.GoogleUserData
-	if err := json.Unmarshal(body, &userData); err != nil {
-		return nil, err
+		return fmt.Errorf("request to %s failed with status %d: %s", req.URL.Host, resp.StatusCode, string(body))
 	}
 
-	return &userData, nil
+	return json.Unmarshal(body, out)
 }
 
-func (s *OAuthService) IsOriginAllowed(provider, origin string) bool {
-	var allowedOrigins []string
-
-	switch provider {
-	case "google":
-		allowedOrigins = s.googleConfig.AllowedOrigins
+// claimString reads a claim as a string; numeric IDs (e.g. GitHub's) are formatted
+func claimString(claims map[string]any, name string) string {
+	switch value := claims[name].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
 	default:
-		return false
+		return ""
 	}
+}
 
-	if len(allowedOrigins) == 0 {
-		return false
+// randomURLSafe returns n random bytes, base64url encoded without padding
+func randomURLSafe(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
 	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
 
-	for _, allowed := range allowedOrigins {
-		if allowed == "*" || allowed == origin {
-			return true
-		}
+// appendQuery adds params to a URL that may already have a query
+func appendQuery(rawURL string, params url.Values) string {
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
 	}
-
-	return false
+	return rawURL + separator + params.Encode()
 }
@@ -0,0 +1,157 @@
+package security
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"go-gin-clean/internal/entity"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+)
+
+// MaxPasswordBytes is the longest password allowed, whatever the policy says. It is
+// bcrypt's limit, kept with argon2id so passwords can still be hashed if
+// PASSWORD_HASH_ALGORITHM is switched back to bcrypt.
+const MaxPasswordBytes = 72
+
+// minPersonalWordLength keeps short name parts like "Li" from banning half the passwords
+const minPersonalWordLength = 4
+
+// PasswordPolicy is the set of rules a new password must satisfy
+type PasswordPolicy struct {
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	BannedWords      []string
+	HistorySize      int
+	MaxAge           time.Duration
+}
+
+// NewPasswordPolicy returns the platform policy
+func NewPasswordPolicy(cfg *config.PasswordPolicyConfig) PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:        cfg.MinLength,
+		RequireUppercase: cfg.RequireUppercase,
+		RequireLowercase: cfg.RequireLowercase,
+		RequireDigit:     cfg.RequireDigit,
+		RequireSymbol:    cfg.RequireSymbol,
+		BannedWords:      cfg.BannedWords,
+		HistorySize:      cfg.HistorySize,
+		MaxAge:           cfg.MaxAge,
+	}
+}
+
+// WithTenant applies a tenant's overrides on top of the policy
+func (p PasswordPolicy) WithTenant(override *entity.TenantPasswordPolicy) PasswordPolicy {
+	if override == nil {
+		return p
+	}
+
+	if override.MinLength != nil {
+		p.MinLength = *override.MinLength
+	}
+	if override.RequireUppercase != nil {
+		p.RequireUppercase = *override.RequireUppercase
+	}
+	if override.RequireLowercase != nil {
+		p.RequireLowercase = *override.RequireLowercase
+	}
+	if override.RequireDigit != nil {
+		p.RequireDigit = *override.RequireDigit
+	}
+	if override.RequireSymbol != nil {
+		p.RequireSymbol = *override.RequireSymbol
+	}
+	if len(override.BannedWords) > 0 {
+		p.BannedWords = append(append([]string{}, p.BannedWords...), override.BannedWords...)
+	}
+	if override.HistorySize != nil {
+		p.HistorySize = *override.HistorySize
+	}
+	if override.MaxAge != "" {
+		if maxAge, err := time.ParseDuration(override.MaxAge); err == nil && maxAge >= 0 {
+			p.MaxAge = maxAge
+		}
+	}
+	return p
+}
+
+// Stricter combines two policies into one that satisfies both, for users who belong
+// to several tenants
+func (p PasswordPolicy) Stricter(other PasswordPolicy) PasswordPolicy {
+	p.MinLength = max(p.MinLength, other.MinLength)
+	p.RequireUppercase = p.RequireUppercase || other.RequireUppercase
+	p.RequireLowercase = p.RequireLowercase || other.RequireLowercase
+	p.RequireDigit = p.RequireDigit || other.RequireDigit
+	p.RequireSymbol = p.RequireSymbol || other.RequireSymbol
+	p.BannedWords = append(append([]string{}, p.BannedWords...), other.BannedWords...)
+	p.HistorySize = max(p.HistorySize, other.HistorySize)
+	if other.MaxAge > 0 && (p.MaxAge == 0 || other.MaxAge < p.MaxAge) {
+		p.MaxAge = other.MaxAge
+	}
+	return p
+}
+
+// Validate checks password against the policy. personal holds the user's name and
+// email, which the password must not contain either.
+func (p PasswordPolicy) Validate(password string, personal ...string) error {
+	if len([]rune(password)) < p.MinLength || len(password) > MaxPasswordBytes {
+		return errors.ErrInvalidPasswordLength
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r):
+			hasSymbol = true
+		}
+	}
+	if (p.RequireUppercase && !hasUpper) || (p.RequireLowercase && !hasLower) ||
+		(p.RequireDigit && !hasDigit) || (p.RequireSymbol && !hasSymbol) {
+		return errors.ErrPasswordWeak
+	}
+
+	lowered := strings.ToLower(password)
+	for _, word := range p.BannedWords {
+		if word != "" && strings.Contains(lowered, strings.ToLower(word)) {
+			return errors.ErrPasswordBanned
+		}
+	}
+	for _, word := range personalWords(personal) {
+		if strings.Contains(lowered, word) {
+			return errors.ErrPasswordBanned
+		}
+	}
+
+	return nil
+}
+
+// IsExpired reports whether a password set at changedAt must be changed now
+func (p PasswordPolicy) IsExpired(changedAt time.Time) bool {
+	return p.MaxAge > 0 && time.Since(changedAt) > p.MaxAge
+}
+
+// personalWords splits names and emails into the lowercase words a password may not contain
+func personalWords(values []string) []string {
+	var words []string
+	for _, value := range values {
+		local, _, _ := strings.Cut(strings.ToLower(value), "@")
+		for _, word := range strings.FieldsFunc(local, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if len([]rune(word)) >= minPersonalWordLength {
+				words = append(words, word)
+			}
+		}
+	}
+	return words
+}
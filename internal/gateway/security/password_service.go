@@ -0,0 +1,84 @@
+package security
+
+import (
+	"fmt"
+	"log"
+
+	"go-gin-clean/pkg/config"
+)
+
+// PasswordHasher hashes and verifies passwords with one algorithm. Hashes carry their
+// algorithm and parameters, so any registered hasher can verify them later.
+type PasswordHasher interface {
+	Algorithm() string
+	// Identifies reports whether encoded was produced by this algorithm
+	Identifies(encoded string) bool
+	Hash(password string) (string, error)
+	Verify(encoded string, password string) error
+	// NeedsRehash reports whether encoded uses weaker parameters than the hasher's own
+	NeedsRehash(encoded string) bool
+}
+
+// PasswordService hashes new passwords with the configured algorithm and verifies
+// hashes of every supported one
+type PasswordService struct {
+	hasher  PasswordHasher
+	hashers []PasswordHasher
+}
+
+func NewPasswordService(cfg *config.PasswordHashConfig) *PasswordService {
+	hashers := []PasswordHasher{
+		NewArgon2idHasher(cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism),
+		NewBcryptHasher(cfg.BcryptCost),
+	}
+
+	hasher := hashers[0]
+	for _, h := range hashers {
+		if h.Algorithm() == cfg.Algorithm {
+			hasher = h
+		}
+	}
+	if hasher.Algorithm() != cfg.Algorithm {
+		log.Printf("Warning: unsupported password hash algorithm %q, using %s", cfg.Algorithm, hasher.Algorithm())
+	}
+
+	return &PasswordService{
+		hasher:  hasher,
+		hashers: hashers,
+	}
+}
+
+func (p *PasswordService) HashPassword(password string) (string, error) {
+	return p.hasher.Hash(password)
+}
+
+func (p *PasswordService) ValidatePassword(password, hashedPassword string) error {
+	return p.ComparePassword(hashedPassword, password)
+}
+
+// ComparePassword compares a plain password with a hashed password
+func (p *PasswordService) ComparePassword(hashedPassword, password string) error {
+	hasher := p.hasherFor(hashedPassword)
+	if hasher == nil {
+		return fmt.Errorf("unsupported password hash format")
+	}
+	return hasher.Verify(hashedPassword, password)
+}
+
+// NeedsRehash reports whether a hash should be replaced by one made with the configured
+// algorithm and parameters
+func (p *PasswordService) NeedsRehash(hashedPassword string) bool {
+	if !p.hasher.Identifies(hashedPassword) {
+		return true
+	}
+	return p.hasher.NeedsRehash(hashedPassword)
+}
+
+func (p *PasswordService) hasherFor(hashedPassword string) PasswordHasher {
+	for _, h := range p.hashers {
+		if h.Identifies(hashedPassword) {
+			return h
+		}
+	}
+	return nil
+}
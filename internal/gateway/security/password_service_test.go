@@ -0,0 +1,199 @@
+package security
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+)
+
+// Cheap parameters keep the tests fast; the format is the same at any cost
+var testHashConfig = config.PasswordHashConfig{
+	Algorithm:         "argon2id",
+	Argon2Memory:      64,
+	Argon2Iterations:  1,
+	Argon2Parallelism: 1,
+	BcryptCost:        4,
+}
+
+func TestArgon2idHasherPHC(t *testing.T) {
+	h := NewArgon2idHasher(64, 2, 1)
+
+	encoded, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=2,p=1$") {
+		t.Fatalf("Hash() = %q, want a PHC string with the hasher's parameters", encoded)
+	}
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		t.Fatalf("Hash() has %d parts, want 6", len(parts))
+	}
+	if salt, err := base64.RawStdEncoding.DecodeString(parts[4]); err != nil || len(salt) != argon2SaltLength {
+		t.Errorf("salt %q is not %d bytes of unpadded base64", parts[4], argon2SaltLength)
+	}
+	if key, err := base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) != argon2KeyLength {
+		t.Errorf("hash %q is not %d bytes of unpadded base64", parts[5], argon2KeyLength)
+	}
+
+	if again, _ := h.Hash("correct horse"); again == encoded {
+		t.Error("Hash() reused a salt")
+	}
+	if !h.Identifies(encoded) {
+		t.Error("Identifies() rejected its own hash")
+	}
+	if err := h.Verify(encoded, "correct horse"); err != nil {
+		t.Errorf("Verify() of the right password error = %v", err)
+	}
+	if err := h.Verify(encoded, "correct horsf"); err != errors.ErrPasswordNotMatch {
+		t.Errorf("Verify() of a wrong password error = %v, want %v", err, errors.ErrPasswordNotMatch)
+	}
+}
+
+func TestArgon2idHasherMalformed(t *testing.T) {
+	h := NewArgon2idHasher(64, 1, 1)
+	valid, err := h.Hash("secret")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	parts := strings.Split(valid, "$")
+	with := func(i int, value string) string {
+		p := append([]string{}, parts...)
+		p[i] = value
+		return strings.Join(p, "$")
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"empty", ""},
+		{"missing hash", strings.Join(parts[:5], "$")},
+		{"other algorithm", with(1, "argon2i")},
+		{"other version", with(2, "v=16")},
+		{"garbled parameters", with(3, "m=64;t=1;p=1")},
+		{"no iterations", with(3, "m=64,t=0,p=1")},
+		{"no parallelism", with(3, "m=64,t=1,p=0")},
+		{"invalid salt", with(4, "!!!")},
+		{"empty hash", with(5, "")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := h.Verify(tt.encoded, "secret"); err == nil {
+				t.Errorf("Verify(%q) accepted a malformed hash", tt.encoded)
+			}
+			if !h.NeedsRehash(tt.encoded) {
+				t.Errorf("NeedsRehash(%q) = false, want true", tt.encoded)
+			}
+		})
+	}
+}
+
+func TestArgon2idHasherNeedsRehash(t *testing.T) {
+	h := NewArgon2idHasher(64, 2, 1)
+
+	tests := []struct {
+		name   string
+		hasher *Argon2idHasher
+		want   bool
+	}{
+		{"same parameters", NewArgon2idHasher(64, 2, 1), false},
+		{"less memory", NewArgon2idHasher(32, 2, 1), true},
+		{"fewer iterations", NewArgon2idHasher(64, 1, 1), true},
+		{"other parallelism", NewArgon2idHasher(64, 2, 2), true},
+		{"stronger parameters", NewArgon2idHasher(128, 3, 1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := tt.hasher.Hash("secret")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if got := h.NeedsRehash(encoded); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+			// Hashes stay verifiable whatever parameters they were made with
+			if err := h.Verify(encoded, "secret"); err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewArgon2idHasherMinimums(t *testing.T) {
+	h := NewArgon2idHasher(0, 0, 0)
+	encoded, err := h.Hash("secret")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=8,t=1,p=1$") {
+		t.Errorf("Hash() with zero parameters = %q, want the argon2 minimums", encoded)
+	}
+}
+
+func TestPasswordService(t *testing.T) {
+	argon2Config := testHashConfig
+	bcryptConfig := testHashConfig
+	bcryptConfig.Algorithm = "bcrypt"
+	unknownConfig := testHashConfig
+	unknownConfig.Algorithm = "md5"
+
+	argon2Service := NewPasswordService(&argon2Config)
+	bcryptService := NewPasswordService(&bcryptConfig)
+
+	argon2Hash, err := argon2Service.HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	bcryptHash, err := bcryptService.HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		service    *PasswordService
+		hash       string
+		wantRehash bool
+		wantPrefix string
+	}{
+		{"argon2id hash with argon2id configured", argon2Service, argon2Hash, false, "$argon2id$"},
+		{"legacy bcrypt hash is upgraded", argon2Service, bcryptHash, true, "$argon2id$"},
+		{"bcrypt hash with bcrypt configured", bcryptService, bcryptHash, false, "$2a$"},
+		{"argon2id hash after switching back to bcrypt", bcryptService, argon2Hash, true, "$2a$"},
+		{"unknown algorithm falls back to argon2id", NewPasswordService(&unknownConfig), argon2Hash, false, "$argon2id$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every supported hash verifies, whatever algorithm new hashes use
+			if err := tt.service.ComparePassword(tt.hash, "secret"); err != nil {
+				t.Errorf("ComparePassword() error = %v", err)
+			}
+			if err := tt.service.ComparePassword(tt.hash, "wrong"); err != errors.ErrPasswordNotMatch {
+				t.Errorf("ComparePassword() of a wrong password error = %v, want %v", err, errors.ErrPasswordNotMatch)
+			}
+			if got := tt.service.NeedsRehash(tt.hash); got != tt.wantRehash {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.wantRehash)
+			}
+
+			fresh, err := tt.service.HashPassword("secret")
+			if err != nil {
+				t.Fatalf("HashPassword() error = %v", err)
+			}
+			if !strings.HasPrefix(fresh, tt.wantPrefix) || tt.service.NeedsRehash(fresh) {
+				t.Errorf("HashPassword() = %q, want a current %s hash", fresh, tt.wantPrefix)
+			}
+		})
+	}
+
+	if err := argon2Service.ComparePassword("plaintext", "plaintext"); err == nil {
+		t.Error("ComparePassword() accepted an unknown hash format")
+	}
+}
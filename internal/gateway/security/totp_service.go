@@ -0,0 +1,140 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-gin-clean/pkg/config"
+)
+
+// TOTP parameters (RFC 6238 defaults, the only ones all authenticator apps support)
+const (
+	totpDigits     = 6
+	totpPeriod     = 30 // Seconds per time step
+	totpSkew       = 1  // Steps accepted before and after the current one, for clock drift
+	totpSecretSize = 20 // 160-bit secret, as recommended by RFC 4226
+
+	recoveryCodeSize = 5 // Random bytes per recovery code (10 hex characters)
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TOTPService struct {
+	issuer string
+}
+
+func NewTOTPService(cfg *config.MFAConfig) *TOTPService {
+	return &TOTPService{issuer: cfg.Issuer}
+}
+
+// Issuer returns the issuer name shown in authenticator apps
+func (s *TOTPService) Issuer() string {
+	return s.issuer
+}
+
+// Digits returns the number of digits of a code
+func (s *TOTPService) Digits() int {
+	return totpDigits
+}
+
+// Period returns the lifetime of a code in seconds
+func (s *TOTPService) Period() int {
+	return totpPeriod
+}
+
+// GenerateSecret creates a random base32 encoded TOTP secret
+func (s *TOTPService) GenerateSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// ProvisioningURI builds the otpauth:// URI authenticator apps import, usually as a QR code
+func (s *TOTPService) ProvisioningURI(accountName string, secret string) string {
+	label := url.PathEscape(s.issuer) + ":" + url.PathEscape(accountName)
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", s.issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+
+	// Authenticator apps expect %20 rather than + for spaces
+	return "otpauth://totp/" + label + "?" + strings.ReplaceAll(params.Encode(), "+", "%20")
+}
+
+// Validate checks code against the secret at time t, allowing for clock drift.
+// It returns the time step the code belongs to so callers can reject replays.
+func (s *TOTPService) Validate(secret string, code string, t time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return 0, false
+	}
+
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := t.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(hotp(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// hotp computes the RFC 4226 code for counter
+func hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// GenerateRecoveryCodes creates n random one-time recovery codes formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		raw := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := hex.EncodeToString(raw)
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode returns the hex SHA-256 of a recovery code, ignoring case, spaces and dashes.
+// Recovery codes are random, so a fast hash is enough to keep them unusable if the table leaks.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
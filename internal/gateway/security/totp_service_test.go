@@ -0,0 +1,156 @@
+package security
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go-gin-clean/pkg/config"
+)
+
+// rfc6238Secret is the SHA-1 seed of the RFC 6238 test vectors, base32 encoded
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPValidateRFC6238Vectors(t *testing.T) {
+	s := NewTOTPService(&config.MFAConfig{Issuer: "Portal"})
+
+	// RFC 6238 appendix B, truncated to the 6 digits authenticator apps show
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			step, ok := s.Validate(rfc6238Secret, tt.code, time.Unix(tt.unix, 0))
+			if !ok {
+				t.Fatalf("Validate(%s) at %d rejected the RFC code", tt.code, tt.unix)
+			}
+			if want := tt.unix / totpPeriod; step != want {
+				t.Errorf("Validate() step = %d, want %d", step, want)
+			}
+		})
+	}
+}
+
+func TestTOTPValidateWindow(t *testing.T) {
+	s := NewTOTPService(&config.MFAConfig{Issuer: "Portal"})
+	now := time.Unix(1234567890, 0)
+	code := "005924" // Valid for the step of now
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		at     time.Time
+		want   bool
+	}{
+		{"current step", rfc6238Secret, code, now, true},
+		{"one step of drift behind", rfc6238Secret, code, now.Add(totpPeriod * time.Second), true},
+		{"one step of drift ahead", rfc6238Secret, code, now.Add(-totpPeriod * time.Second), true},
+		{"two steps behind", rfc6238Secret, code, now.Add(2 * totpPeriod * time.Second), false},
+		{"two steps ahead", rfc6238Secret, code, now.Add(-2 * totpPeriod * time.Second), false},
+		{"spaces are ignored", rfc6238Secret, "005 924", now, true},
+		{"lowercase secret with padding", strings.ToLower(rfc6238Secret) + "====", code, now, true},
+		{"wrong code", rfc6238Secret, "005925", now, false},
+		{"too short", rfc6238Secret, "05924", now, false},
+		{"too long", rfc6238Secret, "0059240", now, false},
+		{"invalid secret", "not base32!", code, now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := s.Validate(tt.secret, tt.code, tt.at); got != tt.want {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTOTPGenerateSecret(t *testing.T) {
+	s := NewTOTPService(&config.MFAConfig{Issuer: "Portal"})
+
+	secret, err := s.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("GenerateSecret() returned invalid base32 %q: %v", secret, err)
+	}
+	if len(key) != totpSecretSize {
+		t.Errorf("secret has %d bytes, want %d", len(key), totpSecretSize)
+	}
+
+	// A code computed for the secret validates
+	now := time.Now()
+	if _, ok := s.Validate(secret, hotp(key, now.Unix()/totpPeriod), now); !ok {
+		t.Error("Validate() rejected the current code of a generated secret")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	s := NewTOTPService(&config.MFAConfig{Issuer: "ERP Portal"})
+
+	uri, err := url.Parse(s.ProvisioningURI("jane@example.com", rfc6238Secret))
+	if err != nil {
+		t.Fatalf("ProvisioningURI() is not a URL: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("ProvisioningURI() = %s, want otpauth://totp/...", uri)
+	}
+	if uri.Path != "/ERP Portal:jane@example.com" {
+		t.Errorf("label = %q, want %q", uri.Path, "/ERP Portal:jane@example.com")
+	}
+	if strings.Contains(uri.RawQuery, "+") {
+		t.Errorf("query %q encodes spaces as +", uri.RawQuery)
+	}
+
+	query := uri.Query()
+	want := map[string]string{"secret": rfc6238Secret, "issuer": "ERP Portal", "digits": "6", "period": "30", "algorithm": "SHA1"}
+	for k, v := range want {
+		if query.Get(k) != v {
+			t.Errorf("%s = %q, want %q", k, query.Get(k), v)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("GenerateRecoveryCodes() returned %d codes, want 10", len(codes))
+	}
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("code %q is not formatted as xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q generated twice", code)
+		}
+		seen[code] = true
+	}
+
+	// Users may type codes in any case, with or without the dash
+	hash := HashRecoveryCode("abcde-12345")
+	for _, typed := range []string{"ABCDE-12345", "abcde12345", "abcde 12345"} {
+		if HashRecoveryCode(typed) != hash {
+			t.Errorf("HashRecoveryCode(%q) differs from the formatted code", typed)
+		}
+	}
+	if HashRecoveryCode("abcde-12346") == hash {
+		t.Error("different codes share a hash")
+	}
+}
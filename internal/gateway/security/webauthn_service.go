@@ -0,0 +1,144 @@
+package security
+
+import (
+	"bytes"
+	"log"
+
+	"go-gin-clean/pkg/config"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// PasskeyUser is the account a WebAuthn ceremony runs for. ID is the opaque user
+// handle stored on discoverable credentials; it must never change for a user.
+type PasskeyUser struct {
+	ID          []byte
+	Name        string
+	DisplayName string
+	Credentials []webauthn.Credential
+}
+
+func (u *PasskeyUser) WebAuthnID() []byte {
+	return u.ID
+}
+
+func (u *PasskeyUser) WebAuthnName() string {
+	return u.Name
+}
+
+func (u *PasskeyUser) WebAuthnDisplayName() string {
+	return u.DisplayName
+}
+
+func (u *PasskeyUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.Credentials
+}
+
+func (u *PasskeyUser) WebAuthnIcon() string {
+	return ""
+}
+
+// PasskeyUserLookup resolves the owner of a credential during a passwordless login
+type PasskeyUserLookup func(credentialID []byte, userHandle []byte) (*PasskeyUser, error)
+
+// WebAuthnService runs the registration and assertion ceremonies of passkeys
+type WebAuthnService struct {
+	webAuthn *webauthn.WebAuthn
+}
+
+func NewWebAuthnService(cfg *config.WebAuthnConfig) *WebAuthnService {
+	timeout := webauthn.TimeoutConfig{
+		Enforce:    true,
+		Timeout:    cfg.CeremonyTTL,
+		TimeoutUVD: cfg.CeremonyTTL,
+	}
+
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize WebAuthn: %v", err)
+	}
+
+	return &WebAuthnService{webAuthn: webAuthn}
+}
+
+// BeginRegistration creates the options for navigator.credentials.create(). The user's
+// existing credentials are excluded so an authenticator is not registered twice.
+func (s *WebAuthnService) BeginRegistration(user *PasskeyUser) (*protocol.CredentialCreation, *webauthn.SessionData, error) {
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.Credentials))
+	for _, credential := range user.Credentials {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	return s.webAuthn.BeginRegistration(user,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			UserVerification: protocol.VerificationPreferred,
+		}),
+		// Discoverable credentials allow passwordless login; keys without storage still work as a second factor
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+}
+
+// FinishRegistration verifies the attestation in body, the JSON of the created credential
+func (s *WebAuthnService) FinishRegistration(user *PasskeyUser, session webauthn.SessionData, body []byte) (*webauthn.Credential, error) {
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return s.webAuthn.CreateCredential(user, session, parsed)
+}
+
+// BeginDiscoverableLogin creates the options for a passwordless login where the
+// authenticator picks the account. User verification is required because the passkey
+// replaces the password.
+func (s *WebAuthnService) BeginDiscoverableLogin() (*protocol.CredentialAssertion, *webauthn.SessionData, error) {
+	return s.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+}
+
+// FinishDiscoverableLogin verifies the assertion in body and returns the owner and the
+// credential that signed it
+func (s *WebAuthnService) FinishDiscoverableLogin(lookup PasskeyUserLookup, session webauthn.SessionData, body []byte) (*PasskeyUser, *webauthn.Credential, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var owner *PasskeyUser
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		user, err := lookup(rawID, userHandle)
+		if err != nil {
+			return nil, err
+		}
+		owner = user
+		return user, nil
+	}
+
+	credential, err := s.webAuthn.ValidateDiscoverableLogin(handler, session, parsed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return owner, credential, nil
+}
+
+// BeginLogin creates the options for using one of the user's passkeys as a second factor
+func (s *WebAuthnService) BeginLogin(user *PasskeyUser) (*protocol.CredentialAssertion, *webauthn.SessionData, error) {
+	return s.webAuthn.BeginLogin(user, webauthn.WithUserVerification(protocol.VerificationPreferred))
+}
+
+// FinishLogin verifies the assertion in body against the user's passkeys
+func (s *WebAuthnService) FinishLogin(user *PasskeyUser, session webauthn.SessionData, body []byte) (*webauthn.Credential, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return s.webAuthn.ValidateLogin(user, session, parsed)
+}
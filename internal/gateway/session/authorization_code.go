@@ -0,0 +1,132 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	AuthorizationRequestKeyPrefix = "oauth_request:" // Pending OpenID Connect logins awaiting consent
+	AuthorizationCodeKeyPrefix    = "oauth_code:"
+)
+
+// generateOAuthToken creates a cryptographically secure random token with the given prefix
+func generateOAuthToken(prefix string) (string, error) {
+	bytes := make([]byte, 32) // 256-bit token
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return prefix + hex.EncodeToString(bytes), nil
+}
+
+// CreateAuthorizationRequest stores a validated authorization request until the user
+// approves or denies it on the consent screen
+func (s *SessionService) CreateAuthorizationRequest(ctx context.Context, value *model.AuthorizationRequestValue, ttl time.Duration) (string, error) {
+	requestID, err := generateOAuthToken("azr_")
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	value.IssuedAt = now.Unix()
+	value.ExpiresAt = now.Add(ttl).Unix()
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal authorization request: %w", err)
+	}
+
+	if err := s.redisClient.Set(ctx, AuthorizationRequestKeyPrefix+requestID, valueJSON, ttl).Err(); err != nil {
+		return "", fmt.Errorf("failed to store authorization request in Redis: %w", err)
+	}
+
+	return requestID, nil
+}
+
+// GetAuthorizationRequest returns a pending authorization request without consuming it
+func (s *SessionService) GetAuthorizationRequest(ctx context.Context, requestID string) (*model.AuthorizationRequestValue, error) {
+	valueJSON, err := s.redisClient.Get(ctx, AuthorizationRequestKeyPrefix+requestID).Result()
+	if err == redis.Nil {
+		return nil, errors.ErrOAuthAuthorizationRequestInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authorization request from Redis: %w", err)
+	}
+
+	var value model.AuthorizationRequestValue
+	if err := json.Unmarshal([]byte(valueJSON), &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal authorization request: %w", err)
+	}
+
+	return &value, nil
+}
+
+// ConsumeAuthorizationRequest atomically reads and deletes a pending authorization
+// request, so it can be decided at most once
+func (s *SessionService) ConsumeAuthorizationRequest(ctx context.Context, requestID string) (*model.AuthorizationRequestValue, error) {
+	valueJSON, err := s.redisClient.GetDel(ctx, AuthorizationRequestKeyPrefix+requestID).Result()
+	if err == redis.Nil {
+		return nil, errors.ErrOAuthAuthorizationRequestInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume authorization request: %w", err)
+	}
+
+	var value model.AuthorizationRequestValue
+	if err := json.Unmarshal([]byte(valueJSON), &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal authorization request: %w", err)
+	}
+
+	return &value, nil
+}
+
+// CreateAuthorizationCode stores the grant a user approved behind a short-lived code
+func (s *SessionService) CreateAuthorizationCode(ctx context.Context, value *model.AuthorizationCodeValue, ttl time.Duration) (string, error) {
+	code, err := generateOAuthToken("")
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	value.IssuedAt = now.Unix()
+	value.ExpiresAt = now.Add(ttl).Unix()
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal authorization code: %w", err)
+	}
+
+	if err := s.redisClient.Set(ctx, AuthorizationCodeKeyPrefix+code, valueJSON, ttl).Err(); err != nil {
+		return "", fmt.Errorf("failed to store authorization code in Redis: %w", err)
+	}
+
+	return code, nil
+}
+
+// ConsumeAuthorizationCode atomically reads and deletes an authorization code, so a
+// code is redeemed at most once (RFC 6749 section 4.1.2)
+func (s *SessionService) ConsumeAuthorizationCode(ctx context.Context, code string) (*model.AuthorizationCodeValue, error) {
+	valueJSON, err := s.redisClient.GetDel(ctx, AuthorizationCodeKeyPrefix+code).Result()
+	if err == redis.Nil {
+		return nil, errors.ErrOAuthGrantInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume authorization code: %w", err)
+	}
+
+	var value model.AuthorizationCodeValue
+	if err := json.Unmarshal([]byte(valueJSON), &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal authorization code: %w", err)
+	}
+
+	return &value, nil
+}
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	MFAChallengeKeyPrefix         = "mfa_challenge:"
+	MFAChallengeAttemptsKeyPrefix = "mfa_challenge_attempts:" // Wrong codes entered for a challenge
+)
+
+// GenerateChallengeToken creates a cryptographically secure random MFA challenge token
+func (s *SessionService) GenerateChallengeToken() (string, error) {
+	bytes := make([]byte, 32) // 256-bit token
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return "mfa_" + hex.EncodeToString(bytes), nil
+}
+
+// CreateMFAChallenge stores a pending login that still needs its second factor
+func (s *SessionService) CreateMFAChallenge(ctx context.Context, value *model.MFAChallengeValue, ttl time.Duration) (string, error) {
+	token, err := s.GenerateChallengeToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	value.IssuedAt = now.Unix()
+	value.ExpiresAt = now.Add(ttl).Unix()
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal MFA challenge: %w", err)
+	}
+
+	if err := s.redisClient.Set(ctx, MFAChallengeKeyPrefix+token, valueJSON, ttl).Err(); err != nil {
+		return "", fmt.Errorf("failed to store MFA challenge in Redis: %w", err)
+	}
+
+	return token, nil
+}
+
+// GetMFAChallenge returns a pending challenge without consuming it
+func (s *SessionService) GetMFAChallenge(ctx context.Context, token string) (*model.MFAChallengeValue, error) {
+	valueJSON, err := s.redisClient.Get(ctx, MFAChallengeKeyPrefix+token).Result()
+	if err == redis.Nil {
+		return nil, errors.ErrMFAChallengeInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MFA challenge from Redis: %w", err)
+	}
+
+	var value model.MFAChallengeValue
+	if err := json.Unmarshal([]byte(valueJSON), &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal MFA challenge: %w", err)
+	}
+
+	return &value, nil
+}
+
+// ReserveMFAChallengeAttempt counts an attempt before its code is checked, so
+// concurrent requests cannot try more than maxAttempts codes. It returns the number of
+// attempts left after this one and discards the challenge once none were left.
+func (s *SessionService) ReserveMFAChallengeAttempt(ctx context.Context, token string, maxAttempts int) (int, error) {
+	attemptsKey := MFAChallengeAttemptsKeyPrefix + token
+
+	ttl, err := s.redisClient.TTL(ctx, MFAChallengeKeyPrefix+token).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read MFA challenge TTL: %w", err)
+	}
+	if ttl <= 0 {
+		return 0, errors.ErrMFAChallengeInvalid
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	incr := pipe.Incr(ctx, attemptsKey)
+	pipe.Expire(ctx, attemptsKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to record MFA attempt: %w", err)
+	}
+
+	remaining := maxAttempts - int(incr.Val())
+	if remaining < 0 {
+		_, _ = s.ConsumeMFAChallenge(ctx, token)
+		return 0, errors.ErrMFAChallengeInvalid
+	}
+	return remaining, nil
+}
+
+// ConsumeMFAChallenge deletes a challenge. Only the caller that actually removed it
+// gets true, so a challenge can complete at most one login.
+func (s *SessionService) ConsumeMFAChallenge(ctx context.Context, token string) (bool, error) {
+	deleted, err := s.redisClient.Del(ctx, MFAChallengeKeyPrefix+token).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete MFA challenge from Redis: %w", err)
+	}
+
+	_ = s.redisClient.Del(ctx, MFAChallengeAttemptsKeyPrefix+token).Err()
+	return deleted == 1, nil
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+)
+
+func TestReserveMFAChallengeAttempt(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestSessionService(t, &config.SessionConfig{})
+
+	token, err := s.CreateMFAChallenge(ctx, &model.MFAChallengeValue{UserID: 1}, time.Minute)
+	if err != nil {
+		t.Fatalf("CreateMFAChallenge() error = %v", err)
+	}
+
+	for _, want := range []int{2, 1, 0} {
+		remaining, err := s.ReserveMFAChallengeAttempt(ctx, token, 3)
+		if err != nil {
+			t.Fatalf("ReserveMFAChallengeAttempt() error = %v", err)
+		}
+		if remaining != want {
+			t.Errorf("ReserveMFAChallengeAttempt() remaining = %d, want %d", remaining, want)
+		}
+	}
+
+	// The fourth attempt is refused and discards the challenge
+	if _, err := s.ReserveMFAChallengeAttempt(ctx, token, 3); err != errors.ErrMFAChallengeInvalid {
+		t.Errorf("ReserveMFAChallengeAttempt() past the limit error = %v, want %v", err, errors.ErrMFAChallengeInvalid)
+	}
+	if _, err := s.GetMFAChallenge(ctx, token); err != errors.ErrMFAChallengeInvalid {
+		t.Errorf("GetMFAChallenge() after the last attempt error = %v, want %v", err, errors.ErrMFAChallengeInvalid)
+	}
+}
+
+func TestReserveMFAChallengeAttemptConcurrently(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestSessionService(t, &config.SessionConfig{})
+
+	token, err := s.CreateMFAChallenge(ctx, &model.MFAChallengeValue{UserID: 1}, time.Minute)
+	if err != nil {
+		t.Fatalf("CreateMFAChallenge() error = %v", err)
+	}
+
+	// Parallel guesses cannot get more attempts than allowed
+	const maxAttempts = 5
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	granted := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.ReserveMFAChallengeAttempt(ctx, token, maxAttempts); err == nil {
+				mu.Lock()
+				granted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if granted != maxAttempts {
+		t.Errorf("%d attempts granted, want %d", granted, maxAttempts)
+	}
+}
+
+func TestMFAChallengeUnknownOrConsumed(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestSessionService(t, &config.SessionConfig{})
+
+	if _, err := s.ReserveMFAChallengeAttempt(ctx, "mfa_unknown", 3); err != errors.ErrMFAChallengeInvalid {
+		t.Errorf("ReserveMFAChallengeAttempt() of an unknown challenge error = %v, want %v", err, errors.ErrMFAChallengeInvalid)
+	}
+
+	token, err := s.CreateMFAChallenge(ctx, &model.MFAChallengeValue{UserID: 1}, time.Minute)
+	if err != nil {
+		t.Fatalf("CreateMFAChallenge() error = %v", err)
+	}
+
+	// Only one of two logins racing on the same challenge completes
+	first, err := s.ConsumeMFAChallenge(ctx, token)
+	if err != nil || !first {
+		t.Fatalf("ConsumeMFAChallenge() = %v, %v, want true", first, err)
+	}
+	if second, _ := s.ConsumeMFAChallenge(ctx, token); second {
+		t.Error("ConsumeMFAChallenge() completed the same challenge twice")
+	}
+}
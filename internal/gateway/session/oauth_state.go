@@ -0,0 +1,107 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	OAuthStateKeyPrefix      = "oauth_state:" // Logins in progress at external identity providers
+	OAuthLoginKeyPrefix      = "oauth_login:" // Finished logins awaiting the frontend's code exchange
+	DefaultOAuthStateTTL     = 10 * time.Minute
+	DefaultOAuthLoginCodeTTL = 1 * time.Minute
+)
+
+// CreateOAuthState stores a login at an external identity provider and returns the
+// state parameter that identifies it in the callback. Any replica behind Kong can
+// handle the callback.
+func (s *SessionService) CreateOAuthState(ctx context.Context, value *model.OAuthStateValue, ttl time.Duration) (string, error) {
+	state, err := generateOAuthToken("")
+	if err != nil {
+		return "", err
+	}
+
+	value.ExpiresAt = time.Now().Add(ttl).Unix()
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal OAuth state: %w", err)
+	}
+
+	if err := s.redisClient.Set(ctx, OAuthStateKeyPrefix+state, valueJSON, ttl).Err(); err != nil {
+		return "", fmt.Errorf("failed to store OAuth state in Redis: %w", err)
+	}
+
+	return state, nil
+}
+
+// ConsumeOAuthState atomically reads and deletes a login, so a state is accepted at
+// most once and only in the callback of the provider it was issued for
+func (s *SessionService) ConsumeOAuthState(ctx context.Context, state string, provider string) (*model.OAuthStateValue, error) {
+	valueJSON, err := s.redisClient.GetDel(ctx, OAuthStateKeyPrefix+state).Result()
+	if err == redis.Nil {
+		return nil, errors.ErrOAuthStateInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume OAuth state: %w", err)
+	}
+
+	var value model.OAuthStateValue
+	if err := json.Unmarshal([]byte(valueJSON), &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal OAuth state: %w", err)
+	}
+
+	if value.Provider != provider {
+		return nil, errors.ErrOAuthStateInvalid
+	}
+
+	return &value, nil
+}
+
+// CreateOAuthLogin stores a finished external login behind a one-time code, so the
+// frontend receives a code in the redirect URL instead of tokens
+func (s *SessionService) CreateOAuthLogin(ctx context.Context, value *model.OAuthLoginValue, ttl time.Duration) (string, error) {
+	code, err := generateOAuthToken("olc_")
+	if err != nil {
+		return "", err
+	}
+
+	value.ExpiresAt = time.Now().Add(ttl).Unix()
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal OAuth login: %w", err)
+	}
+
+	if err := s.redisClient.Set(ctx, OAuthLoginKeyPrefix+code, valueJSON, ttl).Err(); err != nil {
+		return "", fmt.Errorf("failed to store OAuth login in Redis: %w", err)
+	}
+
+	return code, nil
+}
+
+// ConsumeOAuthLogin atomically reads and deletes a login code, so it is exchanged
+// at most once
+func (s *SessionService) ConsumeOAuthLogin(ctx context.Context, code string) (*model.OAuthLoginValue, error) {
+	valueJSON, err := s.redisClient.GetDel(ctx, OAuthLoginKeyPrefix+code).Result()
+	if err == redis.Nil {
+		return nil, errors.ErrOAuthLoginCodeInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume OAuth login: %w", err)
+	}
+
+	var value model.OAuthLoginValue
+	if err := json.Unmarshal([]byte(valueJSON), &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal OAuth login: %w", err)
+	}
+
+	return &value, nil
+}
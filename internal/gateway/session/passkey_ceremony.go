@@ -0,0 +1,63 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const PasskeyCeremonyKeyPrefix = "passkey_ceremony:"
+
+// CreatePasskeyCeremony stores the state of a WebAuthn ceremony until the browser returns
+// the authenticator's response
+func (s *SessionService) CreatePasskeyCeremony(ctx context.Context, value *model.PasskeyCeremonyValue, ttl time.Duration) (string, error) {
+	bytes := make([]byte, 32) // 256-bit token
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	token := "pkc_" + hex.EncodeToString(bytes)
+
+	value.ExpiresAt = time.Now().Add(ttl).Unix()
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal passkey ceremony: %w", err)
+	}
+
+	if err := s.redisClient.Set(ctx, PasskeyCeremonyKeyPrefix+token, valueJSON, ttl).Err(); err != nil {
+		return "", fmt.Errorf("failed to store passkey ceremony in Redis: %w", err)
+	}
+
+	return token, nil
+}
+
+// ConsumePasskeyCeremony returns and deletes a ceremony, so every WebAuthn challenge
+// can be answered only once
+func (s *SessionService) ConsumePasskeyCeremony(ctx context.Context, token string, purpose string) (*model.PasskeyCeremonyValue, error) {
+	valueJSON, err := s.redisClient.GetDel(ctx, PasskeyCeremonyKeyPrefix+token).Result()
+	if err == redis.Nil {
+		return nil, errors.ErrPasskeyCeremonyInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkey ceremony from Redis: %w", err)
+	}
+
+	var value model.PasskeyCeremonyValue
+	if err := json.Unmarshal([]byte(valueJSON), &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal passkey ceremony: %w", err)
+	}
+
+	if value.Purpose != purpose {
+		return nil, errors.ErrPasskeyCeremonyInvalid
+	}
+
+	return &value, nil
+}
@@ -0,0 +1,321 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	RefreshTokenKeyPrefix   = "refresh:"
+	TokenFamilyKeyPrefix    = "token_family:"
+	ClientFamiliesKeyPrefix = "client_families:" // Per-client set of token families issued to OpenID Connect clients
+	DefaultRefreshTTL       = 7 * 24 * time.Hour // 7 days default refresh token expiration
+)
+
+// TokenFamily tracks the current access/refresh token pair issued from one login.
+// Every rotation replaces both tokens; presenting a refresh token that is no longer
+// the current one means it was stolen or replayed, and the whole family is revoked.
+type TokenFamily struct {
+	ID           string
+	UserID       int64
+	TenantID     int64
+	AccessToken  string
+	RefreshToken string
+	AuthTime     int64  // When the user originally logged in; rotation never resets it
+	ClientID     string // OpenID Connect client the family was issued to, empty for portal logins
+}
+
+// GenerateRefreshToken creates a cryptographically secure random refresh token
+func (s *SessionService) GenerateRefreshToken() (string, error) {
+	bytes := make([]byte, 32) // 256-bit token
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return "rt_" + hex.EncodeToString(bytes), nil
+}
+
+// NewFamilyID creates a random identifier for a new token family
+func (s *SessionService) NewFamilyID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate family ID: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// RefreshTTL returns the lifetime of refresh tokens
+func (s *SessionService) RefreshTTL() time.Duration {
+	return s.refreshTTL
+}
+
+// CreateRefreshToken stores a new refresh token for the access session and makes
+// the pair the current one of the session's token family. The refresh token never
+// outlives the session's absolute lifetime.
+func (s *SessionService) CreateRefreshToken(ctx context.Context, value *model.RefreshTokenValue, accessToken string, sessionValue *model.SessionValue) (string, error) {
+	refreshToken, err := s.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().Unix()
+	value.IssuedAt = now
+	value.ExpiresAt = now + int64(s.refreshTTL.Seconds())
+	if sessionValue.MaxExpiresAt > 0 {
+		value.ExpiresAt = min(value.ExpiresAt, sessionValue.MaxExpiresAt)
+	}
+	ttl := time.Duration(value.ExpiresAt-now) * time.Second
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal refresh token value: %w", err)
+	}
+
+	familyKey := TokenFamilyKeyPrefix + value.FamilyID
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, RefreshTokenKeyPrefix+refreshToken, valueJSON, ttl)
+	pipe.HSet(ctx, familyKey,
+		"uid", value.UserID,
+		"tid", sessionValue.TenantID,
+		"access", accessToken,
+		"refresh", refreshToken,
+		"auth_time", sessionValue.AuthTime,
+		"client_id", sessionValue.ClientID,
+	)
+	pipe.Expire(ctx, familyKey, ttl)
+	if sessionValue.ClientID != "" {
+		indexKey := ClientFamiliesKeyPrefix + sessionValue.ClientID
+		pipe.SAdd(ctx, indexKey, value.FamilyID)
+		pipe.ExpireGT(ctx, indexKey, ttl)
+		pipe.ExpireNX(ctx, indexKey, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("failed to store refresh token in Redis: %w", err)
+	}
+
+	return refreshToken, nil
+}
+
+// RotateRefreshToken consumes a refresh token and returns its value together with
+// the token family it belongs to. The caller is expected to issue a new pair with
+// CreateRefreshToken and delete the previous access token.
+// Presenting a token that was already rotated revokes the entire family. A token of
+// a family issued to another client (or to the portal, clientID "") is refused as
+// invalid without rotating it.
+func (s *SessionService) RotateRefreshToken(ctx context.Context, refreshToken string, clientID string) (*model.RefreshTokenValue, *TokenFamily, error) {
+	valueJSON, err := s.redisClient.Get(ctx, RefreshTokenKeyPrefix+refreshToken).Result()
+	if err == redis.Nil {
+		return nil, nil, errors.ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get refresh token from Redis: %w", err)
+	}
+
+	var value model.RefreshTokenValue
+	if err := json.Unmarshal([]byte(valueJSON), &value); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal refresh token value: %w", err)
+	}
+
+	if time.Now().Unix() > value.ExpiresAt {
+		return nil, nil, errors.ErrRefreshTokenInvalid
+	}
+
+	familyKey := TokenFamilyKeyPrefix + value.FamilyID
+	var family *TokenFamily
+	reused := false
+
+	// Watch the family so two concurrent refreshes with the same token cannot both win
+	err = s.redisClient.Watch(ctx, func(tx *redis.Tx) error {
+		fields, err := tx.HGetAll(ctx, familyKey).Result()
+		if err != nil {
+			return err
+		}
+		if len(fields) == 0 || fields["client_id"] != clientID {
+			return errors.ErrRefreshTokenInvalid
+		}
+
+		if fields["refresh"] != refreshToken {
+			reused = true
+			return nil
+		}
+
+		family = parseTokenFamily(value.FamilyID, fields)
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, familyKey, "refresh", "")
+			return nil
+		})
+		return err
+	}, familyKey)
+
+	if err == redis.TxFailedErr {
+		// Another request rotated this token concurrently
+		reused = true
+	} else if err != nil {
+		if err == errors.ErrRefreshTokenInvalid {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	if reused {
+		_ = s.RevokeTokenFamily(ctx, value.FamilyID)
+		return nil, nil, errors.ErrRefreshTokenReused
+	}
+
+	return &value, family, nil
+}
+
+// RestoreRefreshToken undoes a rotation whose new pair could not be issued, so a
+// transient failure does not lock the user out of refreshing. It only applies while
+// the family is still waiting for its new pair.
+func (s *SessionService) RestoreRefreshToken(ctx context.Context, family *TokenFamily) error {
+	familyKey := TokenFamilyKeyPrefix + family.ID
+
+	err := s.redisClient.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.HGet(ctx, familyKey, "refresh").Result()
+		if err == redis.Nil {
+			// The family was revoked meanwhile
+			return nil
+		}
+		if err != nil || current != "" {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, familyKey, "refresh", family.RefreshToken)
+			return nil
+		})
+		return err
+	}, familyKey)
+	if err != nil && err != redis.TxFailedErr {
+		return fmt.Errorf("failed to restore refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshToken looks up a refresh token without consuming it. Only the current
+// refresh token of a live family is returned; rotated tokens count as invalid.
+func (s *SessionService) GetRefreshToken(ctx context.Context, refreshToken string) (*model.RefreshTokenValue, *TokenFamily, error) {
+	valueJSON, err := s.redisClient.Get(ctx, RefreshTokenKeyPrefix+refreshToken).Result()
+	if err == redis.Nil {
+		return nil, nil, errors.ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get refresh token from Redis: %w", err)
+	}
+
+	var value model.RefreshTokenValue
+	if err := json.Unmarshal([]byte(valueJSON), &value); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal refresh token value: %w", err)
+	}
+
+	if time.Now().Unix() > value.ExpiresAt {
+		return nil, nil, errors.ErrRefreshTokenInvalid
+	}
+
+	fields, err := s.redisClient.HGetAll(ctx, TokenFamilyKeyPrefix+value.FamilyID).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get token family from Redis: %w", err)
+	}
+	if len(fields) == 0 || fields["refresh"] != refreshToken {
+		return nil, nil, errors.ErrRefreshTokenInvalid
+	}
+
+	return &value, parseTokenFamily(value.FamilyID, fields), nil
+}
+
+// RevokeTokenFamily deletes the current access session of a token family and the
+// family itself, which invalidates every refresh token ever issued in it
+func (s *SessionService) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	familyKey := TokenFamilyKeyPrefix + familyID
+
+	accessToken, err := s.redisClient.HGet(ctx, familyKey, "access").Result()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to read token family: %w", err)
+	}
+
+	if err := s.redisClient.Del(ctx, familyKey).Err(); err != nil {
+		return fmt.Errorf("failed to delete token family: %w", err)
+	}
+
+	if accessToken != "" {
+		return s.DeleteSession(ctx, accessToken)
+	}
+
+	return nil
+}
+
+// RevokeClientFamilies revokes every token family issued to an OpenID Connect client,
+// with its current access session, e.g. when the client is disabled or deleted
+func (s *SessionService) RevokeClientFamilies(ctx context.Context, clientID string) error {
+	indexKey := ClientFamiliesKeyPrefix + clientID
+	familyIDs, err := s.redisClient.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list client token families: %w", err)
+	}
+
+	for _, familyID := range familyIDs {
+		if err := s.RevokeTokenFamily(ctx, familyID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.redisClient.Del(ctx, indexKey).Err(); err != nil {
+		return fmt.Errorf("failed to delete client token family index: %w", err)
+	}
+	return nil
+}
+
+// revokeFamilyOf drops the token family of a session that is being deleted, but only
+// while that session is still the family's current access token. Sessions replaced
+// during rotation leave the family untouched.
+func (s *SessionService) revokeFamilyOf(ctx context.Context, refToken string, familyID string) {
+	if familyID == "" {
+		return
+	}
+
+	familyKey := TokenFamilyKeyPrefix + familyID
+	accessToken, err := s.redisClient.HGet(ctx, familyKey, "access").Result()
+	if err == nil && accessToken == refToken {
+		_ = s.redisClient.Del(ctx, familyKey).Err()
+	}
+}
+
+// setFamilyTenant keeps the family's tenant in sync when a session switches tenant,
+// so that later refreshes continue in the newly selected tenant
+func (s *SessionService) setFamilyTenant(ctx context.Context, familyID string, tenantID int64) {
+	if familyID == "" {
+		return
+	}
+
+	familyKey := TokenFamilyKeyPrefix + familyID
+	if n, err := s.redisClient.Exists(ctx, familyKey).Result(); err == nil && n > 0 {
+		_ = s.redisClient.HSet(ctx, familyKey, "tid", tenantID).Err()
+	}
+}
+
+func parseTokenFamily(id string, fields map[string]string) *TokenFamily {
+	userID, _ := strconv.ParseInt(fields["uid"], 10, 64)
+	tenantID, _ := strconv.ParseInt(fields["tid"], 10, 64)
+	authTime, _ := strconv.ParseInt(fields["auth_time"], 10, 64)
+	return &TokenFamily{
+		ID:           id,
+		UserID:       userID,
+		TenantID:     tenantID,
+		AccessToken:  fields["access"],
+		RefreshToken: fields["refresh"],
+		AuthTime:     authTime,
+		ClientID:     fields["client_id"],
+	}
+}
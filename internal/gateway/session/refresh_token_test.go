@@ -0,0 +1,276 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+)
+
+// newTestFamily logs a user in: an access session plus the first refresh token of
+// its family
+func newTestFamily(t *testing.T, s *SessionService, policy Policy) (accessToken string, refreshToken string, familyID string) {
+	t.Helper()
+	ctx := context.Background()
+
+	familyID, err := s.NewFamilyID()
+	if err != nil {
+		t.Fatalf("NewFamilyID() error = %v", err)
+	}
+
+	sessionValue := &model.SessionValue{UserID: 1, TenantID: 10, FamilyID: familyID}
+	accessToken, err = s.CreateSession(ctx, sessionValue, policy)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	refreshToken, err = s.CreateRefreshToken(ctx, &model.RefreshTokenValue{FamilyID: familyID, UserID: 1}, accessToken, sessionValue)
+	if err != nil {
+		t.Fatalf("CreateRefreshToken() error = %v", err)
+	}
+	return accessToken, refreshToken, familyID
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestSessionService(t, &config.SessionConfig{})
+	accessToken, refreshToken, familyID := newTestFamily(t, s, s.ResolvePolicy(nil))
+
+	value, family, err := s.RotateRefreshToken(ctx, refreshToken, "")
+	if err != nil {
+		t.Fatalf("RotateRefreshToken() error = %v", err)
+	}
+	if value.FamilyID != familyID || family.ID != familyID {
+		t.Errorf("rotated family = %q/%q, want %q", value.FamilyID, family.ID, familyID)
+	}
+	if family.UserID != 1 || family.TenantID != 10 || family.AccessToken != accessToken {
+		t.Errorf("family = %+v, want user 1 in tenant 10 with the login's access token", family)
+	}
+
+	// The consumed token is no longer current, even before the new pair exists
+	if _, _, err := s.GetRefreshToken(ctx, refreshToken); err != errors.ErrRefreshTokenInvalid {
+		t.Errorf("GetRefreshToken() after rotation error = %v, want %v", err, errors.ErrRefreshTokenInvalid)
+	}
+}
+
+func TestRotateRefreshTokenClientBinding(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestSessionService(t, &config.SessionConfig{})
+	policy := s.ResolvePolicy(nil)
+
+	familyID, err := s.NewFamilyID()
+	if err != nil {
+		t.Fatalf("NewFamilyID() error = %v", err)
+	}
+	sessionValue := &model.SessionValue{UserID: 1, TenantID: 10, FamilyID: familyID, ClientID: "grafana"}
+	accessToken, err := s.CreateSession(ctx, sessionValue, policy)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	refreshToken, err := s.CreateRefreshToken(ctx, &model.RefreshTokenValue{FamilyID: familyID, UserID: 1}, accessToken, sessionValue)
+	if err != nil {
+		t.Fatalf("CreateRefreshToken() error = %v", err)
+	}
+
+	// Refusing another client does not consume the token
+	for _, clientID := range []string{"", "erp-web"} {
+		if _, _, err := s.RotateRefreshToken(ctx, refreshToken, clientID); err != errors.ErrRefreshTokenInvalid {
+			t.Errorf("RotateRefreshToken() by %q error = %v, want %v", clientID, err, errors.ErrRefreshTokenInvalid)
+		}
+	}
+
+	_, family, err := s.RotateRefreshToken(ctx, refreshToken, "grafana")
+	if err != nil {
+		t.Fatalf("RotateRefreshToken() by the same client error = %v", err)
+	}
+	if family.ClientID != "grafana" {
+		t.Errorf("family client = %q, want grafana", family.ClientID)
+	}
+}
+
+func TestRevokeClientFamilies(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestSessionService(t, &config.SessionConfig{})
+	policy := s.ResolvePolicy(nil)
+
+	login := func(clientID string) (string, string) {
+		t.Helper()
+		familyID, err := s.NewFamilyID()
+		if err != nil {
+			t.Fatalf("NewFamilyID() error = %v", err)
+		}
+		sessionValue := &model.SessionValue{UserID: 1, TenantID: 10, FamilyID: familyID, ClientID: clientID}
+		accessToken, err := s.CreateSession(ctx, sessionValue, policy)
+		if err != nil {
+			t.Fatalf("CreateSession() error = %v", err)
+		}
+		refreshToken, err := s.CreateRefreshToken(ctx, &model.RefreshTokenValue{FamilyID: familyID, UserID: 1}, accessToken, sessionValue)
+		if err != nil {
+			t.Fatalf("CreateRefreshToken() error = %v", err)
+		}
+		return accessToken, refreshToken
+	}
+
+	tests := []struct {
+		name        string
+		clientID    string
+		wantRevoked bool
+	}{
+		{"first session of the client", "grafana", true},
+		{"second session of the client", "grafana", true},
+		{"another client", "erp-web", false},
+		{"portal session", "", false},
+	}
+	tokens := make([][2]string, len(tests))
+	for i, tt := range tests {
+		tokens[i][0], tokens[i][1] = login(tt.clientID)
+	}
+
+	if err := s.RevokeClientFamilies(ctx, "grafana"); err != nil {
+		t.Fatalf("RevokeClientFamilies() error = %v", err)
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, sessionErr := s.GetSession(ctx, tokens[i][0])
+			_, _, refreshErr := s.GetRefreshToken(ctx, tokens[i][1])
+			if revoked := sessionErr != nil && refreshErr != nil; revoked != tt.wantRevoked {
+				t.Errorf("session and refresh token revoked = %v, want %v (errors %v, %v)", revoked, tt.wantRevoked, sessionErr, refreshErr)
+			}
+		})
+	}
+}
+
+func TestRotateRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestSessionService(t, &config.SessionConfig{})
+	policy := s.ResolvePolicy(nil)
+	_, refreshToken, familyID := newTestFamily(t, s, policy)
+
+	_, family, err := s.RotateRefreshToken(ctx, refreshToken, "")
+	if err != nil {
+		t.Fatalf("RotateRefreshToken() error = %v", err)
+	}
+
+	// Issue the next pair, as the refresh endpoint does
+	nextValue := &model.SessionValue{UserID: 1, TenantID: 10, FamilyID: familyID}
+	nextAccess, err := s.CreateSession(ctx, nextValue, policy)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	nextRefresh, err := s.CreateRefreshToken(ctx, &model.RefreshTokenValue{FamilyID: familyID, UserID: 1}, nextAccess, nextValue)
+	if err != nil {
+		t.Fatalf("CreateRefreshToken() error = %v", err)
+	}
+	if err := s.DeleteSession(ctx, family.AccessToken); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+
+	// Replaying the old token means it leaked: the whole family goes
+	if _, _, err := s.RotateRefreshToken(ctx, refreshToken, ""); err != errors.ErrRefreshTokenReused {
+		t.Fatalf("RotateRefreshToken() replay error = %v, want %v", err, errors.ErrRefreshTokenReused)
+	}
+	if mr.Exists(TokenFamilyKeyPrefix + familyID) {
+		t.Error("token family survived the replay")
+	}
+	if _, err := s.GetSession(ctx, nextAccess); err == nil {
+		t.Error("current access session survived the replay")
+	}
+	if _, _, err := s.RotateRefreshToken(ctx, nextRefresh, ""); err != errors.ErrRefreshTokenInvalid {
+		t.Errorf("RotateRefreshToken() with the current token error = %v, want %v", err, errors.ErrRefreshTokenInvalid)
+	}
+}
+
+func TestRestoreRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestSessionService(t, &config.SessionConfig{})
+	_, refreshToken, _ := newTestFamily(t, s, s.ResolvePolicy(nil))
+
+	_, family, err := s.RotateRefreshToken(ctx, refreshToken, "")
+	if err != nil {
+		t.Fatalf("RotateRefreshToken() error = %v", err)
+	}
+
+	// Issuing the new pair failed; the client may retry with the same token
+	family.RefreshToken = refreshToken
+	if err := s.RestoreRefreshToken(ctx, family); err != nil {
+		t.Fatalf("RestoreRefreshToken() error = %v", err)
+	}
+	if _, _, err := s.RotateRefreshToken(ctx, refreshToken, ""); err != nil {
+		t.Errorf("RotateRefreshToken() after restore error = %v", err)
+	}
+}
+
+func TestRestoreRefreshTokenAfterRevocation(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestSessionService(t, &config.SessionConfig{})
+	_, refreshToken, familyID := newTestFamily(t, s, s.ResolvePolicy(nil))
+
+	_, family, err := s.RotateRefreshToken(ctx, refreshToken, "")
+	if err != nil {
+		t.Fatalf("RotateRefreshToken() error = %v", err)
+	}
+	if err := s.RevokeTokenFamily(ctx, familyID); err != nil {
+		t.Fatalf("RevokeTokenFamily() error = %v", err)
+	}
+
+	family.RefreshToken = refreshToken
+	if err := s.RestoreRefreshToken(ctx, family); err != nil {
+		t.Fatalf("RestoreRefreshToken() error = %v", err)
+	}
+	if mr.Exists(TokenFamilyKeyPrefix + familyID) {
+		t.Error("RestoreRefreshToken() brought a revoked family back")
+	}
+}
+
+func TestRefreshTokenLifetime(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestSessionService(t, &config.SessionConfig{RefreshTokenTTL: 24 * time.Hour})
+
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantTTL time.Duration
+	}{
+		{"refresh TTL", Policy{IdleTimeout: time.Minute, MaxLifetime: 48 * time.Hour}, 24 * time.Hour},
+		{"capped by the session lifetime", Policy{IdleTimeout: time.Minute, MaxLifetime: time.Hour}, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, refreshToken, familyID := newTestFamily(t, s, tt.policy)
+
+			for _, key := range []string{RefreshTokenKeyPrefix + refreshToken, TokenFamilyKeyPrefix + familyID} {
+				if ttl := mr.TTL(key); ttl < tt.wantTTL-time.Second || ttl > tt.wantTTL {
+					t.Errorf("TTL of %s = %v, want %v", key, ttl, tt.wantTTL)
+				}
+			}
+		})
+	}
+
+	_, refreshToken, _ := newTestFamily(t, s, s.ResolvePolicy(nil))
+	mr.FastForward(25 * time.Hour)
+	if _, _, err := s.RotateRefreshToken(ctx, refreshToken, ""); err != errors.ErrRefreshTokenInvalid {
+		t.Errorf("RotateRefreshToken() of an expired token error = %v, want %v", err, errors.ErrRefreshTokenInvalid)
+	}
+}
+
+func TestDeleteSessionRevokesCurrentFamily(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestSessionService(t, &config.SessionConfig{})
+	accessToken, refreshToken, familyID := newTestFamily(t, s, s.ResolvePolicy(nil))
+
+	// Logging out of the current session also ends its refresh tokens
+	if err := s.DeleteSession(ctx, accessToken); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+	if mr.Exists(TokenFamilyKeyPrefix + familyID) {
+		t.Error("token family survived the logout")
+	}
+	if _, _, err := s.RotateRefreshToken(ctx, refreshToken, ""); err != errors.ErrRefreshTokenInvalid {
+		t.Errorf("RotateRefreshToken() after logout error = %v, want %v", err, errors.ErrRefreshTokenInvalid)
+	}
+}
@@ -3,36 +3,111 @@ package session
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
+	"go-gin-clean/internal/entity"
 	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
 
 	"github.com/redis/go-redis/v9"
 )
 
 const (
-	SessionKeyPrefix = "session:"
-	DefaultTTL       = 30 * time.Minute // 30 minutes default session expiration
+	SessionKeyPrefix        = "session:"
+	UserSessionsKeyPrefix   = "user_sessions:"        // Per-user set of active reference tokens
+	LastSeenKeyPrefix       = "session_seen:"         // Per-user hash of session ID -> last activity (unix seconds)
+	InvalidationChannel     = "session_invalidations" // Pub/sub channel of changed or deleted reference tokens
+	DefaultTTL              = 30 * time.Minute        // 30 minutes default session expiration
+	DefaultMaxLifetime      = 24 * time.Hour          // 24 hours default absolute session lifetime
+	DefaultImpersonationTTL = 30 * time.Minute        // 30 minutes default impersonation session lifetime
 )
 
+// Policy defines how long a session may stay idle and how long it may live in total
+type Policy struct {
+	IdleTimeout time.Duration
+	MaxLifetime time.Duration
+}
+
+// UserSession pairs a reference token with the session value stored under it
+type UserSession struct {
+	RefToken string
+	Value    *model.SessionValue
+}
+
 type SessionService struct {
-	redisClient *redis.Client
-	sessionTTL  time.Duration
+	redisClient      *redis.Client
+	policy           Policy
+	refreshTTL       time.Duration
+	impersonationTTL time.Duration
 }
 
-func NewSessionService(redisClient *redis.Client, ttl time.Duration) *SessionService {
-	if ttl == 0 {
-		ttl = DefaultTTL
+func NewSessionService(redisClient *redis.Client, cfg *config.SessionConfig) *SessionService {
+	policy := Policy{
+		IdleTimeout: cfg.IdleTimeout,
+		MaxLifetime: cfg.MaxLifetime,
+	}
+	if policy.IdleTimeout == 0 {
+		policy.IdleTimeout = DefaultTTL
+	}
+	if policy.MaxLifetime == 0 {
+		policy.MaxLifetime = DefaultMaxLifetime
+	}
+
+	refreshTTL := cfg.RefreshTokenTTL
+	if refreshTTL == 0 {
+		refreshTTL = DefaultRefreshTTL
+	}
+
+	impersonationTTL := cfg.ImpersonationTTL
+	if impersonationTTL == 0 {
+		impersonationTTL = DefaultImpersonationTTL
 	}
+
 	return &SessionService{
-		redisClient: redisClient,
-		sessionTTL:  ttl,
+		redisClient:      redisClient,
+		policy:           policy,
+		refreshTTL:       refreshTTL,
+		impersonationTTL: impersonationTTL,
+	}
+}
+
+// ImpersonationPolicy returns the policy of impersonated sessions: one short lifetime,
+// whatever the tenant allows its own members
+func (s *SessionService) ImpersonationPolicy() Policy {
+	return Policy{
+		IdleTimeout: s.impersonationTTL,
+		MaxLifetime: s.impersonationTTL,
 	}
 }
 
+// ResolvePolicy returns the platform session policy with the tenant's overrides applied
+func (s *SessionService) ResolvePolicy(tenant *entity.Tenant) Policy {
+	policy := s.policy
+	if tenant == nil {
+		return policy
+	}
+
+	override := tenant.GetConfig().Session
+	if override == nil {
+		return policy
+	}
+
+	if d, err := time.ParseDuration(override.IdleTimeout); err == nil && d > 0 {
+		policy.IdleTimeout = d
+	}
+	if d, err := time.ParseDuration(override.MaxLifetime); err == nil && d > 0 {
+		policy.MaxLifetime = d
+	}
+
+	return policy
+}
+
 // GenerateReferenceToken creates a cryptographically secure random reference token
 func (s *SessionService) GenerateReferenceToken() (string, error) {
 	bytes := make([]byte, 32) // 256-bit token
@@ -42,8 +117,10 @@ func (s *SessionService) GenerateReferenceToken() (string, error) {
 	return "ref_" + hex.EncodeToString(bytes), nil
 }
 
-// CreateSession stores the session value in Redis with the reference token as key
-func (s *SessionService) CreateSession(ctx context.Context, sessionValue *model.SessionValue) (string, error) {
+// CreateSession stores the session value in Redis with the reference token as key.
+// The session expires after the policy's idle timeout, but never later than AuthTime
+// plus the policy's max lifetime. AuthTime defaults to now for a fresh login.
+func (s *SessionService) CreateSession(ctx context.Context, sessionValue *model.SessionValue, policy Policy) (string, error) {
 	// Generate reference token
 	refToken, err := s.GenerateReferenceToken()
 	if err != nil {
@@ -52,8 +129,18 @@ func (s *SessionService) CreateSession(ctx context.Context, sessionValue *model.
 
 	// Set issued at and expires at
 	now := time.Now().Unix()
+	if sessionValue.AuthTime == 0 {
+		sessionValue.AuthTime = now
+	}
 	sessionValue.IssuedAt = now
-	sessionValue.ExpiresAt = now + int64(s.sessionTTL.Seconds())
+	sessionValue.LastSeenAt = now
+	sessionValue.IdleTimeout = int64(policy.IdleTimeout.Seconds())
+	sessionValue.MaxExpiresAt = sessionValue.AuthTime + int64(policy.MaxLifetime.Seconds())
+	if now >= sessionValue.MaxExpiresAt {
+		return "", errors.ErrSessionLifetimeExceeded
+	}
+	sessionValue.ExpiresAt = min(now+sessionValue.IdleTimeout, sessionValue.MaxExpiresAt)
+	ttl := time.Duration(sessionValue.ExpiresAt-now) * time.Second
 
 	// Marshal session value to JSON
 	valueJSON, err := json.Marshal(sessionValue)
@@ -61,16 +148,59 @@ func (s *SessionService) CreateSession(ctx context.Context, sessionValue *model.
 		return "", fmt.Errorf("failed to marshal session value: %w", err)
 	}
 
-	// Store in Redis with TTL
+	// Store in Redis with TTL and register the token in the user's session index
 	key := SessionKeyPrefix + refToken
-	err = s.redisClient.Set(ctx, key, valueJSON, s.sessionTTL).Err()
-	if err != nil {
+	indexKey := userSessionsKey(sessionValue.UserID)
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, key, valueJSON, ttl)
+	pipe.SAdd(ctx, indexKey, refToken)
+	pipe.ExpireGT(ctx, indexKey, ttl)
+	pipe.ExpireNX(ctx, indexKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return "", fmt.Errorf("failed to store session in Redis: %w", err)
 	}
 
 	return refToken, nil
 }
 
+// SessionID derives a stable public identifier for a session so it can be
+// listed and revoked without exposing the reference token itself
+func SessionID(refToken string) string {
+	sum := sha256.Sum256([]byte(refToken))
+	return hex.EncodeToString(sum[:16])
+}
+
+func userSessionsKey(userID int64) string {
+	return fmt.Sprintf("%s%d", UserSessionsKeyPrefix, userID)
+}
+
+func lastSeenKey(userID int64) string {
+	return fmt.Sprintf("%s%d", LastSeenKeyPrefix, userID)
+}
+
+// MarkSeen records now as the session's last activity. It is a single hash write
+// kept outside the session value, so it can run on every introspection without
+// rewriting the session itself.
+func (s *SessionService) MarkSeen(ctx context.Context, refToken string, sessionValue *model.SessionValue) error {
+	now := time.Now().Unix()
+	ttl := time.Duration(sessionValue.ExpiresAt-now) * time.Second
+	if ttl <= 0 {
+		return nil
+	}
+
+	key := lastSeenKey(sessionValue.UserID)
+	pipe := s.redisClient.Pipeline()
+	pipe.HSet(ctx, key, SessionID(refToken), now)
+	pipe.ExpireGT(ctx, key, ttl)
+	pipe.ExpireNX(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record session activity: %w", err)
+	}
+
+	sessionValue.LastSeenAt = now
+	return nil
+}
+
 // GetSession retrieves the session value from Redis using the reference token
 func (s *SessionService) GetSession(ctx context.Context, refToken string) (*model.SessionValue, error) {
 	key := SessionKeyPrefix + refToken
@@ -98,19 +228,38 @@ func (s *SessionService) GetSession(ctx context.Context, refToken string) (*mode
 	return &sessionValue, nil
 }
 
-// RefreshSession extends the TTL of an existing session
+// RefreshSession extends the idle expiry of an existing session.
+// The expiry never moves past the session's absolute lifetime.
 func (s *SessionService) RefreshSession(ctx context.Context, refToken string) error {
-	key := SessionKeyPrefix + refToken
-	
 	// Get current session
 	sessionValue, err := s.GetSession(ctx, refToken)
 	if err != nil {
 		return err
 	}
 
-	// Update expiration time
+	return s.TouchSession(ctx, refToken, sessionValue)
+}
+
+// TouchSession slides the idle expiry of an already loaded session
+func (s *SessionService) TouchSession(ctx context.Context, refToken string, sessionValue *model.SessionValue) error {
+	key := SessionKeyPrefix + refToken
+
+	// Sessions created before lifetimes were tracked fall back to the platform policy
+	if sessionValue.IdleTimeout == 0 {
+		sessionValue.IdleTimeout = int64(s.policy.IdleTimeout.Seconds())
+	}
+	if sessionValue.MaxExpiresAt == 0 {
+		sessionValue.MaxExpiresAt = sessionValue.IssuedAt + int64(s.policy.MaxLifetime.Seconds())
+	}
+
 	now := time.Now().Unix()
-	sessionValue.ExpiresAt = now + int64(s.sessionTTL.Seconds())
+	if now >= sessionValue.MaxExpiresAt {
+		return errors.ErrSessionLifetimeExceeded
+	}
+
+	// Update expiration time
+	sessionValue.ExpiresAt = min(now+sessionValue.IdleTimeout, sessionValue.MaxExpiresAt)
+	ttl := time.Duration(sessionValue.ExpiresAt-now) * time.Second
 
 	// Marshal updated session value
 	valueJSON, err := json.Marshal(sessionValue)
@@ -118,22 +267,43 @@ func (s *SessionService) RefreshSession(ctx context.Context, refToken string) er
 		return fmt.Errorf("failed to marshal session value: %w", err)
 	}
 
-	// Update in Redis with new TTL
-	err = s.redisClient.Set(ctx, key, valueJSON, s.sessionTTL).Err()
-	if err != nil {
+	// Update in Redis with new TTL and keep the user's index alive as long as the session
+	indexKey := userSessionsKey(sessionValue.UserID)
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, key, valueJSON, ttl)
+	pipe.ExpireGT(ctx, indexKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("failed to refresh session in Redis: %w", err)
 	}
 
 	return nil
 }
 
-// DeleteSession removes a session from Redis
+// DeleteSession removes a session from Redis and from its owner's session index
 func (s *SessionService) DeleteSession(ctx context.Context, refToken string) error {
 	key := SessionKeyPrefix + refToken
-	err := s.redisClient.Del(ctx, key).Err()
-	if err != nil {
+
+	// Read the raw value (ignoring expiry) so we know which index to clean up
+	valueJSON, err := s.redisClient.Get(ctx, key).Result()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to get session from Redis: %w", err)
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Del(ctx, key)
+	if err == nil {
+		var sessionValue model.SessionValue
+		if json.Unmarshal([]byte(valueJSON), &sessionValue) == nil {
+			pipe.SRem(ctx, userSessionsKey(sessionValue.UserID), refToken)
+			pipe.HDel(ctx, lastSeenKey(sessionValue.UserID), SessionID(refToken))
+			s.revokeFamilyOf(ctx, refToken, sessionValue.FamilyID)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("failed to delete session from Redis: %w", err)
 	}
+
+	s.publishInvalidation(ctx, refToken)
 	return nil
 }
 
@@ -146,8 +316,9 @@ func (s *SessionService) ValidateSession(ctx context.Context, refToken string) (
 	return true, nil
 }
 
-// UpdateSessionTenant updates the tenant context in an existing session
-func (s *SessionService) UpdateSessionTenant(ctx context.Context, refToken string, tenantID int64, tenantSlug string, roles []string, permissions []string, scope string) error {
+// UpdateSessionTenant updates the tenant context in an existing session and applies
+// the new tenant's session policy to its expiry
+func (s *SessionService) UpdateSessionTenant(ctx context.Context, refToken string, tenantID int64, tenantSlug string, roles []string, permissions []string, scope string, policy Policy) error {
 	key := SessionKeyPrefix + refToken
 	
 	// Get current session
@@ -163,56 +334,174 @@ func (s *SessionService) UpdateSessionTenant(ctx context.Context, refToken strin
 	sessionValue.Permissions = permissions
 	sessionValue.Scope = scope
 
+	// Re-evaluate lifetime limits under the new tenant's policy
+	now := time.Now().Unix()
+	if sessionValue.AuthTime == 0 {
+		sessionValue.AuthTime = sessionValue.IssuedAt
+	}
+	sessionValue.IdleTimeout = int64(policy.IdleTimeout.Seconds())
+	sessionValue.MaxExpiresAt = sessionValue.AuthTime + int64(policy.MaxLifetime.Seconds())
+	if now >= sessionValue.MaxExpiresAt {
+		_ = s.DeleteSession(ctx, refToken)
+		return errors.ErrSessionLifetimeExceeded
+	}
+	sessionValue.ExpiresAt = min(sessionValue.ExpiresAt, now+sessionValue.IdleTimeout, sessionValue.MaxExpiresAt)
+	ttl := time.Duration(sessionValue.ExpiresAt-now) * time.Second
+
 	// Marshal updated session value
 	valueJSON, err := json.Marshal(sessionValue)
 	if err != nil {
 		return fmt.Errorf("failed to marshal session value: %w", err)
 	}
 
-	// Get remaining TTL
-	ttl, err := s.redisClient.TTL(ctx, key).Result()
-	if err != nil {
-		ttl = s.sessionTTL
-	}
-
 	// Update in Redis with remaining TTL
 	err = s.redisClient.Set(ctx, key, valueJSON, ttl).Err()
 	if err != nil {
 		return fmt.Errorf("failed to update session in Redis: %w", err)
 	}
 
+	s.setFamilyTenant(ctx, sessionValue.FamilyID, tenantID)
+	s.publishInvalidation(ctx, refToken)
+
 	return nil
 }
 
-// GetAllUserSessions retrieves all active sessions for a user
-func (s *SessionService) GetAllUserSessions(ctx context.Context, userID int64) ([]string, error) {
-	var sessions []string
-	
-	// Scan all session keys
-	iter := s.redisClient.Scan(ctx, 0, SessionKeyPrefix+"*", 0).Iterator()
-	for iter.Next(ctx) {
-		key := iter.Val()
-		refToken := key[len(SessionKeyPrefix):]
-		
-		// Get session value
-		sessionValue, err := s.GetSession(ctx, refToken)
-		if err != nil {
+// GetUserSessionDetails retrieves all active sessions for a user from the per-user index,
+// including their last activity. Tokens whose session has already expired are pruned
+// from the index on the way.
+func (s *SessionService) GetUserSessionDetails(ctx context.Context, userID int64) ([]UserSession, error) {
+	indexKey := userSessionsKey(userID)
+
+	refTokens, err := s.redisClient.SMembers(ctx, indexKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user session index: %w", err)
+	}
+	if len(refTokens) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(refTokens))
+	for i, refToken := range refTokens {
+		keys[i] = SessionKeyPrefix + refToken
+	}
+
+	values, err := s.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions from Redis: %w", err)
+	}
+
+	// Missing activity data is not fatal; sessions then show their issue time
+	lastSeen, _ := s.redisClient.HGetAll(ctx, lastSeenKey(userID)).Result()
+
+	now := time.Now().Unix()
+	sessions := make([]UserSession, 0, len(refTokens))
+	var stale []any
+	var staleSeen []string
+	for i, raw := range values {
+		valueJSON, ok := raw.(string)
+		if !ok {
+			stale = append(stale, refTokens[i])
+			staleSeen = append(staleSeen, SessionID(refTokens[i]))
 			continue
 		}
-		
-		// Check if session belongs to the user
-		if sessionValue.UserID == userID {
-			sessions = append(sessions, refToken)
+
+		var sessionValue model.SessionValue
+		if err := json.Unmarshal([]byte(valueJSON), &sessionValue); err != nil || now > sessionValue.ExpiresAt {
+			stale = append(stale, refTokens[i])
+			staleSeen = append(staleSeen, SessionID(refTokens[i]))
+			continue
 		}
+
+		if seen, err := strconv.ParseInt(lastSeen[SessionID(refTokens[i])], 10, 64); err == nil && seen > sessionValue.LastSeenAt {
+			sessionValue.LastSeenAt = seen
+		}
+
+		sessions = append(sessions, UserSession{RefToken: refTokens[i], Value: &sessionValue})
 	}
-	
-	if err := iter.Err(); err != nil {
-		return nil, fmt.Errorf("failed to scan sessions: %w", err)
+
+	if len(stale) > 0 {
+		pipe := s.redisClient.Pipeline()
+		pipe.SRem(ctx, indexKey, stale...)
+		pipe.HDel(ctx, lastSeenKey(userID), staleSeen...)
+		_, _ = pipe.Exec(ctx)
 	}
-	
+
 	return sessions, nil
 }
 
+// GetAllUserSessions retrieves the reference tokens of all active sessions for a user
+func (s *SessionService) GetAllUserSessions(ctx context.Context, userID int64) ([]string, error) {
+	details, err := s.GetUserSessionDetails(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]string, 0, len(details))
+	for _, d := range details {
+		sessions = append(sessions, d.RefToken)
+	}
+
+	return sessions, nil
+}
+
+// UpdateUserTenantSessions rewrites the roles and permissions of all of a user's live
+// sessions in a tenant, so Kong picks up role changes on the next request.
+// Remaining lifetimes are left untouched. Returns the number of sessions updated.
+func (s *SessionService) UpdateUserTenantSessions(ctx context.Context, userID int64, tenantID int64, roles []string, permissions []string, scope string) (int, error) {
+	sessions, err := s.GetUserSessionDetails(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, us := range sessions {
+		// Limited sessions never carry roles
+		if us.Value.TenantID != tenantID || us.Value.Restriction != "" {
+			continue
+		}
+
+		us.Value.Roles = roles
+		us.Value.Permissions = permissions
+		us.Value.Scope = scope
+
+		valueJSON, err := json.Marshal(us.Value)
+		if err != nil {
+			return updated, fmt.Errorf("failed to marshal session value: %w", err)
+		}
+
+		// SetXX with KeepTTL never resurrects a session that expired in the meantime
+		if err := s.redisClient.SetXX(ctx, SessionKeyPrefix+us.RefToken, valueJSON, redis.KeepTTL).Err(); err != nil {
+			return updated, fmt.Errorf("failed to update session in Redis: %w", err)
+		}
+		s.publishInvalidation(ctx, us.RefToken)
+		updated++
+	}
+
+	return updated, nil
+}
+
+// DeleteUserTenantSessions removes all of a user's sessions in a tenant.
+// Returns the number of sessions removed.
+func (s *SessionService) DeleteUserTenantSessions(ctx context.Context, userID int64, tenantID int64) (int, error) {
+	sessions, err := s.GetUserSessionDetails(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, us := range sessions {
+		if us.Value.TenantID != tenantID {
+			continue
+		}
+		if err := s.DeleteSession(ctx, us.RefToken); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
 // DeleteAllUserSessions removes all sessions for a user
 func (s *SessionService) DeleteAllUserSessions(ctx context.Context, userID int64) error {
 	sessions, err := s.GetAllUserSessions(ctx, userID)
@@ -229,3 +518,46 @@ func (s *SessionService) DeleteAllUserSessions(ctx context.Context, userID int64
 	
 	return nil
 }
+
+// publishInvalidation tells every portal instance that a session changed or was deleted,
+// so in-process caches drop it. Delivery is best effort; caches also expire on their own.
+func (s *SessionService) publishInvalidation(ctx context.Context, refToken string) {
+	_ = s.redisClient.Publish(ctx, InvalidationChannel, refToken).Err()
+}
+
+// InvalidateAPIToken drops a revoked API token from every instance's in-process cache.
+// Caches key API tokens by their stored hash, the only form of the token kept.
+func (s *SessionService) InvalidateAPIToken(ctx context.Context, tokenHash string) {
+	s.publishInvalidation(ctx, tokenHash)
+}
+
+// SubscribeInvalidations streams the reference tokens of changed or deleted sessions
+// until ctx is done
+func (s *SessionService) SubscribeInvalidations(ctx context.Context) <-chan string {
+	tokens := make(chan string)
+	pubsub := s.redisClient.Subscribe(ctx, InvalidationChannel)
+
+	go func() {
+		defer close(tokens)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case tokens <- msg.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return tokens
+}
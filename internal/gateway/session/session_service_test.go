@@ -0,0 +1,269 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-gin-clean/internal/entity"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestSessionService(t *testing.T, cfg *config.SessionConfig) (*SessionService, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return NewSessionService(client, cfg), mr
+}
+
+func TestResolvePolicy(t *testing.T) {
+	s, _ := newTestSessionService(t, &config.SessionConfig{
+		IdleTimeout: 15 * time.Minute,
+		MaxLifetime: 8 * time.Hour,
+	})
+	platform := Policy{IdleTimeout: 15 * time.Minute, MaxLifetime: 8 * time.Hour}
+
+	tests := []struct {
+		name   string
+		tenant *entity.Tenant
+		want   Policy
+	}{
+		{"no tenant", nil, platform},
+		{"no override", &entity.Tenant{Config: `{}`}, platform},
+		{
+			"both overridden",
+			&entity.Tenant{Config: `{"session":{"idle_timeout":"5m","max_lifetime":"1h"}}`},
+			Policy{IdleTimeout: 5 * time.Minute, MaxLifetime: time.Hour},
+		},
+		{
+			"idle timeout only",
+			&entity.Tenant{Config: `{"session":{"idle_timeout":"1m"}}`},
+			Policy{IdleTimeout: time.Minute, MaxLifetime: 8 * time.Hour},
+		},
+		{
+			"invalid and negative values are ignored",
+			&entity.Tenant{Config: `{"session":{"idle_timeout":"soon","max_lifetime":"-1h"}}`},
+			platform,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.ResolvePolicy(tt.tenant); got != tt.want {
+				t.Errorf("ResolvePolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateSessionExpiry(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestSessionService(t, &config.SessionConfig{})
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name     string
+		authTime int64
+		policy   Policy
+		wantTTL  time.Duration
+		wantErr  error
+	}{
+		{
+			name:    "idle timeout",
+			policy:  Policy{IdleTimeout: 30 * time.Minute, MaxLifetime: 24 * time.Hour},
+			wantTTL: 30 * time.Minute,
+		},
+		{
+			name:     "capped by the absolute lifetime",
+			authTime: now - int64((23*time.Hour + 50*time.Minute).Seconds()),
+			policy:   Policy{IdleTimeout: 30 * time.Minute, MaxLifetime: 24 * time.Hour},
+			wantTTL:  10 * time.Minute,
+		},
+		{
+			name:     "lifetime already over",
+			authTime: now - int64((25 * time.Hour).Seconds()),
+			policy:   Policy{IdleTimeout: 30 * time.Minute, MaxLifetime: 24 * time.Hour},
+			wantErr:  errors.ErrSessionLifetimeExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refToken, err := s.CreateSession(ctx, &model.SessionValue{UserID: 1, AuthTime: tt.authTime}, tt.policy)
+			if err != tt.wantErr {
+				t.Fatalf("CreateSession() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			// Allow a second of drift between computing the expiry and storing it
+			ttl := mr.TTL(SessionKeyPrefix + refToken)
+			if ttl < tt.wantTTL-time.Second || ttl > tt.wantTTL {
+				t.Errorf("session TTL = %v, want %v", ttl, tt.wantTTL)
+			}
+			if !mr.Exists(userSessionsKey(1)) {
+				t.Error("session missing from the user's session index")
+			}
+		})
+	}
+}
+
+func TestTouchSessionStopsAtMaxLifetime(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestSessionService(t, &config.SessionConfig{})
+
+	authTime := time.Now().Add(-55 * time.Minute).Unix()
+	refToken, err := s.CreateSession(ctx, &model.SessionValue{UserID: 1, AuthTime: authTime}, Policy{
+		IdleTimeout: 30 * time.Minute,
+		MaxLifetime: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	if err := s.RefreshSession(ctx, refToken); err != nil {
+		t.Fatalf("RefreshSession() error = %v", err)
+	}
+	if ttl := mr.TTL(SessionKeyPrefix + refToken); ttl > 5*time.Minute {
+		t.Errorf("session TTL = %v, want at most the 5m left of its lifetime", ttl)
+	}
+
+	// Once the lifetime is over the session cannot be extended any more
+	value, err := s.GetSession(ctx, refToken)
+	if err != nil {
+		t.Fatalf("GetSession() error = %v", err)
+	}
+	value.MaxExpiresAt = time.Now().Unix()
+	if err := s.TouchSession(ctx, refToken, value); err != errors.ErrSessionLifetimeExceeded {
+		t.Errorf("TouchSession() error = %v, want %v", err, errors.ErrSessionLifetimeExceeded)
+	}
+}
+
+func TestGetSessionExpired(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestSessionService(t, &config.SessionConfig{})
+
+	refToken, err := s.CreateSession(ctx, &model.SessionValue{UserID: 1}, s.ResolvePolicy(nil))
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	mr.FastForward(DefaultTTL + time.Second)
+	if _, err := s.GetSession(ctx, refToken); err == nil {
+		t.Error("GetSession() returned an idle session past its timeout")
+	}
+}
+
+func TestDeleteSession(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestSessionService(t, &config.SessionConfig{})
+
+	refToken, err := s.CreateSession(ctx, &model.SessionValue{UserID: 7}, s.ResolvePolicy(nil))
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := s.MarkSeen(ctx, refToken, &model.SessionValue{UserID: 7, ExpiresAt: time.Now().Add(time.Minute).Unix()}); err != nil {
+		t.Fatalf("MarkSeen() error = %v", err)
+	}
+
+	invalidations := s.SubscribeInvalidations(ctx)
+	// Subscribing is asynchronous; wait until Redis knows about it
+	for mr.PubSubNumSub(InvalidationChannel)[InvalidationChannel] == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := s.DeleteSession(ctx, refToken); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+
+	if _, err := s.GetSession(ctx, refToken); err == nil {
+		t.Error("session still readable after DeleteSession()")
+	}
+	if ok, _ := mr.SIsMember(userSessionsKey(7), refToken); ok {
+		t.Error("session still in the user's session index")
+	}
+	if mr.HGet(lastSeenKey(7), SessionID(refToken)) != "" {
+		t.Error("session activity still recorded")
+	}
+
+	select {
+	case got := <-invalidations:
+		if got != refToken {
+			t.Errorf("invalidation for %q, want %q", got, refToken)
+		}
+	case <-time.After(time.Second):
+		t.Error("no invalidation published")
+	}
+}
+
+func TestUserTenantSessions(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestSessionService(t, &config.SessionConfig{})
+	policy := s.ResolvePolicy(nil)
+
+	create := func(value *model.SessionValue) string {
+		t.Helper()
+		refToken, err := s.CreateSession(ctx, value, policy)
+		if err != nil {
+			t.Fatalf("CreateSession() error = %v", err)
+		}
+		return refToken
+	}
+	inTenant := create(&model.SessionValue{UserID: 1, TenantID: 10, Roles: []string{"member"}})
+	limited := create(&model.SessionValue{UserID: 1, TenantID: 10, Restriction: "mfa_enrollment"})
+	otherTenant := create(&model.SessionValue{UserID: 1, TenantID: 20, Roles: []string{"member"}})
+	otherUser := create(&model.SessionValue{UserID: 2, TenantID: 10, Roles: []string{"member"}})
+
+	updated, err := s.UpdateUserTenantSessions(ctx, 1, 10, []string{"admin"}, []string{"users.read"}, "users.read")
+	if err != nil {
+		t.Fatalf("UpdateUserTenantSessions() error = %v", err)
+	}
+	if updated != 1 {
+		t.Errorf("UpdateUserTenantSessions() updated %d sessions, want 1", updated)
+	}
+
+	wantRoles := map[string]string{
+		inTenant:    "admin",
+		limited:     "",
+		otherTenant: "member",
+		otherUser:   "member",
+	}
+	for refToken, want := range wantRoles {
+		value, err := s.GetSession(ctx, refToken)
+		if err != nil {
+			t.Fatalf("GetSession() error = %v", err)
+		}
+		got := ""
+		if len(value.Roles) > 0 {
+			got = value.Roles[0]
+		}
+		if got != want {
+			t.Errorf("session of user %d in tenant %d has role %q, want %q", value.UserID, value.TenantID, got, want)
+		}
+	}
+
+	deleted, err := s.DeleteUserTenantSessions(ctx, 1, 10)
+	if err != nil {
+		t.Fatalf("DeleteUserTenantSessions() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("DeleteUserTenantSessions() deleted %d sessions, want 2", deleted)
+	}
+
+	remaining, err := s.GetAllUserSessions(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetAllUserSessions() error = %v", err)
+	}
+	if len(remaining) != 1 || remaining[0] != otherTenant {
+		t.Errorf("GetAllUserSessions() = %v, want only the session in the other tenant", remaining)
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"go-gin-clean/internal/repository"
 	"go-gin-clean/internal/usecase"
 	"go-gin-clean/pkg/config"
-	"time"
 
 	"github.com/rabbitmq/amqp091-go"
 	"gorm.io/gorm"
@@ -24,9 +23,19 @@ type Container struct {
 	AuthHandler             http.AuthHandler
 	UserManagementHandler   http.UserManagementHandler
 	IntrospectionHandler    http.IntrospectionHandler
+	WellKnownHandler        http.WellKnownHandler
+	MFAHandler              http.MFAHandler
+	PasskeyHandler          http.PasskeyHandler
+	APITokenHandler         http.APITokenHandler
+	OAuthClientHandler      http.OAuthClientHandler
+	OIDCHandler             http.OIDCHandler
 	JWTService              security.JWTService
 	OAuthService            security.OAuthService
 	SessionService          *session.SessionService
+	RateLimiter             *cache.RateLimiter
+	BreachedPasswords       *security.BreachedPasswordService
+	SigningKeyUseCase       *usecase.SigningKeyUseCase
+	IntrospectionUseCase    *usecase.IntrospectionUseCase
 }
 
 func NewContainer(db *gorm.DB, ch *amqp091.Channel, cfg *config.Config) *Container {
@@ -36,13 +45,25 @@ func NewContainer(db *gorm.DB, ch *amqp091.Channel, cfg *config.Config) *Contain
 	tenantRepo := repository.NewTenantRepository(db)
 	tenantRoleRepo := repository.NewTenantRoleRepository(db)
 	membershipRepo := repository.NewMembershipRepository(db)
+	passwordHistoryRepo := repository.NewPasswordHistoryRepository(db)
+	impersonationLogRepo := repository.NewImpersonationLogRepository(db)
+	apiTokenRepo := repository.NewAPITokenRepository(db)
+	oauthClientRepo := repository.NewOAuthClientRepository(db)
+	oauthConsentRepo := repository.NewOAuthConsentRepository(db)
 	permissionRepo := repository.NewPermissionRepository(db)
+	signingKeyRepo := repository.NewSigningKeyRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	webAuthnCredentialRepo := repository.NewWebAuthnCredentialRepository(db)
 
 	// Init services
-	jwtService := security.NewJWTService(&cfg.JWT)
-	passwordService := security.NewBcryptService()
+	keyRing := security.NewKeyRing()
+	jwtService := security.NewJWTService(&cfg.JWT, keyRing)
+	passwordService := security.NewPasswordService(&cfg.PasswordHash)
+	breachedPasswordService := security.NewBreachedPasswordService(&cfg.Password)
 	oauthService := security.NewOAuthService(&cfg.OAuth)
 	aesService := security.NewAESService(&cfg.AES)
+	totpService := security.NewTOTPService(&cfg.MFA)
+	webAuthnService := security.NewWebAuthnService(&cfg.WebAuthn)
 	cloudinaryService := media.NewCloudinaryService(&cfg.Cloudinary)
 	localStorageService := media.NewLocalStorageService("")
 	redisService := cache.NewRedisService(&cfg.Redis)
@@ -51,18 +72,27 @@ func NewContainer(db *gorm.DB, ch *amqp091.Channel, cfg *config.Config) *Contain
 	kongClient := kong.NewKongAdminClient(cfg.Kong.AdminURL, cfg.Kong.Timeout)
 	
 	// Init session service
-	sessionTTL := 30 * time.Minute // 30 minutes session expiration
-	sessionService := session.NewSessionService(redisService.GetClient(), sessionTTL)
+	sessionService := session.NewSessionService(redisService.GetClient(), &cfg.Session)
+	loginGuard := cache.NewLoginGuard(redisService.GetClient(), &cfg.Lockout)
+	rateLimiter := cache.NewRateLimiter(redisService.GetClient())
 
 	// init message publisher
 	userPublisher := messaging.NewUserPublisher(ch)
 
 	// Init use cases
-	userUseCase := usecase.NewUserUseCase(userRepo, refreshTokenRepo, jwtService, passwordService, oauthService, aesService, cloudinaryService, localStorageService, redisService, userPublisher)
-	registrationUseCase := usecase.NewRegistrationUseCase(db, userRepo, tenantRepo, tenantRoleRepo, membershipRepo, passwordService, kongClient)
-	authUseCase := usecase.NewAuthUseCase(userRepo, membershipRepo, tenantRepo, tenantRoleRepo, permissionRepo, passwordService, sessionService, sessionTTL)
-	userManagementUseCase := usecase.NewUserManagementUseCase(db, userRepo, tenantRepo, tenantRoleRepo, membershipRepo, permissionRepo, passwordService)
-	introspectionUseCase := usecase.NewIntrospectionUseCase(sessionService)
+	lockoutUseCase := usecase.NewLockoutUseCase(userRepo, loginGuard, userPublisher, &cfg.Lockout)
+	passwordPolicyUseCase := usecase.NewPasswordPolicyUseCase(membershipRepo, tenantRepo, passwordHistoryRepo, passwordService, breachedPasswordService, &cfg.Password)
+	passkeyUseCase := usecase.NewPasskeyUseCase(userRepo, webAuthnCredentialRepo, webAuthnService, sessionService, passwordService, lockoutUseCase, &cfg.WebAuthn)
+	mfaUseCase := usecase.NewMFAUseCase(userRepo, mfaRepo, totpService, passkeyUseCase, aesService, passwordService, sessionService, lockoutUseCase, &cfg.MFA)
+	userUseCase := usecase.NewUserUseCase(userRepo, refreshTokenRepo, mfaRepo, jwtService, passwordService, oauthService, aesService, cloudinaryService, localStorageService, redisService, sessionService, lockoutUseCase, passwordPolicyUseCase, &cfg.OAuth, userPublisher)
+	registrationUseCase := usecase.NewRegistrationUseCase(db, userRepo, tenantRepo, tenantRoleRepo, membershipRepo, passwordService, kongClient, passwordPolicyUseCase)
+	authUseCase := usecase.NewAuthUseCase(userRepo, membershipRepo, tenantRepo, tenantRoleRepo, permissionRepo, passwordService, sessionService, mfaUseCase, passkeyUseCase, lockoutUseCase, passwordPolicyUseCase, impersonationLogRepo, &cfg.OAuth)
+	userManagementUseCase := usecase.NewUserManagementUseCase(db, userRepo, tenantRepo, tenantRoleRepo, membershipRepo, permissionRepo, passwordService, sessionService, mfaRepo, lockoutUseCase, passwordPolicyUseCase)
+	apiTokenUseCase := usecase.NewAPITokenUseCase(apiTokenRepo, userRepo, membershipRepo, tenantRepo, permissionRepo, sessionService, &cfg.APIToken)
+	oauthClientUseCase := usecase.NewOAuthClientUseCase(oauthClientRepo, tenantRepo, membershipRepo, tenantRoleRepo, sessionService, &cfg.OAuthServer)
+	oidcUseCase := usecase.NewOIDCUseCase(oauthClientRepo, oauthConsentRepo, userRepo, membershipRepo, tenantRepo, sessionService, jwtService, authUseCase, oauthClientUseCase, &cfg.OAuthServer)
+	introspectionUseCase := usecase.NewIntrospectionUseCase(sessionService, apiTokenUseCase, jwtService, &cfg.Introspection)
+	signingKeyUseCase := usecase.NewSigningKeyUseCase(signingKeyRepo, keyRing, aesService, &cfg.JWT)
 
 	// Init handlers
 	userHandler := http.NewUserHandler(userUseCase)
@@ -71,6 +101,12 @@ func NewContainer(db *gorm.DB, ch *amqp091.Channel, cfg *config.Config) *Contain
 	authHandler := http.NewAuthHandler(authUseCase)
 	userManagementHandler := http.NewUserManagementHandler(userManagementUseCase)
 	introspectionHandler := http.NewIntrospectionHandler(introspectionUseCase)
+	wellKnownHandler := http.NewWellKnownHandler(signingKeyUseCase, oidcUseCase)
+	mfaHandler := http.NewMFAHandler(mfaUseCase)
+	passkeyHandler := http.NewPasskeyHandler(passkeyUseCase)
+	apiTokenHandler := http.NewAPITokenHandler(apiTokenUseCase)
+	oauthClientHandler := http.NewOAuthClientHandler(oauthClientUseCase)
+	oidcHandler := http.NewOIDCHandler(oidcUseCase)
 
 	return &Container{
 		UserHandler:           *userHandler,
@@ -79,8 +115,18 @@ func NewContainer(db *gorm.DB, ch *amqp091.Channel, cfg *config.Config) *Contain
 		AuthHandler:           *authHandler,
 		UserManagementHandler: *userManagementHandler,
 		IntrospectionHandler:  *introspectionHandler,
+		WellKnownHandler:      *wellKnownHandler,
+		MFAHandler:            *mfaHandler,
+		PasskeyHandler:        *passkeyHandler,
+		APITokenHandler:       *apiTokenHandler,
+		OAuthClientHandler:    *oauthClientHandler,
+		OIDCHandler:           *oidcHandler,
 		JWTService:            *jwtService,
 		OAuthService:          *oauthService,
 		SessionService:        sessionService,
+		RateLimiter:           rateLimiter,
+		BreachedPasswords:     breachedPasswordService,
+		SigningKeyUseCase:     signingKeyUseCase,
+		IntrospectionUseCase:  introspectionUseCase,
 	}
 }
@@ -0,0 +1,30 @@
+package model
+
+// APITokenPrefix starts every personal access token, followed by 64 hex characters
+const APITokenPrefix = "pat_"
+
+// CreateAPITokenRequest creates a personal access token for one tenant
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	TenantID      int64    `json:"tenant_id" binding:"required"`
+	Permissions   []string `json:"permissions" binding:"required,min=1"` // Subset of the user's permissions in the tenant
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1"`
+}
+
+// APITokenResponse describes an API token; the secret itself is never shown again
+type APITokenResponse struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	TenantID    int64    `json:"tenant_id"`
+	TokenHint   string   `json:"token_hint"` // e.g. "pat_1a2b3c4d"
+	Permissions []string `json:"permissions"`
+	ExpiresAt   int64    `json:"expires_at"`
+	CreatedAt   int64    `json:"created_at"`
+	LastUsedAt  *int64   `json:"last_used_at,omitempty"`
+}
+
+// CreateAPITokenResponse carries the new token's secret, shown only once
+type CreateAPITokenResponse struct {
+	APITokenResponse
+	Token string `json:"token"`
+}
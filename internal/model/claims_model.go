@@ -23,3 +23,42 @@ type RefreshTokenClaims struct {
 	Issuer    string    `json:"issuer"`
 	Subject   string    `json:"subject"`
 }
+
+// SessionTokenClaims is the session context carried by JWTs minted for upstream services
+type SessionTokenClaims struct {
+	SessionID   string    `json:"sid"`
+	UserID      int64     `json:"uid"`
+	UserUUID    string    `json:"uuid"`
+	Email       string    `json:"email"`
+	TenantID    int64     `json:"tid"`
+	TenantSlug  string    `json:"tenant_slug"`
+	Roles       []string  `json:"roles"`
+	Permissions []string  `json:"permissions"`
+	Scope       string    `json:"scope"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	IssuedAt    time.Time `json:"issued_at"`
+	Issuer      string    `json:"issuer"`
+	Audience    string    `json:"audience"`
+	Subject     string    `json:"subject"`
+	Actor       string    `json:"actor,omitempty"`       // Subject of the admin impersonating the user
+	ClientID    string    `json:"client_id,omitempty"`   // OAuth client the token was issued to (client credentials)
+	Restriction string    `json:"restriction,omitempty"` // Limited session, e.g. mfa_enrollment
+}
+
+// IDTokenClaims is the identity carried by OpenID Connect ID tokens issued to clients
+type IDTokenClaims struct {
+	Issuer        string    `json:"iss"`
+	Subject       string    `json:"sub"`
+	Audience      string    `json:"aud"` // Client ID
+	ExpiresAt     time.Time `json:"exp"`
+	AuthTime      int64     `json:"auth_time"`
+	Nonce         string    `json:"nonce,omitempty"`
+	AMR           []string  `json:"amr,omitempty"`
+	Name          string    `json:"name,omitempty"`           // profile scope
+	Picture       string    `json:"picture,omitempty"`        // profile scope
+	Email         string    `json:"email,omitempty"`          // email scope
+	EmailVerified *bool     `json:"email_verified,omitempty"` // email scope
+	TenantID      int64     `json:"tid"`
+	TenantSlug    string    `json:"tenant_slug"`
+	Roles         []string  `json:"roles"`
+}
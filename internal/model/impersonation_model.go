@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// ImpersonateRequest asks for a session acting as another user in one of their tenants
+type ImpersonateRequest struct {
+	UserID    int64  `json:"user_id" binding:"required"`
+	TenantID  int64  `json:"tenant_id" binding:"required"`
+	Reason    string `json:"reason" binding:"required,max=500"` // Recorded in the audit trail, e.g. a support ticket
+	UserAgent string `json:"-"`                                 // Set by the handler from the request
+	ClientIP  string `json:"-"`                                 // Set by the handler from the request
+}
+
+// ImpersonationLogResponse is one audit entry, as shown to the impersonated user
+type ImpersonationLogResponse struct {
+	ID           int64        `json:"id"`
+	Impersonator Impersonator `json:"impersonator"`
+	TenantID     int64        `json:"tenant_id"`
+	Reason       string       `json:"reason"`
+	SessionID    string       `json:"session_id"` // Revocable with DELETE /auth/sessions/:id while active
+	IPAddress    string       `json:"ip_address,omitempty"`
+	StartedAt    time.Time    `json:"started_at"`
+	ExpiresAt    time.Time    `json:"expires_at"`
+}
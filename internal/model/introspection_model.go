@@ -7,20 +7,70 @@ type IntrospectionRequest struct {
 
 // IntrospectionResponse is returned to Kong with session context
 type IntrospectionResponse struct {
-	Active      bool     `json:"active"`
-	Sub         string   `json:"sub,omitempty"`          // User ID
-	TenantID    int64    `json:"tenant_id,omitempty"`    // Current tenant ID
-	UserID      int64    `json:"user_id,omitempty"`      // User ID
-	RoleID      int64    `json:"role_id,omitempty"`      // Current role ID
-	RoleName    string   `json:"role_name,omitempty"`    // Role name
-	Permissions []string `json:"permissions,omitempty"`  // Permissions array
-	Exp         int64    `json:"exp,omitempty"`          // Expiration timestamp
+	Active         bool     `json:"active"`
+	Sub            string   `json:"sub,omitempty"`             // User ID
+	TenantID       int64    `json:"tenant_id,omitempty"`       // Current tenant ID
+	UserID         int64    `json:"user_id,omitempty"`         // User ID
+	RoleID         int64    `json:"role_id,omitempty"`         // Current role ID
+	RoleName       string   `json:"role_name,omitempty"`       // Role name
+	Permissions    []string `json:"permissions,omitempty"`     // Permissions array
+	Exp            int64    `json:"exp,omitempty"`             // Expiration timestamp
+	Token          string   `json:"token,omitempty"`           // Signed session JWT, when enabled
+	Restriction    string   `json:"restriction,omitempty"`     // Limited session, e.g. mfa_enrollment
+	ImpersonatedBy int64    `json:"impersonated_by,omitempty"` // Platform admin acting as the user
+	APITokenID     int64    `json:"api_token_id,omitempty"`    // Personal access token used instead of a session
+	ClientID       string   `json:"client_id,omitempty"`       // OAuth client acting on its own behalf (client credentials)
 }
 
 // IntrospectionHeaders are the headers Kong should inject into upstream requests
 type IntrospectionHeaders struct {
-	XTenantID    string `header:"X-Tenant-ID"`
-	XUserID      string `header:"X-User-ID"`
-	XRoleID      string `header:"X-Role-ID"`
-	XPermissions string `header:"X-Permissions"` // Comma-separated
+	XTenantID       string `header:"X-Tenant-ID"`
+	XUserID         string `header:"X-User-ID"`
+	XRoleID         string `header:"X-Role-ID"`
+	XPermissions    string `header:"X-Permissions"`     // Comma-separated
+	XImpersonatedBy string `header:"X-Impersonated-By"` // User ID of the impersonating admin
+	XAPITokenID     string `header:"X-API-Token-ID"`
+	XClientID       string `header:"X-Client-ID"`
 }
+
+// TokenIntrospectionRequest is an RFC 7662 introspection request (form encoded)
+type TokenIntrospectionRequest struct {
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"` // access_token or refresh_token
+}
+
+// TokenIntrospectionResponse is an RFC 7662 introspection response.
+// Inactive tokens are answered with "active": false only.
+type TokenIntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+
+	// Tenant extensions
+	TenantID    int64    `json:"tenant_id,omitempty"`
+	TenantSlug  string   `json:"tenant_slug,omitempty"`
+	UserID      int64    `json:"user_id,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	Restriction string   `json:"restriction,omitempty"` // Limited session, e.g. mfa_enrollment
+
+	// Set on impersonated sessions (RFC 8693 actor claim)
+	Act *ActorClaim `json:"act,omitempty"`
+}
+
+// ActorClaim names the party acting on behalf of the token's subject
+type ActorClaim struct {
+	Sub string `json:"sub"`
+}
+
+// Token type hints accepted by the RFC 7662 endpoint
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
@@ -0,0 +1,19 @@
+package model
+
+// JWKS is a JSON Web Key Set (RFC 7517) of the public signing keys
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a public JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	KID string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"` // EC and OKP keys
+	X   string `json:"x,omitempty"`   // EC and OKP keys
+	Y   string `json:"y,omitempty"`   // EC keys
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+}
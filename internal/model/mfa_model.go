@@ -0,0 +1,110 @@
+package model
+
+// Second factors a login challenge can be answered with
+const (
+	MFAMethodTOTP         = "totp"
+	MFAMethodRecoveryCode = "recovery_code"
+	MFAMethodPasskey      = "passkey"
+)
+
+// MFAChallengeValue is stored in Redis between the password step and the second factor
+type MFAChallengeValue struct {
+	UserID      int64  `json:"uid"`
+	TenantID    int64  `json:"tid"` // Tenant the session will be issued for
+	LoginMethod string `json:"login_method"`
+	IssuedAt    int64  `json:"iat"`
+	ExpiresAt   int64  `json:"exp"`
+}
+
+// MFAChallengeResponse is returned by the password step when a second factor is required
+type MFAChallengeResponse struct {
+	MFARequired    bool     `json:"mfa_required"`
+	ChallengeToken string   `json:"challenge_token"`
+	ExpiresIn      int      `json:"expires_in"`
+	Methods        []string `json:"methods"`
+}
+
+// VerifyMFARequest completes a login with a TOTP or recovery code
+type VerifyMFARequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+	UserAgent      string `json:"-"` // Set by the handler from the request
+	ClientIP       string `json:"-"` // Set by the handler from the request
+}
+
+// MFAStatusResponse describes the second factors of the current user
+type MFAStatusResponse struct {
+	TOTPEnabled            bool   `json:"totp_enabled"`
+	TOTPConfirmedAt        *int64 `json:"totp_confirmed_at,omitempty"`
+	RecoveryCodesRemaining int64  `json:"recovery_codes_remaining"`
+	Passkeys               int64  `json:"passkeys"` // Registered passkeys, each also works as a second factor
+}
+
+// TOTPEnrollmentResponse carries a new TOTP secret. OTPAuthURI is the QR code payload.
+type TOTPEnrollmentResponse struct {
+	Secret      string `json:"secret"`
+	OTPAuthURI  string `json:"otpauth_uri"`
+	Issuer      string `json:"issuer"`
+	AccountName string `json:"account_name"`
+	Digits      int    `json:"digits"`
+	Period      int    `json:"period"`
+}
+
+// MFACodeRequest carries a code proving possession of the authenticator
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTOTPRequest requires both the password and a current code
+type DisableTOTPRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// RegenerateRecoveryCodesRequest requires both the password and a current code
+type RegenerateRecoveryCodesRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse returns freshly generated recovery codes; they are shown only once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAPolicyStatus tells the client how the tenant's MFA policy applies to the user
+type MFAPolicyStatus struct {
+	Enforcement        string `json:"enforcement"`
+	Required           bool   `json:"required"` // The user's role must use MFA in this tenant
+	Enrolled           bool   `json:"enrolled"`
+	Deadline           int64  `json:"deadline,omitempty"`  // End of the grace period (unix seconds)
+	EnrollmentRequired bool   `json:"enrollment_required"` // The session is limited to enrolling MFA
+}
+
+// UpdateMFAPolicyRequest sets a tenant's MFA enforcement
+type UpdateMFAPolicyRequest struct {
+	Enforcement string `json:"enforcement" binding:"required,oneof=off optional admins all"`
+	GracePeriod string `json:"grace_period"` // Go duration, e.g. "168h"; empty means no grace period
+}
+
+// MFAComplianceResponse lists how a tenant's members comply with its MFA policy
+type MFAComplianceResponse struct {
+	TenantID    int64                 `json:"tenant_id"`
+	Enforcement string                `json:"enforcement"`
+	GracePeriod string                `json:"grace_period,omitempty"`
+	EnforcedAt  *int64                `json:"enforced_at,omitempty"`
+	Members     []MFAComplianceMember `json:"members"`
+}
+
+// MFAComplianceMember is one member's MFA state
+type MFAComplianceMember struct {
+	UserID    int64  `json:"user_id"`
+	UserCode  string `json:"user_code"`
+	UserName  string `json:"user_name"`
+	UserEmail string `json:"user_email"`
+	RoleName  string `json:"role_name"`
+	Enrolled  bool   `json:"enrolled"`
+	Required  bool   `json:"required"`
+	Deadline  int64  `json:"deadline,omitempty"`
+	Compliant bool   `json:"compliant"` // Enrolled, not required, or still within the grace period
+}
@@ -1,15 +1,5 @@
 package model
 
-type GoogleUserData struct {
-	ID            string `json:"id"`
-	Email         string `json:"email"`
-	VerifiedEmail bool   `json:"verified_email"`
-	Name          string `json:"name"`
-	GivenName     string `json:"given_name"`
-	FamilyName    string `json:"family_name"`
-	Picture       string `json:"picture"`
-}
-
 type TokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	TokenType    string `json:"token_type"`
@@ -20,57 +10,193 @@ type TokenResponse struct {
 }
 
 type OAuthLoginRequest struct {
-	Provider string `json:"provider" binding:"required,oneof=google facebook microsoft"`
+	Provider string `json:"provider" binding:"required"` // One of the configured OAUTH_PROVIDERS
 	AppID    string `json:"app_id" binding:"omitempty"`
 }
 
 type OAuthCallbackRequest struct {
-	Provider string `json:"provider" binding:"required,oneof=google facebook microsoft"`
+	Provider string `json:"provider" binding:"required"`
 	Code     string `json:"code" binding:"required"`
 	State    string `json:"state" binding:"required"`
 	AppID    string `json:"app_id" binding:"omitempty"`
+	Error    string `json:"error,omitempty"` // Set by the provider when the login failed or was cancelled
 }
 
 type OAuthUrlResponse struct {
-	AuthURL string `json:"auth_url"`
+	AuthURL    string `json:"auth_url"`
+	LoginNonce string `json:"login_nonce"` // Kept by the browser and sent with the login code to /auth/oauth2/exchange
+}
+
+// OAuthStateValue is stored in Redis under the state parameter while the user logs
+// in at an external identity provider
+type OAuthStateValue struct {
+	Provider       string `json:"provider"`
+	AppID          string `json:"app_id"`
+	CodeVerifier   string `json:"code_verifier"`
+	Nonce          string `json:"nonce,omitempty"`
+	LoginNonceHash string `json:"login_nonce_hash"` // Binds the resulting login code to the browser that started the login
+	ExpiresAt      int64  `json:"exp"`
+}
+
+// OAuthLoginValue is stored in Redis behind the one-time login code the frontend
+// receives after a login at an external identity provider
+type OAuthLoginValue struct {
+	UserID         int64  `json:"uid"`
+	Provider       string `json:"provider"`
+	LoginNonceHash string `json:"login_nonce_hash"` // Only the browser holding the login nonce may exchange the code
+	ExpiresAt      int64  `json:"exp"`
+}
+
+// OAuthExchangeRequest redeems a login code for a session
+type OAuthExchangeRequest struct {
+	Code       string `json:"code" binding:"required"`
+	LoginNonce string `json:"login_nonce" binding:"required"` // Returned with the login URL
+	TenantID   *int64 `json:"tenant_id,omitempty"`            // Optional: for multi-tenant users
+	UserAgent  string `json:"-"`                              // Set by the handler from the request
+	ClientIP   string `json:"-"`                              // Set by the handler from the request
+}
+
+// OAuthProvidersResponse lists the identity providers users can log in with
+type OAuthProvidersResponse struct {
+	Providers []string `json:"providers"`
 }
 
 type OAuthClientRequest struct {
-	Name         string `json:"name" binding:"required"`
-	Description  string `json:"description"`
-	RedirectURIs string `json:"redirect_uris" binding:"required"`
-	Scopes       string `json:"scopes"`
+	Name         string `json:"name" binding:"required,max=100"`
+	Description  string `json:"description" binding:"max=500"`
+	RedirectURIs string `json:"redirect_uris"`
+	Scopes       string `json:"scopes" binding:"required"` // Space separated, e.g. "inventory:read inventory:update"
+	TenantID     *int64 `json:"tenant_id"`                 // Owning tenant, empty for platform-wide clients
+	Public       bool   `json:"public"`                    // Browser apps without a secret, they need redirect_uris
 }
 
 type OAuthClientResponse struct {
 	PKID         int64  `json:"pkid"`
 	ClientID     string `json:"client_id"`
-	ClientSecret string `json:"client_secret,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"` // Only returned when created or rotated
 	Name         string `json:"name"`
 	Description  string `json:"description"`
 	RedirectURIs string `json:"redirect_uris"`
 	Scopes       string `json:"scopes"`
+	TenantID     *int64 `json:"tenant_id"`
+	Public       bool   `json:"public"`
 	IsActive     bool   `json:"is_active"`
 	CreatedAt    string `json:"created_at"`
 }
 
+// OAuthClientStatusRequest enables or disables a registered client
+type OAuthClientStatusRequest struct {
+	IsActive *bool `json:"is_active" binding:"required"`
+}
+
+// Grant types accepted by POST /oauth2/token
+const (
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+)
+
+// OpenID Connect scopes every client may request besides its registered ones
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// CodeChallengeMethodS256 is the only PKCE method accepted (RFC 7636)
+const CodeChallengeMethodS256 = "S256"
+
+// ClientTokenPrefix starts every access token issued to an OAuth client, followed by 64 hex characters
+const ClientTokenPrefix = "cct_"
+
+// OAuthTokenRequest is a token request (form encoded). Clients authenticate with HTTP
+// Basic or client_id/client_secret form fields; public clients only send client_id.
+type OAuthTokenRequest struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Scope        string `form:"scope"`         // client_credentials: space separated, defaults to every scope the client is allowed
+	Code         string `form:"code"`          // authorization_code
+	RedirectURI  string `form:"redirect_uri"`  // authorization_code: same as in the authorization request
+	CodeVerifier string `form:"code_verifier"` // authorization_code: PKCE verifier
+	RefreshToken string `form:"refresh_token"` // refresh_token
+	UserAgent    string `form:"-"`             // Set by the handler from the request
+	ClientIP     string `form:"-"`             // Set by the handler from the request
+}
+
+// ClientTokenValue is stored in Redis behind a client access token
+type ClientTokenValue struct {
+	ClientPKID int64  `json:"cid"`
+	ClientID   string `json:"client_id"`
+	TenantID   int64  `json:"tid,omitempty"` // Empty for platform-wide clients
+	Scope      string `json:"scope"`
+	IssuedAt   int64  `json:"iat"`
+	ExpiresAt  int64  `json:"exp"`
+}
+
+// OAuthAuthorizeRequest starts an OpenID Connect login (authorization code flow with PKCE)
 type OAuthAuthorizeRequest struct {
-	ResponseType        string `form:"response_type" binding:"required,eq=code"`
+	ResponseType        string `form:"response_type" binding:"required"`
 	ClientID            string `form:"client_id" binding:"required"`
 	RedirectURI         string `form:"redirect_uri" binding:"required"`
-	Scope               string `form:"scope"`
+	Scope               string `form:"scope"` // Must include openid
 	State               string `form:"state"`
+	Nonce               string `form:"nonce"`  // Echoed in the ID token
+	Prompt              string `form:"prompt"` // "consent" asks the user again
 	CodeChallenge       string `form:"code_challenge"`
-	CodeChallengeMethod string `form:"code_challenge_method"`
+	CodeChallengeMethod string `form:"code_challenge_method"` // Only S256
 }
 
-type OAuthTokenRequest struct {
-	GrantType    string `form:"grant_type" binding:"required,eq=authorization_code"`
-	Code         string `form:"code" binding:"required"`
-	RedirectURI  string `form:"redirect_uri" binding:"required"`
-	ClientID     string `form:"client_id" binding:"required"`
-	ClientSecret string `form:"client_secret" binding:"required"`
-	CodeVerifier string `form:"code_verifier"`
+// AuthorizationRequestValue is stored in Redis while the user logs in and approves a client
+type AuthorizationRequestValue struct {
+	ClientPKID    int64  `json:"cid"`
+	ClientID      string `json:"client_id"`
+	RedirectURI   string `json:"redirect_uri"`
+	Scope         string `json:"scope"`
+	State         string `json:"state,omitempty"`
+	Nonce         string `json:"nonce,omitempty"`
+	Prompt        string `json:"prompt,omitempty"`
+	CodeChallenge string `json:"code_challenge"`
+	IssuedAt      int64  `json:"iat"`
+	ExpiresAt     int64  `json:"exp"`
+}
+
+// AuthorizationCodeValue is stored in Redis behind an authorization code until the
+// client redeems it
+type AuthorizationCodeValue struct {
+	ClientPKID    int64    `json:"cid"`
+	ClientID      string   `json:"client_id"`
+	RedirectURI   string   `json:"redirect_uri"`
+	Scope         string   `json:"scope"`
+	Nonce         string   `json:"nonce,omitempty"`
+	CodeChallenge string   `json:"code_challenge"`
+	UserID        int64    `json:"uid"`
+	TenantID      int64    `json:"tid"`
+	LoginMethod   string   `json:"login_method,omitempty"` // How the user logged in to the portal
+	AMR           []string `json:"amr,omitempty"`
+	AuthTime      int64    `json:"auth_time"`
+	IssuedAt      int64    `json:"iat"`
+	ExpiresAt     int64    `json:"exp"`
+}
+
+// AuthorizationRequestInfo is shown on the consent screen
+type AuthorizationRequestInfo struct {
+	RequestID       string     `json:"request_id"`
+	ClientID        string     `json:"client_id"`
+	ClientName      string     `json:"client_name"`
+	Description     string     `json:"description"`
+	RedirectURI     string     `json:"redirect_uri"`
+	Scopes          []string   `json:"scopes"`
+	Tenant          TenantInfo `json:"tenant"`           // Organization the application gets access to
+	ConsentRequired bool       `json:"consent_required"` // False when the user already approved these scopes
+}
+
+// AuthorizationDecisionRequest approves or denies a pending authorization request
+type AuthorizationDecisionRequest struct {
+	Approve *bool `json:"approve" binding:"required"`
+}
+
+// AuthorizationDecisionResponse tells the frontend where to send the browser next
+type AuthorizationDecisionResponse struct {
+	RedirectTo string `json:"redirect_to"`
 }
 
 type OAuthTokenResponse struct {
@@ -78,18 +204,38 @@ type OAuthTokenResponse struct {
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int    `json:"expires_in"`
 	RefreshToken string `json:"refresh_token,omitempty"`
-	Scope        string `json:"scope"`
+	Scope        string `json:"scope,omitempty"`
 	IDToken      string `json:"id_token,omitempty"`
 }
 
+// OAuthUserInfoResponse holds the OpenID Connect claims about the token's user
 type OAuthUserInfoResponse struct {
-	Sub      string `json:"sub"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Picture  string `json:"picture"`
-	Gender   string `json:"gender"`
-	Username string `json:"username"`
-	Code     string `json:"code"`
+	Sub           string   `json:"sub"`
+	Name          string   `json:"name,omitempty"`
+	Picture       string   `json:"picture,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	EmailVerified *bool    `json:"email_verified,omitempty"`
+	TenantID      int64    `json:"tid"`
+	TenantSlug    string   `json:"tenant_slug"`
+	Roles         []string `json:"roles"`
+}
+
+// OpenIDConfiguration is the OpenID Connect discovery document
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
 }
 
 type OAuthErrorResponse struct {
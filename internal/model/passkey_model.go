@@ -0,0 +1,79 @@
+package model
+
+import "encoding/json"
+
+// Purposes of a WebAuthn ceremony, a ceremony can only be finished for its own purpose
+const (
+	PasskeyCeremonyRegistration = "registration"
+	PasskeyCeremonyLogin        = "login"
+	PasskeyCeremonyMFA          = "mfa"
+)
+
+// PasskeyCeremonyValue is stored in Redis between the begin and finish step of a ceremony
+type PasskeyCeremonyValue struct {
+	Purpose        string          `json:"purpose"`
+	UserID         int64           `json:"uid,omitempty"`       // Empty for passwordless logins, the passkey tells who it is
+	ChallengeToken string          `json:"challenge,omitempty"` // MFA challenge the ceremony answers
+	SessionData    json.RawMessage `json:"session_data"`        // WebAuthn challenge and allowed credentials
+	ExpiresAt      int64           `json:"exp"`
+}
+
+// PasskeyCeremonyResponse starts a ceremony. Options go to navigator.credentials.create()
+// or .get(); the ceremony token comes back with the result.
+type PasskeyCeremonyResponse struct {
+	CeremonyToken string `json:"ceremony_token"`
+	ExpiresIn     int    `json:"expires_in"`
+	Options       any    `json:"options"`
+}
+
+// BeginPasskeyRegistrationRequest re-confirms the password before a passkey is added,
+// since a passkey logs in without it
+type BeginPasskeyRegistrationRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// DeletePasskeyRequest re-confirms the password before a passkey is removed
+type DeletePasskeyRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// FinishPasskeyRegistrationRequest stores the credential created by the authenticator
+type FinishPasskeyRegistrationRequest struct {
+	CeremonyToken string          `json:"ceremony_token" binding:"required"`
+	Name          string          `json:"name" binding:"max=100"` // Defaults to "Passkey"
+	Credential    json.RawMessage `json:"credential" binding:"required"`
+}
+
+// PasskeyLoginRequest logs in with a passkey instead of email and password
+type PasskeyLoginRequest struct {
+	CeremonyToken string          `json:"ceremony_token" binding:"required"`
+	Credential    json.RawMessage `json:"credential" binding:"required"`
+	TenantID      *int64          `json:"tenant_id,omitempty"` // Optional: for multi-tenant users
+	UserAgent     string          `json:"-"`                   // Set by the handler from the request
+	ClientIP      string          `json:"-"`                   // Set by the handler from the request
+}
+
+// BeginMFAPasskeyRequest starts answering an MFA challenge with a passkey
+type BeginMFAPasskeyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+// VerifyMFAPasskeyRequest completes a login with a passkey as the second factor
+type VerifyMFAPasskeyRequest struct {
+	ChallengeToken string          `json:"challenge_token" binding:"required"`
+	CeremonyToken  string          `json:"ceremony_token" binding:"required"`
+	Credential     json.RawMessage `json:"credential" binding:"required"`
+	UserAgent      string          `json:"-"` // Set by the handler from the request
+	ClientIP       string          `json:"-"` // Set by the handler from the request
+}
+
+// PasskeyResponse describes a registered passkey
+type PasskeyResponse struct {
+	ID             int64    `json:"id"`
+	Name           string   `json:"name"`
+	Transports     []string `json:"transports"`
+	BackupEligible bool     `json:"backup_eligible"`
+	BackupState    bool     `json:"backup_state"` // Synced to a passkey provider
+	CreatedAt      int64    `json:"created_at"`
+	LastUsedAt     *int64   `json:"last_used_at,omitempty"`
+}
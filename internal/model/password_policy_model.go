@@ -0,0 +1,27 @@
+package model
+
+// UpdatePasswordPolicyRequest replaces a tenant's password policy overrides. Omitted
+// fields fall back to the platform default; banned words extend the platform list.
+type UpdatePasswordPolicyRequest struct {
+	MinLength        *int     `json:"min_length" binding:"omitempty,min=1,max=72"`
+	RequireUppercase *bool    `json:"require_uppercase"`
+	RequireLowercase *bool    `json:"require_lowercase"`
+	RequireDigit     *bool    `json:"require_digit"`
+	RequireSymbol    *bool    `json:"require_symbol"`
+	BannedWords      []string `json:"banned_words"`
+	HistorySize      *int     `json:"history_size" binding:"omitempty,min=0,max=24"`
+	MaxAge           string   `json:"max_age"` // Go duration, e.g. "2160h"; "0" turns expiry off
+}
+
+// PasswordPolicyResponse is the password policy in effect for a tenant
+type PasswordPolicyResponse struct {
+	TenantID         int64  `json:"tenant_id"`
+	MinLength        int    `json:"min_length"`
+	MaxLength        int    `json:"max_length"`
+	RequireUppercase bool   `json:"require_uppercase"`
+	RequireLowercase bool   `json:"require_lowercase"`
+	RequireDigit     bool   `json:"require_digit"`
+	RequireSymbol    bool   `json:"require_symbol"`
+	HistorySize      int    `json:"history_size"`      // Recent passwords that cannot be reused
+	MaxAge           string `json:"max_age,omitempty"` // Empty when passwords never expire
+}
@@ -3,7 +3,7 @@ package model
 // RegisterWithTenantRequest represents the request to register a new user with a tenant
 type RegisterWithTenantRequest struct {
 	Email       string `json:"email" binding:"required,email"`
-	Password    string `json:"password" binding:"required,min=8"`
+	Password    string `json:"password" binding:"required"`
 	Name        string `json:"name" binding:"required"`
 	CompanyName string `json:"company_name" binding:"required"`
 }
@@ -2,33 +2,100 @@ package model
 
 // SessionValue represents the "fat" session object stored in Redis
 type SessionValue struct {
-	UserID      int64    `json:"uid"`
-	UserUUID    string   `json:"uuid"`
-	TenantID    int64    `json:"tid"`
-	TenantSlug  string   `json:"tenant_slug"`
-	Roles       []string `json:"roles"`
-	Permissions []string `json:"permissions"`
-	Scope       string   `json:"scope"`
-	Email       string   `json:"email"`
-	Name        string   `json:"name"`
-	IssuedAt    int64    `json:"iat"`
-	ExpiresAt   int64    `json:"exp"`
+	UserID       int64         `json:"uid"`
+	UserUUID     string        `json:"uuid"`
+	TenantID     int64         `json:"tid"`
+	TenantSlug   string        `json:"tenant_slug"`
+	Roles        []string      `json:"roles"`
+	Permissions  []string      `json:"permissions"`
+	Scope        string        `json:"scope"`
+	Email        string        `json:"email"`
+	Name         string        `json:"name"`
+	UserAgent    string        `json:"user_agent,omitempty"`
+	ClientIP     string        `json:"ip,omitempty"`
+	Device       string        `json:"device,omitempty"`       // Parsed label, e.g. "Chrome on Windows"
+	LoginMethod  string        `json:"login_method,omitempty"` // password, oauth, ...
+	AMR          []string      `json:"amr,omitempty"`          // Authentication methods used (RFC 8176), e.g. pwd, otp, mfa
+	Restriction  string        `json:"restriction,omitempty"`  // Set on limited sessions, e.g. mfa_enrollment
+	Impersonator *Impersonator `json:"impersonator,omitempty"` // Platform admin acting as the user
+	APITokenID   int64         `json:"api_token_id,omitempty"` // Set when the context comes from a personal access token
+	ClientID     string        `json:"client_id,omitempty"`    // OAuth client the context was issued to (client credentials, or an OpenID Connect login)
+	FamilyID     string        `json:"fid,omitempty"`          // Refresh token family this session belongs to
+	AuthTime     int64         `json:"auth_time,omitempty"`    // When the user actually logged in
+	IssuedAt     int64         `json:"iat"`
+	ExpiresAt    int64         `json:"exp"`                    // Sliding idle expiry
+	IdleTimeout  int64         `json:"idle_timeout,omitempty"` // Seconds the session may stay unused
+	MaxExpiresAt int64         `json:"max_exp,omitempty"`      // Absolute expiry, never extended
+	LastSeenAt   int64         `json:"last_seen,omitempty"`    // Tracked separately in Redis, filled in when listing
+}
+
+// Login methods recorded on sessions
+const (
+	LoginMethodPassword          = "password"
+	LoginMethodOAuth             = "oauth"
+	LoginMethodPasskey           = "passkey"
+	LoginMethodImpersonation     = "impersonation"
+	LoginMethodAPIToken          = "api_token"
+	LoginMethodClientCredentials = "client_credentials"
+)
+
+// Impersonator identifies the platform admin behind an impersonated session
+type Impersonator struct {
+	UserID int64  `json:"uid"`
+	Email  string `json:"email"`
+	Name   string `json:"name"`
+}
+
+// Restrictions of limited sessions. A restricted session carries no roles or
+// permissions and is only accepted by the endpoints that allow its restriction.
+const (
+	SessionRestrictionMFAEnrollment  = "mfa_enrollment"
+	ScopeMFAEnrollment               = "mfa:enroll"
+	SessionRestrictionPasswordChange = "password_change"
+	ScopePasswordChange              = "password:change"
+)
+
+// Authentication method references (RFC 8176) recorded on sessions
+const (
+	AMRPassword    = "pwd"
+	AMROTP         = "otp"
+	AMRMFA         = "mfa"
+	AMRHardwareKey = "hwk" // Proof of possession of a passkey
+	AMRFederated   = "fed" // Authenticated by an external identity provider
+)
+
+// ClientInfo describes the client a session is created for
+type ClientInfo struct {
+	IPAddress   string
+	UserAgent   string
+	LoginMethod string
+	AMR         []string
+	ClientID    string // OpenID Connect client the session is issued to, empty for portal logins
+	Scope       string // Scopes the user approved for that client
 }
 
 // PhantomLoginRequest represents the login credentials for phantom token
 type PhantomLoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
-	TenantID *int64 `json:"tenant_id,omitempty"` // Optional: for multi-tenant users
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required"`
+	TenantID  *int64 `json:"tenant_id,omitempty"` // Optional: for multi-tenant users
+	UserAgent string `json:"-"`                   // Set by the handler from the request
+	ClientIP  string `json:"-"`                   // Set by the handler from the request
 }
 
 // PhantomLoginResponse returns the reference token (phantom token)
 type PhantomLoginResponse struct {
-	AccessToken string          `json:"access_token"` // This is the reference token
-	ExpiresIn   int             `json:"expires_in"`   // Seconds until expiration
-	TokenType   string          `json:"token_type"`   // Always "Bearer"
-	User        UserSessionInfo `json:"user"`
-	Tenant      TenantInfo      `json:"tenant"`
+	AccessToken      string           `json:"access_token"`                 // This is the reference token
+	ExpiresIn        int              `json:"expires_in"`                   // Seconds until expiration
+	TokenType        string           `json:"token_type"`                   // Always "Bearer"
+	RefreshToken     string           `json:"refresh_token,omitempty"`      // Long-lived, single-use refresh token
+	RefreshExpiresIn int              `json:"refresh_expires_in,omitempty"` // Seconds until the refresh token expires
+	User             UserSessionInfo  `json:"user"`
+	Tenant           TenantInfo       `json:"tenant"`
+	MFA              *MFAPolicyStatus `json:"mfa,omitempty"` // Set when the tenant's MFA policy concerns the user
+
+	PasswordChangeRequired bool          `json:"password_change_required,omitempty"` // The session is limited to changing the expired password
+	Impersonator           *Impersonator `json:"impersonator,omitempty"`             // Set on sessions of an admin acting as the user
 }
 
 // UserSessionInfo contains basic user info for the session
@@ -41,9 +108,10 @@ type UserSessionInfo struct {
 
 // TenantSelectionResponse is returned when user has multiple tenants
 type TenantSelectionResponse struct {
-	Message      string              `json:"message"`
-	Tenants      []TenantMembership  `json:"tenants"`
-	RequiresChoice bool              `json:"requires_choice"`
+	Message        string             `json:"message"`
+	Tenants        []TenantMembership `json:"tenants"`
+	RequiresChoice bool               `json:"requires_choice"`
+	LoginCode      string             `json:"login_code,omitempty"` // External logins: exchange it again with tenant_id
 }
 
 // TenantMembership extends TenantInfo with role information
@@ -56,9 +124,16 @@ type TenantMembership struct {
 
 // SelectTenantRequest for multi-tenant user to select active tenant
 type SelectTenantRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
-	TenantID int64  `json:"tenant_id" binding:"required"`
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required"`
+	TenantID  int64  `json:"tenant_id" binding:"required"`
+	UserAgent string `json:"-"` // Set by the handler from the request
+	ClientIP  string `json:"-"` // Set by the handler from the request
+}
+
+// SwitchTenantRequest for moving an existing session to another tenant
+type SwitchTenantRequest struct {
+	TenantID int64 `json:"tenant_id" binding:"required"`
 }
 
 // MembershipWithTenant represents a user's membership with tenant details
@@ -83,9 +158,41 @@ type SessionContext struct {
 // RefreshSessionRequest for refreshing the reference token
 type RefreshSessionRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
+	UserAgent    string `json:"-"` // Set by the handler from the request
+	ClientIP     string `json:"-"` // Set by the handler from the request
+	ClientID     string `json:"-"` // OpenID Connect client refreshing, empty on the portal
+}
+
+// RefreshTokenValue is stored in Redis for every phantom refresh token
+type RefreshTokenValue struct {
+	FamilyID    string   `json:"fid"`
+	UserID      int64    `json:"uid"`
+	UserAgent   string   `json:"user_agent,omitempty"`
+	LoginMethod string   `json:"login_method,omitempty"` // Carried over to every rotated session
+	AMR         []string `json:"amr,omitempty"`          // Carried over to every rotated session
+	Scope       string   `json:"scope,omitempty"`        // Approved scopes of an OpenID Connect client, carried over
+	IssuedAt    int64    `json:"iat"`
+	ExpiresAt   int64    `json:"exp"`
 }
 
 // LogoutRequest for invalidating the session
 type LogoutRequest struct {
 	AccessToken string `json:"access_token" binding:"required"`
 }
+
+// ActiveSessionInfo describes one of the user's active sessions without exposing its token
+type ActiveSessionInfo struct {
+	SessionID   string `json:"session_id"`
+	TenantID    int64  `json:"tenant_id"`
+	TenantSlug  string `json:"tenant_slug"`
+	Device      string `json:"device,omitempty"`
+	UserAgent   string `json:"user_agent,omitempty"`
+	IPAddress   string `json:"ip_address,omitempty"`
+	LoginMethod string `json:"login_method,omitempty"`
+	IssuedAt    int64  `json:"issued_at"`
+	LastSeenAt  int64  `json:"last_seen_at,omitempty"`
+	ExpiresAt   int64  `json:"expires_at"`
+	Current     bool   `json:"current"` // True for the session used to make the request
+
+	ImpersonatedBy *Impersonator `json:"impersonated_by,omitempty"` // Set when a platform admin is acting as the user
+}
@@ -17,4 +17,12 @@ type (
 		Email    string `json:"email"`
 		ResetURL string `json:"reset_url"`
 	}
+
+	AccountLockedEvent struct {
+		UserEvent
+		Email          string `json:"email"`
+		IPAddress      string `json:"ip_address"`
+		FailedAttempts int64  `json:"failed_attempts"`
+		LockedUntil    int64  `json:"locked_until"`
+	}
 )
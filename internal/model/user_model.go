@@ -19,6 +19,7 @@ type (
 	LoginRequest struct {
 		Email    string `json:"email" binding:"required,email"`
 		Password string `json:"password" binding:"required"`
+		ClientIP string `json:"-"` // Set by the handler from the request
 	}
 
 	LoginResponse struct {
@@ -39,7 +40,7 @@ type (
 
 	ResetPasswordRequest struct {
 		Token       string `json:"token" binding:"required"`
-		NewPassword string `json:"new_password" binding:"required,min=8"`
+		NewPassword string `json:"new_password" binding:"required"`
 	}
 
 	VerifyEmailRequest struct {
@@ -56,13 +57,13 @@ type (
 
 	ChangePasswordRequest struct {
 		OldPassword string `json:"old_password" binding:"required"`
-		NewPassword string `json:"new_password" binding:"required,min=8"`
+		NewPassword string `json:"new_password" binding:"required"`
 	}
 
 	CreateUserRequest struct {
 		Name     string        `json:"name" binding:"required"`
 		Email    string        `json:"email" binding:"required,email"`
-		Password string        `json:"password" binding:"required,min=8"`
+		Password string        `json:"password" binding:"required"`
 		Gender   entity.Gender `json:"gender,omitempty"`
 	}
 
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"go-gin-clean/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APITokenRepository struct {
+	db       *gorm.DB
+	baseRepo BaseRepository[entity.APIToken]
+}
+
+func NewAPITokenRepository(db *gorm.DB) *APITokenRepository {
+	baseRepo := NewBaseRepository[entity.APIToken](db)
+	return &APITokenRepository{
+		db:       db,
+		baseRepo: *baseRepo,
+	}
+}
+
+func (r *APITokenRepository) Create(ctx context.Context, token *entity.APIToken) (*entity.APIToken, error) {
+	return r.baseRepo.Create(ctx, token)
+}
+
+// FindByUserID returns the user's API tokens, newest first
+func (r *APITokenRepository) FindByUserID(ctx context.Context, userID int64) ([]entity.APIToken, error) {
+	var tokens []entity.APIToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *APITokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*entity.APIToken, error) {
+	return r.baseRepo.FindFirst(ctx, "token_hash = ?", tokenHash)
+}
+
+// FindByIDAndUserID returns one of the user's API tokens
+func (r *APITokenRepository) FindByIDAndUserID(ctx context.Context, id int64, userID int64) (*entity.APIToken, error) {
+	return r.baseRepo.FindFirst(ctx, "id = ? AND user_id = ?", id, userID)
+}
+
+// CountByUserID returns how many API tokens the user has, expired ones included
+func (r *APITokenRepository) CountByUserID(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.APIToken{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// RecordUse stores when the token was last accepted
+func (r *APITokenRepository) RecordUse(ctx context.Context, id int64, usedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.APIToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", usedAt).Error
+}
+
+// DeleteByIDAndUserID revokes one of the user's API tokens. It returns false when
+// the token does not exist or belongs to someone else.
+func (r *APITokenRepository) DeleteByIDAndUserID(ctx context.Context, id int64, userID int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&entity.APIToken{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"go-gin-clean/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type ImpersonationLogRepository struct {
+	db       *gorm.DB
+	baseRepo BaseRepository[entity.ImpersonationLog]
+}
+
+func NewImpersonationLogRepository(db *gorm.DB) *ImpersonationLogRepository {
+	baseRepo := NewBaseRepository[entity.ImpersonationLog](db)
+	return &ImpersonationLogRepository{
+		db:       db,
+		baseRepo: *baseRepo,
+	}
+}
+
+func (r *ImpersonationLogRepository) Create(ctx context.Context, log *entity.ImpersonationLog) (*entity.ImpersonationLog, error) {
+	return r.baseRepo.Create(ctx, log)
+}
+
+// FindByUserID returns a page of the impersonations of a user, newest first
+func (r *ImpersonationLogRepository) FindByUserID(ctx context.Context, userID int64, limit, offset int) ([]entity.ImpersonationLog, int64, error) {
+	var logs []entity.ImpersonationLog
+	var count int64
+
+	q := r.db.WithContext(ctx).Model(&entity.ImpersonationLog{}).Where("user_id = ?", userID)
+	if err := q.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := q.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&logs).Error
+	return logs, count, err
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"go-gin-clean/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MFARepository struct {
+	db       *gorm.DB
+	baseRepo BaseRepository[entity.UserMFA]
+}
+
+func NewMFARepository(db *gorm.DB) *MFARepository {
+	baseRepo := NewBaseRepository[entity.UserMFA](db)
+	return &MFARepository{
+		db:       db,
+		baseRepo: *baseRepo,
+	}
+}
+
+func (r *MFARepository) FindByUserID(ctx context.Context, userID int64) (*entity.UserMFA, error) {
+	return r.baseRepo.FindFirst(ctx, "user_id = ?", userID)
+}
+
+// FindEnabledUserIDs returns which of the given users have a second factor, that is
+// TOTP enabled or at least one passkey registered
+func (r *MFARepository) FindEnabledUserIDs(ctx context.Context, userIDs []int64) (map[int64]bool, error) {
+	enabled := make(map[int64]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return enabled, nil
+	}
+
+	var ids []int64
+	if err := r.db.WithContext(ctx).
+		Model(&entity.UserMFA{}).
+		Where("user_id IN ? AND totp_enabled = ?", userIDs, true).
+		Pluck("user_id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	var passkeyIDs []int64
+	if err := r.db.WithContext(ctx).
+		Model(&entity.WebAuthnCredential{}).
+		Distinct("user_id").
+		Where("user_id IN ?", userIDs).
+		Pluck("user_id", &passkeyIDs).Error; err != nil {
+		return nil, err
+	}
+
+	for _, id := range append(ids, passkeyIDs...) {
+		enabled[id] = true
+	}
+	return enabled, nil
+}
+
+// HasSecondFactor reports whether the user has TOTP enabled or a passkey registered
+func (r *MFARepository) HasSecondFactor(ctx context.Context, userID int64) (bool, error) {
+	enabled, err := r.FindEnabledUserIDs(ctx, []int64{userID})
+	if err != nil {
+		return false, err
+	}
+	return enabled[userID], nil
+}
+
+// SavePendingSecret stores a new, not yet confirmed TOTP secret for the user,
+// replacing any earlier unfinished enrollment
+func (r *MFARepository) SavePendingSecret(ctx context.Context, userID int64, encryptedSecret string) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"totp_secret":       encryptedSecret,
+				"totp_enabled":      false,
+				"totp_confirmed_at": nil,
+				"totp_last_step":    0,
+				"updated_at":        time.Now(),
+			}),
+		}).
+		Create(&entity.UserMFA{UserID: userID, TOTPSecret: encryptedSecret}).Error
+}
+
+// EnableTOTP marks the pending secret as confirmed and replaces the user's recovery codes
+func (r *MFARepository) EnableTOTP(ctx context.Context, userID int64, step int64, codeHashes []string) error {
+	tx := r.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	now := time.Now()
+	result := tx.Model(&entity.UserMFA{}).
+		Where("user_id = ? AND totp_enabled = ?", userID, false).
+		Updates(map[string]any{
+			"totp_enabled":      true,
+			"totp_confirmed_at": now,
+			"totp_last_step":    step,
+			"updated_at":        now,
+		})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return gorm.ErrRecordNotFound
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// ReplaceRecoveryCodes invalidates all recovery codes of the user and stores the new set
+func (r *MFARepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	tx := r.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID int64, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&entity.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	codes := make([]entity.MFARecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, entity.MFARecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+// AdvanceTOTPStep records step as the last accepted TOTP time step. It returns false
+// when a code of the same or a later step was already used, so every code works once.
+func (r *MFARepository) AdvanceTOTPStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.UserMFA{}).
+		Where("user_id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used. It returns false when the
+// code does not exist or was used before.
+func (r *MFARepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes the user has left
+func (r *MFARepository) CountUnusedRecoveryCodes(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteByUserID removes the user's TOTP secret and recovery codes
+func (r *MFARepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	tx := r.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Where("user_id = ?", userID).Delete(&entity.MFARecoveryCode{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&entity.UserMFA{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
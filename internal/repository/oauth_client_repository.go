@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"go-gin-clean/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OAuthClientRepository struct {
+	db       *gorm.DB
+	baseRepo BaseRepository[entity.OAuthClient]
+}
+
+func NewOAuthClientRepository(db *gorm.DB) *OAuthClientRepository {
+	baseRepo := NewBaseRepository[entity.OAuthClient](db)
+	return &OAuthClientRepository{
+		db:       db,
+		baseRepo: *baseRepo,
+	}
+}
+
+func (r *OAuthClientRepository) Create(ctx context.Context, client *entity.OAuthClient) (*entity.OAuthClient, error) {
+	return r.baseRepo.Create(ctx, client)
+}
+
+func (r *OAuthClientRepository) FindByID(ctx context.Context, id int64) (*entity.OAuthClient, error) {
+	return r.baseRepo.FindByID(ctx, id)
+}
+
+func (r *OAuthClientRepository) FindByClientID(ctx context.Context, clientID string) (*entity.OAuthClient, error) {
+	return r.baseRepo.FindFirst(ctx, "client_id = ?", clientID)
+}
+
+// FindAll returns a page of registered clients, newest first
+func (r *OAuthClientRepository) FindAll(ctx context.Context, limit, offset int) ([]entity.OAuthClient, int64, error) {
+	var clients []entity.OAuthClient
+	var count int64
+
+	q := r.db.WithContext(ctx).Model(&entity.OAuthClient{})
+	if err := q.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := q.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&clients).Error
+	return clients, count, err
+}
+
+// UpdateSecret replaces the client's secret hash
+func (r *OAuthClientRepository) UpdateSecret(ctx context.Context, id int64, secretHash string) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.OAuthClient{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"client_secret": secretHash,
+			"updated_at":    time.Now(),
+		}).Error
+}
+
+// UpdateStatus enables or disables the client
+func (r *OAuthClientRepository) UpdateStatus(ctx context.Context, id int64, isActive bool) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.OAuthClient{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"is_active":  isActive,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+func (r *OAuthClientRepository) Delete(ctx context.Context, id int64) error {
+	return r.baseRepo.Delete(ctx, id)
+}
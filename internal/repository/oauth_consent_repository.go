@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"go-gin-clean/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OAuthConsentRepository struct {
+	db       *gorm.DB
+	baseRepo BaseRepository[entity.OAuthConsent]
+}
+
+func NewOAuthConsentRepository(db *gorm.DB) *OAuthConsentRepository {
+	baseRepo := NewBaseRepository[entity.OAuthConsent](db)
+	return &OAuthConsentRepository{
+		db:       db,
+		baseRepo: *baseRepo,
+	}
+}
+
+func (r *OAuthConsentRepository) FindByUserAndClient(ctx context.Context, userID, clientID int64) (*entity.OAuthConsent, error) {
+	return r.baseRepo.FindFirst(ctx, "user_id = ? AND client_id = ?", userID, clientID)
+}
+
+// Save stores the scopes the user approved for the client, replacing earlier ones
+func (r *OAuthConsentRepository) Save(ctx context.Context, userID, clientID int64, scopes string) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"scopes":     scopes,
+				"updated_at": time.Now(),
+			}),
+		}).
+		Create(&entity.OAuthConsent{UserID: userID, ClientID: clientID, Scopes: scopes}).Error
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"go-gin-clean/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type PasswordHistoryRepository struct {
+	db       *gorm.DB
+	baseRepo BaseRepository[entity.PasswordHistory]
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) *PasswordHistoryRepository {
+	baseRepo := NewBaseRepository[entity.PasswordHistory](db)
+	return &PasswordHistoryRepository{
+		db:       db,
+		baseRepo: *baseRepo,
+	}
+}
+
+func (r *PasswordHistoryRepository) Create(ctx context.Context, history *entity.PasswordHistory) (*entity.PasswordHistory, error) {
+	return r.baseRepo.Create(ctx, history)
+}
+
+// FindRecentByUserID returns the user's most recently replaced passwords, newest first
+func (r *PasswordHistoryRepository) FindRecentByUserID(ctx context.Context, userID int64, limit int) ([]entity.PasswordHistory, error) {
+	var history []entity.PasswordHistory
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&history).Error
+	return history, err
+}
+
+// Prune deletes all but the user's keep most recent entries
+func (r *PasswordHistoryRepository) Prune(ctx context.Context, userID int64, keep int) error {
+	recent := r.db.
+		Model(&entity.PasswordHistory{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(keep)
+
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND id NOT IN (?)", userID, recent).
+		Delete(&entity.PasswordHistory{}).Error
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"go-gin-clean/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SigningKeyRepository struct {
+	db       *gorm.DB
+	baseRepo BaseRepository[entity.SigningKey]
+}
+
+func NewSigningKeyRepository(db *gorm.DB) *SigningKeyRepository {
+	baseRepo := NewBaseRepository[entity.SigningKey](db)
+	return &SigningKeyRepository{
+		db:       db,
+		baseRepo: *baseRepo,
+	}
+}
+
+// FindPublished returns all keys that are still published, oldest first
+func (r *SigningKeyRepository) FindPublished(ctx context.Context) ([]*entity.SigningKey, error) {
+	var keys []*entity.SigningKey
+	err := r.db.WithContext(ctx).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("activated_at asc").
+		Find(&keys).Error
+	return keys, err
+}
+
+// Rotate stores next as the newest key and retires the keys before it once next
+// activates. It only rotates when the newest key activated before dueBefore, so
+// replicas racing on the same schedule produce a single new key.
+func (r *SigningKeyRepository) Rotate(ctx context.Context, next *entity.SigningKey, dueBefore time.Time, retention time.Duration) (bool, error) {
+	tx := r.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Serialize rotations across replicas for the rest of the transaction
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('signing_keys_rotation'))").Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	var newest entity.SigningKey
+	err := tx.Order("activated_at desc").First(&newest).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		tx.Rollback()
+		return false, err
+	}
+	if err == nil && newest.ActivatedAt.After(dueBefore) {
+		tx.Rollback()
+		return false, nil
+	}
+
+	expiresAt := next.ActivatedAt.Add(retention)
+	if err := tx.Model(&entity.SigningKey{}).
+		Where("retired_at IS NULL").
+		Updates(map[string]any{"retired_at": next.ActivatedAt, "expires_at": expiresAt}).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if err := tx.Create(next).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// DeleteExpired removes keys that are no longer published
+func (r *SigningKeyRepository) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).
+		Where("expires_at IS NOT NULL AND expires_at < ?", time.Now()).
+		Delete(&entity.SigningKey{}).Error
+}
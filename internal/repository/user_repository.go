@@ -163,6 +163,13 @@ func (r *UserRepository) UpdateOAuthInfo(ctx context.Context, userID int64, prov
 		}).Error
 }
 
+// UpdatePasswordHash replaces the stored hash of an unchanged password, e.g. after
+// moving it to a stronger algorithm, without touching password_changed_at
+func (r *UserRepository) UpdatePasswordHash(ctx context.Context, userID int64, hashedPassword string) error {
+	return r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", userID).
+		Update("password", hashedPassword).Error
+}
+
 func (r *UserRepository) Delete(ctx context.Context, code string) error {
 	tx := r.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
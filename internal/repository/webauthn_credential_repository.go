@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"go-gin-clean/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebAuthnCredentialRepository struct {
+	db       *gorm.DB
+	baseRepo BaseRepository[entity.WebAuthnCredential]
+}
+
+func NewWebAuthnCredentialRepository(db *gorm.DB) *WebAuthnCredentialRepository {
+	baseRepo := NewBaseRepository[entity.WebAuthnCredential](db)
+	return &WebAuthnCredentialRepository{
+		db:       db,
+		baseRepo: *baseRepo,
+	}
+}
+
+func (r *WebAuthnCredentialRepository) Create(ctx context.Context, credential *entity.WebAuthnCredential) (*entity.WebAuthnCredential, error) {
+	return r.baseRepo.Create(ctx, credential)
+}
+
+// FindByUserID returns the user's passkeys, oldest first
+func (r *WebAuthnCredentialRepository) FindByUserID(ctx context.Context, userID int64) ([]entity.WebAuthnCredential, error) {
+	var credentials []entity.WebAuthnCredential
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *WebAuthnCredentialRepository) FindByCredentialID(ctx context.Context, credentialID []byte) (*entity.WebAuthnCredential, error) {
+	return r.baseRepo.FindFirst(ctx, "credential_id = ?", credentialID)
+}
+
+// CountByUserID returns how many passkeys the user has registered
+func (r *WebAuthnCredentialRepository) CountByUserID(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.WebAuthnCredential{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// RecordUse stores the sign counter and flags reported by a successful assertion
+func (r *WebAuthnCredentialRepository) RecordUse(ctx context.Context, id int64, signCount int64, cloneWarning bool, backupState bool) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).
+		Model(&entity.WebAuthnCredential{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"sign_count":    signCount,
+			"clone_warning": cloneWarning,
+			"backup_state":  backupState,
+			"last_used_at":  now,
+			"updated_at":    now,
+		}).Error
+}
+
+// DeleteByIDAndUserID removes one of the user's passkeys. It returns false when the
+// passkey does not exist or belongs to someone else.
+func (r *WebAuthnCredentialRepository) DeleteByIDAndUserID(ctx context.Context, id int64, userID int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&entity.WebAuthnCredential{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go-gin-clean/internal/entity"
+	"go-gin-clean/internal/gateway/session"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/repository"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+// apiTokenUseInterval limits how often the last-use time of a busy token is written
+const apiTokenUseInterval = time.Minute
+
+type APITokenUseCase struct {
+	apiTokenRepo   *repository.APITokenRepository
+	userRepo       *repository.UserRepository
+	membershipRepo *repository.MembershipRepository
+	tenantRepo     *repository.TenantRepository
+	permissionRepo *repository.PermissionRepository
+	sessionService *session.SessionService
+	cfg            *config.APITokenConfig
+}
+
+func NewAPITokenUseCase(
+	apiTokenRepo *repository.APITokenRepository,
+	userRepo *repository.UserRepository,
+	membershipRepo *repository.MembershipRepository,
+	tenantRepo *repository.TenantRepository,
+	permissionRepo *repository.PermissionRepository,
+	sessionService *session.SessionService,
+	cfg *config.APITokenConfig,
+) *APITokenUseCase {
+	return &APITokenUseCase{
+		apiTokenRepo:   apiTokenRepo,
+		userRepo:       userRepo,
+		membershipRepo: membershipRepo,
+		tenantRepo:     tenantRepo,
+		permissionRepo: permissionRepo,
+		sessionService: sessionService,
+		cfg:            cfg,
+	}
+}
+
+// ListTokens returns the user's API tokens, without their secrets
+func (uc *APITokenUseCase) ListTokens(ctx context.Context, userID int64) ([]model.APITokenResponse, error) {
+	tokens, err := uc.apiTokenRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API tokens: %w", err)
+	}
+
+	result := make([]model.APITokenResponse, 0, len(tokens))
+	for i := range tokens {
+		result = append(result, toAPITokenResponse(&tokens[i]))
+	}
+	return result, nil
+}
+
+// CreateToken issues a personal access token for one of the user's tenants. The
+// secret is returned once; only its hash is stored.
+func (uc *APITokenUseCase) CreateToken(ctx context.Context, userID int64, req *model.CreateAPITokenRequest) (*model.CreateAPITokenResponse, error) {
+	membership, err := uc.membershipRepo.FindByUserAndTenant(ctx, userID, req.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("user does not have access to the specified tenant")
+	}
+
+	held, err := rolePermissions(ctx, uc.permissionRepo, membership.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	for _, permission := range req.Permissions {
+		if !slices.Contains(held, permission) {
+			return nil, errors.ErrAPITokenPermissionDenied
+		}
+	}
+
+	ttl := uc.cfg.DefaultTTL
+	if req.ExpiresInDays > 0 {
+		ttl = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+	if ttl > uc.cfg.MaxTTL {
+		return nil, errors.ErrAPITokenExpiryTooLong
+	}
+
+	count, err := uc.apiTokenRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count API tokens: %w", err)
+	}
+	if count >= int64(uc.cfg.MaxPerUser) {
+		return nil, errors.ErrAPITokenLimitReached
+	}
+
+	secret, err := generateAPIToken()
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := slices.Clone(req.Permissions)
+	slices.Sort(permissions)
+	permissions = slices.Compact(permissions)
+
+	apiToken, err := uc.apiTokenRepo.Create(ctx, &entity.APIToken{
+		UserID:      userID,
+		TenantID:    req.TenantID,
+		Name:        req.Name,
+		TokenHash:   hashAPIToken(secret),
+		TokenHint:   secret[:len(model.APITokenPrefix)+8],
+		Permissions: strings.Join(permissions, ","),
+		ExpiresAt:   time.Now().Add(ttl),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save API token: %w", err)
+	}
+
+	return &model.CreateAPITokenResponse{
+		APITokenResponse: toAPITokenResponse(apiToken),
+		Token:            secret,
+	}, nil
+}
+
+// RevokeToken deletes one of the user's API tokens and drops it from the
+// introspection caches
+func (uc *APITokenUseCase) RevokeToken(ctx context.Context, userID int64, tokenID int64) error {
+	apiToken, err := uc.apiTokenRepo.FindByIDAndUserID(ctx, tokenID, userID)
+	if err == gorm.ErrRecordNotFound {
+		return errors.ErrAPITokenNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load API token: %w", err)
+	}
+
+	deleted, err := uc.apiTokenRepo.DeleteByIDAndUserID(ctx, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+	if !deleted {
+		return errors.ErrAPITokenNotFound
+	}
+
+	uc.sessionService.InvalidateAPIToken(ctx, apiToken.TokenHash)
+	return nil
+}
+
+// Resolve turns an API token into the context introspection reports for it, shaped
+// like a session. Its permissions are narrowed to what the user still holds in the
+// tenant, so deactivating, demoting or removing the user also applies to their tokens.
+func (uc *APITokenUseCase) Resolve(ctx context.Context, token string) (*model.SessionValue, error) {
+	apiToken, err := uc.apiTokenRepo.FindByTokenHash(ctx, hashAPIToken(token))
+	if err != nil {
+		return nil, errors.ErrAPITokenNotFound
+	}
+
+	now := time.Now()
+	if !now.Before(apiToken.ExpiresAt) {
+		return nil, errors.ErrAPITokenNotFound
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, apiToken.UserID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	if !user.IsActive {
+		return nil, errors.ErrUserInactive
+	}
+
+	membership, err := uc.membershipRepo.FindByUserAndTenant(ctx, user.ID, apiToken.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("user does not have access to the specified tenant")
+	}
+
+	tenant, err := uc.tenantRepo.FindByID(ctx, apiToken.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tenant: %w", err)
+	}
+
+	held, err := rolePermissions(ctx, uc.permissionRepo, membership.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	permissions := make([]string, 0, len(held))
+	for _, permission := range apiToken.PermissionList() {
+		if slices.Contains(held, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	// Last-use tracking is best effort and must never fail introspection
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) >= apiTokenUseInterval {
+		_ = uc.apiTokenRepo.RecordUse(ctx, apiToken.ID, now)
+	}
+
+	// Tokens act with their permissions only, never with the user's role
+	return &model.SessionValue{
+		UserID:       user.ID,
+		UserUUID:     user.UUID,
+		TenantID:     tenant.ID,
+		TenantSlug:   tenant.Slug,
+		Roles:        []string{},
+		Permissions:  permissions,
+		Scope:        buildScope(permissions),
+		Email:        user.Email,
+		Name:         user.Name,
+		LoginMethod:  model.LoginMethodAPIToken,
+		APITokenID:   apiToken.ID,
+		IssuedAt:     apiToken.CreatedAt.Unix(),
+		ExpiresAt:    apiToken.ExpiresAt.Unix(),
+		MaxExpiresAt: apiToken.ExpiresAt.Unix(),
+	}, nil
+}
+
+// generateAPIToken creates a new token secret (pat_<64 hex chars>)
+func generateAPIToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	return model.APITokenPrefix + hex.EncodeToString(bytes), nil
+}
+
+// hashAPIToken is the lookup key stored for a token. The secret is random, so an
+// unsalted fast hash is enough.
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func toAPITokenResponse(t *entity.APIToken) model.APITokenResponse {
+	apiToken := model.APITokenResponse{
+		ID:          t.ID,
+		Name:        t.Name,
+		TenantID:    t.TenantID,
+		TokenHint:   t.TokenHint,
+		Permissions: t.PermissionList(),
+		ExpiresAt:   t.ExpiresAt.Unix(),
+		CreatedAt:   t.CreatedAt.Unix(),
+	}
+	if t.LastUsedAt != nil {
+		lastUsedAt := t.LastUsedAt.Unix()
+		apiToken.LastUsedAt = &lastUsedAt
+	}
+	return apiToken
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-gin-clean/internal/gateway/session"
+	"go-gin-clean/internal/repository"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// newTestSessionService returns a session service on an in-memory Redis
+func newTestSessionService(t *testing.T) (*session.SessionService, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return session.NewSessionService(client, &config.SessionConfig{}), mr
+}
+
+func TestAPITokenRevokeToken(t *testing.T) {
+	const tokenHash = "3a1f0c5e"
+
+	tests := []struct {
+		name             string
+		expect           func(mock sqlmock.Sqlmock)
+		want             error
+		wantInvalidation bool
+	}{
+		{
+			name: "revoked",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM "api_tokens" WHERE id = \$1 AND user_id = \$2`).
+					WithArgs(5, 1, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash"}).AddRow(5, 1, tokenHash))
+				mock.ExpectBegin()
+				mock.ExpectExec(`DELETE FROM "api_tokens" WHERE id = \$1 AND user_id = \$2`).
+					WithArgs(5, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantInvalidation: true,
+		},
+		{
+			name: "token of another user",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM "api_tokens"`).WillReturnError(gorm.ErrRecordNotFound)
+			},
+			want: errors.ErrAPITokenNotFound,
+		},
+		{
+			name: "revoked concurrently",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM "api_tokens"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash"}).AddRow(5, 1, tokenHash))
+				mock.ExpectBegin()
+				mock.ExpectExec(`DELETE FROM "api_tokens"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want: errors.ErrAPITokenNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			db, mock := newTestDB(t)
+			sessionService, mr := newTestSessionService(t)
+
+			uc := NewAPITokenUseCase(
+				repository.NewAPITokenRepository(db),
+				repository.NewUserRepository(db),
+				repository.NewMembershipRepository(db),
+				repository.NewTenantRepository(db),
+				repository.NewPermissionRepository(db),
+				sessionService,
+				&config.APITokenConfig{},
+			)
+
+			invalidations := sessionService.SubscribeInvalidations(ctx)
+			// Subscribing is asynchronous; wait until Redis knows about it
+			for mr.PubSubNumSub(session.InvalidationChannel)[session.InvalidationChannel] == 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			tt.expect(mock)
+			if err := uc.RevokeToken(ctx, 1, 5); err != tt.want {
+				t.Fatalf("RevokeToken() error = %v, want %v", err, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+
+			// Introspection caches drop the token by its hash
+			select {
+			case got := <-invalidations:
+				if !tt.wantInvalidation || got != tokenHash {
+					t.Errorf("invalidation for %q, want %v", got, tt.wantInvalidation)
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.wantInvalidation {
+					t.Error("no invalidation published")
+				}
+			}
+		})
+	}
+}
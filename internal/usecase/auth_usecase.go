@@ -3,6 +3,8 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"log"
+	"sort"
 	"time"
 
 	"go-gin-clean/internal/entity"
@@ -10,18 +12,31 @@ import (
 	"go-gin-clean/internal/gateway/session"
 	"go-gin-clean/internal/model"
 	"go-gin-clean/internal/repository"
+	"go-gin-clean/pkg/config"
 	"go-gin-clean/pkg/errors"
+	"go-gin-clean/pkg/utils"
+
+	"gorm.io/gorm"
 )
 
+// restrictedSessionLifetime bounds the limited session issued to users who must enroll
+// MFA or change an expired password before they get a full session
+const restrictedSessionLifetime = 15 * time.Minute
+
 type AuthUseCase struct {
-	userRepo       *repository.UserRepository
-	membershipRepo *repository.MembershipRepository
-	tenantRepo     *repository.TenantRepository
-	tenantRoleRepo *repository.TenantRoleRepository
-	permissionRepo *repository.PermissionRepository
-	bcryptService  *security.BcryptService
-	sessionService *session.SessionService
-	sessionTTL     time.Duration
+	userRepo          *repository.UserRepository
+	membershipRepo    *repository.MembershipRepository
+	tenantRepo        *repository.TenantRepository
+	tenantRoleRepo    *repository.TenantRoleRepository
+	permissionRepo    *repository.PermissionRepository
+	passwordService   *security.PasswordService
+	sessionService    *session.SessionService
+	mfaUseCase        *MFAUseCase
+	passkeyUseCase    *PasskeyUseCase
+	lockoutUseCase    *LockoutUseCase
+	passwordPolicy    *PasswordPolicyUseCase
+	impersonationRepo *repository.ImpersonationLogRepository
+	oauthCfg          *config.OAuthConfig
 }
 
 func NewAuthUseCase(
@@ -30,156 +45,481 @@ func NewAuthUseCase(
 	tenantRepo *repository.TenantRepository,
 	tenantRoleRepo *repository.TenantRoleRepository,
 	permissionRepo *repository.PermissionRepository,
-	bcryptService *security.BcryptService,
+	passwordService *security.PasswordService,
 	sessionService *session.SessionService,
-	sessionTTL time.Duration,
+	mfaUseCase *MFAUseCase,
+	passkeyUseCase *PasskeyUseCase,
+	lockoutUseCase *LockoutUseCase,
+	passwordPolicy *PasswordPolicyUseCase,
+	impersonationRepo *repository.ImpersonationLogRepository,
+	oauthCfg *config.OAuthConfig,
 ) *AuthUseCase {
-	if sessionTTL == 0 {
-		sessionTTL = 30 * time.Minute // Default 30 minutes
-	}
 	return &AuthUseCase{
-		userRepo:       userRepo,
-		membershipRepo: membershipRepo,
-		tenantRepo:     tenantRepo,
-		tenantRoleRepo: tenantRoleRepo,
-		permissionRepo: permissionRepo,
-		bcryptService:  bcryptService,
-		sessionService: sessionService,
-		sessionTTL:     sessionTTL,
+		userRepo:          userRepo,
+		membershipRepo:    membershipRepo,
+		tenantRepo:        tenantRepo,
+		tenantRoleRepo:    tenantRoleRepo,
+		permissionRepo:    permissionRepo,
+		passwordService:   passwordService,
+		sessionService:    sessionService,
+		mfaUseCase:        mfaUseCase,
+		passkeyUseCase:    passkeyUseCase,
+		lockoutUseCase:    lockoutUseCase,
+		passwordPolicy:    passwordPolicy,
+		impersonationRepo: impersonationRepo,
+		oauthCfg:          oauthCfg,
 	}
 }
 
-// Login authenticates a user and creates a phantom token session
-func (uc *AuthUseCase) Login(ctx context.Context, req *model.PhantomLoginRequest) (*model.PhantomLoginResponse, *model.TenantSelectionResponse, error) {
-	// 1. Validate credentials
+// Login authenticates a user and creates a phantom token session.
+// Users with a second factor (TOTP or a passkey) get an MFA challenge instead; the session
+// is issued by VerifyMFA or VerifyMFAPasskey.
+func (uc *AuthUseCase) Login(ctx context.Context, req *model.PhantomLoginRequest) (*model.PhantomLoginResponse, *model.TenantSelectionResponse, *model.MFAChallengeResponse, error) {
+	// 1. Validate credentials, unless the account or IP is locked out
+	if err := uc.lockoutUseCase.Check(ctx, req.Email, req.ClientIP); err != nil {
+		return nil, nil, nil, err
+	}
+
 	user, err := uc.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, nil, errors.ErrInvalidCredentials
+		uc.lockoutUseCase.RecordFailure(ctx, req.Email, req.ClientIP)
+		return nil, nil, nil, errors.ErrInvalidCredentials
 	}
 
 	// Check if user is active
 	if !user.IsActive {
-		return nil, nil, errors.ErrUserInactive
+		return nil, nil, nil, errors.ErrUserInactive
 	}
 
 	// Verify password
-	if err := uc.bcryptService.ComparePassword(user.Password, req.Password); err != nil {
-		return nil, nil, errors.ErrInvalidCredentials
+	if err := uc.passwordService.ComparePassword(user.Password, req.Password); err != nil {
+		uc.lockoutUseCase.RecordFailure(ctx, req.Email, req.ClientIP)
+		return nil, nil, nil, errors.ErrInvalidCredentials
 	}
+	uc.lockoutUseCase.RecordSuccess(ctx, req.Email)
+	upgradePasswordHash(ctx, uc.userRepo, uc.passwordService, user, req.Password)
 
 	// 2. Fetch user's memberships
 	memberships, err := uc.membershipRepo.FindByUserID(ctx, user.ID)
 	if err != nil || len(memberships) == 0 {
-		return nil, nil, fmt.Errorf("user has no tenant memberships")
+		return nil, nil, nil, fmt.Errorf("user has no tenant memberships")
 	}
 
 	// 3. Determine tenant selection
-	var selectedMembership *entity.Membership
-	
-	if req.TenantID != nil {
-		// User explicitly selected a tenant
-		for _, m := range memberships {
-			if m.TenantID == *req.TenantID {
-				selectedMembership = &m
-				break
-			}
-		}
-		if selectedMembership == nil {
-			return nil, nil, fmt.Errorf("user does not have access to the specified tenant")
-		}
-	} else if len(memberships) == 1 {
-		// User has only one tenant - auto-select
-		selectedMembership = &memberships[0]
-	} else {
-		// User has multiple tenants - return tenant list for selection
-		tenantSelectionResp, err := uc.buildTenantSelectionResponse(ctx, memberships)
-		return nil, tenantSelectionResp, err
+	selectedMembership, tenantSelectionResp, err := uc.selectMembership(ctx, memberships, req.TenantID)
+	if selectedMembership == nil {
+		return nil, tenantSelectionResp, nil, err
 	}
 
-	// 4. Build session and create phantom token
-	loginResp, err := uc.createLoginSession(ctx, user, selectedMembership)
-	return loginResp, nil, err
+	// 4. Build session and create phantom token, unless a second factor is required first
+	loginResp, challenge, err := uc.completePasswordLogin(ctx, user, selectedMembership, req.ClientIP, req.UserAgent)
+	return loginResp, nil, challenge, err
 }
 
-// SelectTenant allows a multi-tenant user to select their active tenant
-func (uc *AuthUseCase) SelectTenant(ctx context.Context, req *model.SelectTenantRequest) (*model.PhantomLoginResponse, error) {
-	// Re-authenticate user
+// SelectTenant allows a multi-tenant user to select their active tenant.
+// Like Login, it returns an MFA challenge instead of a session when a second factor is set up.
+func (uc *AuthUseCase) SelectTenant(ctx context.Context, req *model.SelectTenantRequest) (*model.PhantomLoginResponse, *model.MFAChallengeResponse, error) {
+	// Re-authenticate user, with the same lockout as Login
+	if err := uc.lockoutUseCase.Check(ctx, req.Email, req.ClientIP); err != nil {
+		return nil, nil, err
+	}
+
 	user, err := uc.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, errors.ErrInvalidCredentials
+		uc.lockoutUseCase.RecordFailure(ctx, req.Email, req.ClientIP)
+		return nil, nil, errors.ErrInvalidCredentials
+	}
+
+	// Check if user is active
+	if !user.IsActive {
+		return nil, nil, errors.ErrUserInactive
 	}
 
 	// Verify password
-	if err := uc.bcryptService.ComparePassword(user.Password, req.Password); err != nil {
-		return nil, errors.ErrInvalidCredentials
+	if err := uc.passwordService.ComparePassword(user.Password, req.Password); err != nil {
+		uc.lockoutUseCase.RecordFailure(ctx, req.Email, req.ClientIP)
+		return nil, nil, errors.ErrInvalidCredentials
 	}
+	uc.lockoutUseCase.RecordSuccess(ctx, req.Email)
+	upgradePasswordHash(ctx, uc.userRepo, uc.passwordService, user, req.Password)
 
 	// Find membership for selected tenant
 	membership, err := uc.membershipRepo.FindByUserAndTenant(ctx, user.ID, req.TenantID)
 	if err != nil {
-		return nil, fmt.Errorf("user does not have access to the specified tenant")
+		return nil, nil, fmt.Errorf("user does not have access to the specified tenant")
 	}
 
 	// Create session
-	response, err := uc.createLoginSession(ctx, user, membership)
+	return uc.completePasswordLogin(ctx, user, membership, req.ClientIP, req.UserAgent)
+}
+
+// completePasswordLogin issues the session for a password-verified user, or parks the
+// login behind an MFA challenge when the user has a second factor enabled
+func (uc *AuthUseCase) completePasswordLogin(ctx context.Context, user *entity.User, membership *entity.Membership, clientIP string, userAgent string) (*model.PhantomLoginResponse, *model.MFAChallengeResponse, error) {
+	return uc.completeFirstFactorLogin(ctx, user, membership, model.ClientInfo{
+		IPAddress:   clientIP,
+		UserAgent:   userAgent,
+		LoginMethod: model.LoginMethodPassword,
+		AMR:         []string{model.AMRPassword},
+	})
+}
+
+// completeFirstFactorLogin issues the session of a login whose first factor was verified,
+// or starts an MFA challenge for the login method when the user has a second factor
+func (uc *AuthUseCase) completeFirstFactorLogin(ctx context.Context, user *entity.User, membership *entity.Membership, client model.ClientInfo) (*model.PhantomLoginResponse, *model.MFAChallengeResponse, error) {
+	mfaEnabled, err := uc.mfaUseCase.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if mfaEnabled {
+		challenge, err := uc.mfaUseCase.StartChallenge(ctx, user, membership.TenantID, client.LoginMethod)
+		return nil, challenge, err
+	}
+
+	response, err := uc.createLoginSession(ctx, user, membership, client, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return response, nil, nil
+}
+
+// VerifyMFA completes a login that was parked behind an MFA challenge
+func (uc *AuthUseCase) VerifyMFA(ctx context.Context, req *model.VerifyMFARequest) (*model.PhantomLoginResponse, error) {
+	challenge, method, err := uc.mfaUseCase.CompleteChallenge(ctx, req.ChallengeToken, req.Code)
 	if err != nil {
 		return nil, err
 	}
 
-	return response, nil
+	amr := []string{firstFactorAMR(challenge.LoginMethod), model.AMRMFA}
+	if method == model.MFAMethodTOTP {
+		amr = []string{firstFactorAMR(challenge.LoginMethod), model.AMROTP, model.AMRMFA}
+	}
+
+	return uc.completeChallengeLogin(ctx, challenge, model.ClientInfo{
+		IPAddress: req.ClientIP,
+		UserAgent: req.UserAgent,
+		AMR:       amr,
+	})
 }
 
-// createLoginSession creates a session and returns login response
-func (uc *AuthUseCase) createLoginSession(ctx context.Context, user *entity.User, membership *entity.Membership) (*model.PhantomLoginResponse, error) {
-	// Fetch tenant details
-	tenant, err := uc.tenantRepo.FindByID(ctx, membership.TenantID)
+// BeginMFAPasskey starts answering an MFA challenge with a passkey instead of a code
+func (uc *AuthUseCase) BeginMFAPasskey(ctx context.Context, req *model.BeginMFAPasskeyRequest) (*model.PasskeyCeremonyResponse, error) {
+	return uc.mfaUseCase.BeginPasskeyChallenge(ctx, req.ChallengeToken)
+}
+
+// VerifyMFAPasskey completes a login that was parked behind an MFA challenge with a passkey
+func (uc *AuthUseCase) VerifyMFAPasskey(ctx context.Context, req *model.VerifyMFAPasskeyRequest) (*model.PhantomLoginResponse, error) {
+	challenge, err := uc.mfaUseCase.CompletePasskeyChallenge(ctx, req.ChallengeToken, req.CeremonyToken, req.Credential)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch tenant: %w", err)
+		return nil, err
 	}
 
-	// Fetch role details
-	role, err := uc.tenantRoleRepo.FindByID(ctx, membership.RoleID)
+	return uc.completeChallengeLogin(ctx, challenge, model.ClientInfo{
+		IPAddress: req.ClientIP,
+		UserAgent: req.UserAgent,
+		AMR:       []string{firstFactorAMR(challenge.LoginMethod), model.AMRHardwareKey, model.AMRMFA},
+	})
+}
+
+// firstFactorAMR returns the authentication method reference of the factor a login
+// method verified before its MFA challenge
+func firstFactorAMR(loginMethod string) string {
+	if loginMethod == model.LoginMethodOAuth {
+		return model.AMRFederated
+	}
+	return model.AMRPassword
+}
+
+// completeChallengeLogin issues the session of a login whose MFA challenge was answered
+func (uc *AuthUseCase) completeChallengeLogin(ctx context.Context, challenge *model.MFAChallengeValue, client model.ClientInfo) (*model.PhantomLoginResponse, error) {
+	// Re-check the account, it may have changed while the challenge was pending
+	user, err := uc.userRepo.FindByID(ctx, challenge.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch role: %w", err)
+		return nil, errors.ErrUserNotFound
+	}
+	if !user.IsActive {
+		return nil, errors.ErrUserInactive
 	}
 
-	// Fetch permissions for this role
-	permissionEntities, err := uc.permissionRepo.FindByRoleID(ctx, role.ID)
+	membership, err := uc.membershipRepo.FindByUserAndTenant(ctx, user.ID, challenge.TenantID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch permissions: %w", err)
+		return nil, fmt.Errorf("user does not have access to the specified tenant")
 	}
 
-	// Convert permissions to string format (resource:action)
-	permissions := make([]string, 0, len(permissionEntities))
-	for _, perm := range permissionEntities {
-		permissions = append(permissions, fmt.Sprintf("%s:%s", perm.Resource, perm.Action))
+	client.LoginMethod = challenge.LoginMethod
+	return uc.createLoginSession(ctx, user, membership, client, nil)
+}
+
+// BeginPasskeyLogin starts a passwordless login with a passkey
+func (uc *AuthUseCase) BeginPasskeyLogin(ctx context.Context) (*model.PasskeyCeremonyResponse, error) {
+	return uc.passkeyUseCase.BeginLogin(ctx)
+}
+
+// PasskeyLogin creates a session from a verified passkey. The passkey proves possession
+// and user verification, so no further MFA challenge follows. Multi-tenant users get
+// the tenant list and repeat the ceremony with tenant_id.
+func (uc *AuthUseCase) PasskeyLogin(ctx context.Context, req *model.PasskeyLoginRequest) (*model.PhantomLoginResponse, *model.TenantSelectionResponse, error) {
+	user, err := uc.passkeyUseCase.FinishLogin(ctx, req.CeremonyToken, req.Credential)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !user.IsActive {
+		return nil, nil, errors.ErrUserInactive
+	}
+
+	memberships, err := uc.membershipRepo.FindByUserID(ctx, user.ID)
+	if err != nil || len(memberships) == 0 {
+		return nil, nil, fmt.Errorf("user has no tenant memberships")
+	}
+
+	membership, tenantSelectionResp, err := uc.selectMembership(ctx, memberships, req.TenantID)
+	if membership == nil {
+		return nil, tenantSelectionResp, err
+	}
+
+	loginResp, err := uc.createLoginSession(ctx, user, membership, model.ClientInfo{
+		IPAddress:   req.ClientIP,
+		UserAgent:   req.UserAgent,
+		LoginMethod: model.LoginMethodPasskey,
+		AMR:         []string{model.AMRHardwareKey, model.AMRMFA},
+	}, nil)
+	return loginResp, nil, err
+}
+
+// OAuthLogin exchanges the one-time code of a login at an external identity provider
+// for a session. The code is only accepted with the login nonce of the browser that
+// started the login; a wrong nonce still consumes it. Like Login, multi-tenant users get
+// the tenant list, with a new code to exchange with tenant_id, and users with a second
+// factor get an MFA challenge.
+func (uc *AuthUseCase) OAuthLogin(ctx context.Context, req *model.OAuthExchangeRequest) (*model.PhantomLoginResponse, *model.TenantSelectionResponse, *model.MFAChallengeResponse, error) {
+	login, err := uc.sessionService.ConsumeOAuthLogin(ctx, req.Code)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !loginNonceMatches(login, req.LoginNonce) {
+		return nil, nil, nil, errors.ErrOAuthLoginCodeInvalid
+	}
+
+	// Re-check the account, it may have changed since the callback
+	user, err := uc.userRepo.FindByID(ctx, login.UserID)
+	if err != nil {
+		return nil, nil, nil, errors.ErrUserNotFound
+	}
+	if !user.IsActive {
+		return nil, nil, nil, errors.ErrUserInactive
+	}
+
+	memberships, err := uc.membershipRepo.FindByUserID(ctx, user.ID)
+	if err != nil || len(memberships) == 0 {
+		return nil, nil, nil, fmt.Errorf("user has no tenant memberships")
+	}
+
+	membership, tenantSelectionResp, err := uc.selectMembership(ctx, memberships, req.TenantID)
+	if membership == nil {
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		ttl := uc.oauthCfg.LoginCodeTTL
+		if ttl == 0 {
+			ttl = session.DefaultOAuthLoginCodeTTL
+		}
+		tenantSelectionResp.LoginCode, err = uc.sessionService.CreateOAuthLogin(ctx, &model.OAuthLoginValue{
+			UserID:         user.ID,
+			Provider:       login.Provider,
+			LoginNonceHash: login.LoginNonceHash,
+		}, ttl)
+		return nil, tenantSelectionResp, nil, err
+	}
+
+	loginResp, challenge, err := uc.completeFirstFactorLogin(ctx, user, membership, model.ClientInfo{
+		IPAddress:   req.ClientIP,
+		UserAgent:   req.UserAgent,
+		LoginMethod: model.LoginMethodOAuth,
+		AMR:         []string{model.AMRFederated},
+	})
+	return loginResp, nil, challenge, err
+}
+
+// selectMembership picks the membership a login is for: the requested tenant, or the
+// only one. Users with several tenants and no choice get the tenant list instead.
+func (uc *AuthUseCase) selectMembership(ctx context.Context, memberships []entity.Membership, tenantID *int64) (*entity.Membership, *model.TenantSelectionResponse, error) {
+	if tenantID != nil {
+		// User explicitly selected a tenant
+		for i := range memberships {
+			if memberships[i].TenantID == *tenantID {
+				return &memberships[i], nil, nil
+			}
+		}
+		return nil, nil, fmt.Errorf("user does not have access to the specified tenant")
+	}
+
+	if len(memberships) == 1 {
+		// User has only one tenant - auto-select
+		return &memberships[0], nil, nil
 	}
 
-	scope := uc.buildScope(permissions)
+	// User has multiple tenants - return tenant list for selection
+	tenantSelectionResp, err := uc.buildTenantSelectionResponse(ctx, memberships)
+	return nil, tenantSelectionResp, err
+}
+
+// createLoginSession creates a session plus refresh token and returns login response.
+// A nil family starts a new token family (fresh login); a non-nil one continues
+// an existing family during refresh token rotation and keeps its original auth time.
+// Members that the tenant's MFA policy requires to use MFA, and whose grace period is
+// over, only get a limited session that can enroll a second factor. Password logins
+// with an expired password only get a limited session that can change it.
+func (uc *AuthUseCase) createLoginSession(ctx context.Context, user *entity.User, membership *entity.Membership, client model.ClientInfo, family *session.TokenFamily) (*model.PhantomLoginResponse, error) {
+	tenant, tenantCtx, err := uc.buildTenantContext(ctx, membership)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.LoginMethod == model.LoginMethodPassword && family == nil {
+		expired, err := uc.passwordPolicy.IsExpired(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if expired {
+			response, err := uc.createRestrictedSession(ctx, user, tenantCtx, client, model.SessionRestrictionPasswordChange, model.ScopePasswordChange)
+			if err != nil {
+				return nil, err
+			}
+			response.PasswordChangeRequired = true
+			return response, nil
+		}
+	}
+
+	mfaStatus, err := uc.mfaUseCase.EvaluatePolicy(ctx, user.ID, tenant, tenantCtx.Roles[0], membership.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if mfaStatus != nil && mfaStatus.EnrollmentRequired {
+		response, err := uc.createRestrictedSession(ctx, user, tenantCtx, client, model.SessionRestrictionMFAEnrollment, model.ScopeMFAEnrollment)
+		if err != nil {
+			return nil, err
+		}
+		response.MFA = mfaStatus
+		return response, nil
+	}
+
+	var familyID string
+	var authTime int64
+	if family != nil {
+		familyID = family.ID
+		authTime = family.AuthTime
+	} else {
+		familyID, err = uc.sessionService.NewFamilyID()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Sessions of OpenID Connect clients carry the approved scopes instead of the tenant's
+	scope := tenantCtx.Scope
+	if client.ClientID != "" {
+		scope = client.Scope
+	}
 
 	// Create session value object
 	sessionValue := &model.SessionValue{
 		UserID:      user.ID,
 		UserUUID:    user.UUID,
-		TenantID:    tenant.ID,
-		TenantSlug:  tenant.Slug,
-		Roles:       []string{role.Name},
-		Permissions: permissions,
+		TenantID:    tenantCtx.Tenant.ID,
+		TenantSlug:  tenantCtx.Tenant.Slug,
+		Roles:       tenantCtx.Roles,
+		Permissions: tenantCtx.Permissions,
 		Scope:       scope,
 		Email:       user.Email,
 		Name:        user.Name,
+		UserAgent:   client.UserAgent,
+		ClientIP:    client.IPAddress,
+		Device:      utils.ParseDeviceLabel(client.UserAgent),
+		LoginMethod: client.LoginMethod,
+		AMR:         client.AMR,
+		ClientID:    client.ClientID,
+		FamilyID:    familyID,
+		AuthTime:    authTime,
 	}
 
-	// Generate reference token and store session in Redis
-	refToken, err := uc.sessionService.CreateSession(ctx, sessionValue)
+	// Generate reference token and store session in Redis under the tenant's session policy
+	refToken, err := uc.sessionService.CreateSession(ctx, sessionValue, uc.sessionService.ResolvePolicy(tenant))
+	if err == errors.ErrSessionLifetimeExceeded {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 
+	// Issue the refresh token that can later be exchanged for a new pair
+	refreshToken, err := uc.sessionService.CreateRefreshToken(ctx, &model.RefreshTokenValue{
+		FamilyID:    familyID,
+		UserID:      user.ID,
+		UserAgent:   client.UserAgent,
+		LoginMethod: client.LoginMethod,
+		AMR:         client.AMR,
+		Scope:       client.Scope,
+	}, refToken, sessionValue)
+	if err != nil {
+		_ = uc.sessionService.DeleteSession(ctx, refToken)
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
 	// Build response
+	now := time.Now().Unix()
 	response := &model.PhantomLoginResponse{
+		AccessToken:      refToken,
+		ExpiresIn:        int(sessionValue.ExpiresAt - now),
+		TokenType:        "Bearer",
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(min(sessionValue.MaxExpiresAt, now+int64(uc.sessionService.RefreshTTL().Seconds())) - now),
+		User: model.UserSessionInfo{
+			ID:    user.ID,
+			UUID:  user.UUID,
+			Email: user.Email,
+			Name:  user.Name,
+		},
+		Tenant: *tenantCtx.Tenant,
+		MFA:    mfaStatus,
+	}
+
+	return response, nil
+}
+
+// createRestrictedSession issues a short, non-refreshable session without roles or
+// permissions that only the endpoints allowing restriction accept
+func (uc *AuthUseCase) createRestrictedSession(ctx context.Context, user *entity.User, tenantCtx *model.SessionContext, client model.ClientInfo, restriction string, scope string) (*model.PhantomLoginResponse, error) {
+	sessionValue := &model.SessionValue{
+		UserID:      user.ID,
+		UserUUID:    user.UUID,
+		TenantID:    tenantCtx.Tenant.ID,
+		TenantSlug:  tenantCtx.Tenant.Slug,
+		Scope:       scope,
+		Email:       user.Email,
+		Name:        user.Name,
+		UserAgent:   client.UserAgent,
+		ClientIP:    client.IPAddress,
+		Device:      utils.ParseDeviceLabel(client.UserAgent),
+		LoginMethod: client.LoginMethod,
+		AMR:         client.AMR,
+		Restriction: restriction,
+	}
+
+	refToken, err := uc.sessionService.CreateSession(ctx, sessionValue, session.Policy{
+		IdleTimeout: restrictedSessionLifetime,
+		MaxLifetime: restrictedSessionLifetime,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return &model.PhantomLoginResponse{
 		AccessToken: refToken,
-		ExpiresIn:   int(uc.sessionTTL.Seconds()),
+		ExpiresIn:   int(sessionValue.ExpiresAt - time.Now().Unix()),
 		TokenType:   "Bearer",
 		User: model.UserSessionInfo{
 			ID:    user.ID,
@@ -187,14 +527,40 @@ func (uc *AuthUseCase) createLoginSession(ctx context.Context, user *entity.User
 			Email: user.Email,
 			Name:  user.Name,
 		},
-		Tenant: model.TenantInfo{
-			ID:     tenant.ID,
-			Name:   tenant.Name,
-			Slug:   tenant.Slug,
-		},
+		Tenant: *tenantCtx.Tenant,
+	}, nil
+}
+
+// buildTenantContext resolves the tenant, role, permissions and scope granted by a membership.
+// The tenant entity is returned as well so callers can apply its session policy.
+func (uc *AuthUseCase) buildTenantContext(ctx context.Context, membership *entity.Membership) (*entity.Tenant, *model.SessionContext, error) {
+	// Fetch tenant details
+	tenant, err := uc.tenantRepo.FindByID(ctx, membership.TenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch tenant: %w", err)
 	}
 
-	return response, nil
+	// Fetch role details
+	role, err := uc.tenantRoleRepo.FindByID(ctx, membership.RoleID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch role: %w", err)
+	}
+
+	permissions, err := rolePermissions(ctx, uc.permissionRepo, role.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tenant, &model.SessionContext{
+		Tenant: &model.TenantInfo{
+			ID:   tenant.ID,
+			Name: tenant.Name,
+			Slug: tenant.Slug,
+		},
+		Roles:       []string{role.Name},
+		Permissions: permissions,
+		Scope:       buildScope(permissions),
+	}, nil
 }
 
 // buildTenantSelectionResponse creates a response with available tenants
@@ -227,8 +593,41 @@ func (uc *AuthUseCase) buildTenantSelectionResponse(ctx context.Context, members
 	}, nil
 }
 
+// upgradePasswordHash rehashes a just-verified password when its stored hash uses an
+// outdated algorithm or parameters. Failures are only logged, the login goes on.
+func upgradePasswordHash(ctx context.Context, userRepo *repository.UserRepository, passwordService *security.PasswordService, user *entity.User, password string) {
+	if !passwordService.NeedsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := passwordService.HashPassword(password)
+	if err != nil {
+		log.Println("Failed to rehash password:", err)
+		return
+	}
+	if err := userRepo.UpdatePasswordHash(ctx, user.ID, hashedPassword); err != nil {
+		log.Println("Failed to rehash password:", err)
+		return
+	}
+	user.Password = hashedPassword
+}
+
+// rolePermissions returns the permissions granted by a role as resource:action strings
+func rolePermissions(ctx context.Context, permissionRepo *repository.PermissionRepository, roleID int64) ([]string, error) {
+	permissionEntities, err := permissionRepo.FindByRoleID(ctx, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch permissions: %w", err)
+	}
+
+	permissions := make([]string, 0, len(permissionEntities))
+	for _, perm := range permissionEntities {
+		permissions = append(permissions, fmt.Sprintf("%s:%s", perm.Resource, perm.Action))
+	}
+	return permissions, nil
+}
+
 // buildScope constructs OAuth-style scope string from permissions
-func (uc *AuthUseCase) buildScope(permissions []string) string {
+func buildScope(permissions []string) string {
 	if len(permissions) == 0 {
 		return "read:basic"
 	}
@@ -243,17 +642,466 @@ func (uc *AuthUseCase) buildScope(permissions []string) string {
 	return scope
 }
 
+// SwitchTenant moves an existing phantom session to another tenant the user belongs to.
+// The reference token stays the same; only the tenant context stored behind it changes.
+func (uc *AuthUseCase) SwitchTenant(ctx context.Context, refToken string, req *model.SwitchTenantRequest) (*model.PhantomLoginResponse, error) {
+	sessionValue, err := uc.portalSession(ctx, refToken)
+	if err != nil {
+		return nil, errors.ErrSessionNotFound
+	}
+
+	// A limited session must not gain roles by switching tenants
+	if sessionValue.Restriction == model.SessionRestrictionMFAEnrollment {
+		return nil, errors.ErrMFAEnrollmentRequired
+	}
+	if sessionValue.Restriction == model.SessionRestrictionPasswordChange {
+		return nil, errors.ErrPasswordExpired
+	}
+	// Impersonation is granted for one tenant only
+	if sessionValue.Impersonator != nil {
+		return nil, errors.ErrImpersonationNotAllowed
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, sessionValue.UserID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+
+	if !user.IsActive {
+		return nil, errors.ErrUserInactive
+	}
+
+	// Membership is re-validated against the database, not the session
+	membership, err := uc.membershipRepo.FindByUserAndTenant(ctx, user.ID, req.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("user does not have access to the specified tenant")
+	}
+
+	tenant, tenantCtx, err := uc.buildTenantContext(ctx, membership)
+	if err != nil {
+		return nil, err
+	}
+
+	// The target tenant may require MFA the user has not enrolled yet
+	mfaStatus, err := uc.mfaUseCase.EvaluatePolicy(ctx, user.ID, tenant, tenantCtx.Roles[0], membership.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if mfaStatus != nil && mfaStatus.EnrollmentRequired {
+		return nil, errors.ErrMFAEnrollmentRequired
+	}
+
+	// The new tenant's session policy may shorten the remaining lifetime
+	if err := uc.sessionService.UpdateSessionTenant(ctx, refToken, tenantCtx.Tenant.ID, tenantCtx.Tenant.Slug, tenantCtx.Roles, tenantCtx.Permissions, tenantCtx.Scope, uc.sessionService.ResolvePolicy(tenant)); err != nil {
+		if err == errors.ErrSessionLifetimeExceeded {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	updated, err := uc.sessionService.GetSession(ctx, refToken)
+	if err != nil {
+		return nil, errors.ErrSessionNotFound
+	}
+
+	return &model.PhantomLoginResponse{
+		AccessToken: refToken,
+		ExpiresIn:   int(updated.ExpiresAt - time.Now().Unix()),
+		TokenType:   "Bearer",
+		User: model.UserSessionInfo{
+			ID:    user.ID,
+			UUID:  user.UUID,
+			Email: user.Email,
+			Name:  user.Name,
+		},
+		Tenant: *tenantCtx.Tenant,
+		MFA:    mfaStatus,
+	}, nil
+}
+
 // Logout invalidates a user's session
 func (uc *AuthUseCase) Logout(ctx context.Context, refToken string) error {
 	return uc.sessionService.DeleteSession(ctx, refToken)
 }
 
-// RefreshSession extends the session TTL
-func (uc *AuthUseCase) RefreshSession(ctx context.Context, refToken string) error {
-	return uc.sessionService.RefreshSession(ctx, refToken)
+// RefreshSession exchanges a refresh token for a new access/refresh token pair.
+// The presented refresh token is consumed; replaying it later revokes the whole family.
+func (uc *AuthUseCase) RefreshSession(ctx context.Context, req *model.RefreshSessionRequest) (*model.PhantomLoginResponse, error) {
+	refreshValue, family, err := uc.sessionService.RotateRefreshToken(ctx, req.RefreshToken, req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Re-validate the user and membership so refreshes pick up deactivation and role changes.
+	// Failures that say nothing about the user give the refresh token back instead.
+	user, err := uc.userRepo.FindByID(ctx, family.UserID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		_ = uc.sessionService.RestoreRefreshToken(ctx, family)
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if err != nil || !user.IsActive {
+		_ = uc.sessionService.RevokeTokenFamily(ctx, family.ID)
+		return nil, errors.ErrUserInactive
+	}
+
+	membership, err := uc.membershipRepo.FindByUserAndTenant(ctx, user.ID, family.TenantID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		_ = uc.sessionService.RestoreRefreshToken(ctx, family)
+		return nil, fmt.Errorf("failed to fetch membership: %w", err)
+	}
+	if err != nil {
+		_ = uc.sessionService.RevokeTokenFamily(ctx, family.ID)
+		return nil, fmt.Errorf("user does not have access to the specified tenant")
+	}
+
+	// Rotation keeps the original auth time, so the absolute lifetime still applies
+	// The new session reflects where the refresh came from, but keeps how the user logged in
+	client := model.ClientInfo{
+		IPAddress:   req.ClientIP,
+		UserAgent:   req.UserAgent,
+		LoginMethod: refreshValue.LoginMethod,
+		AMR:         refreshValue.AMR,
+		ClientID:    family.ClientID,
+		Scope:       refreshValue.Scope,
+	}
+	if client.UserAgent == "" {
+		client.UserAgent = refreshValue.UserAgent
+	}
+
+	response, err := uc.createLoginSession(ctx, user, membership, client, family)
+	if err == errors.ErrSessionLifetimeExceeded {
+		_ = uc.sessionService.RevokeTokenFamily(ctx, family.ID)
+		return nil, err
+	}
+	if err != nil {
+		_ = uc.sessionService.RestoreRefreshToken(ctx, family)
+		return nil, err
+	}
+
+	// The grace period ran out since login: the limited session replaces the family
+	if response.MFA != nil && response.MFA.EnrollmentRequired {
+		_ = uc.sessionService.RevokeTokenFamily(ctx, family.ID)
+		return response, nil
+	}
+
+	// The previous access token is replaced by the new one
+	if family.AccessToken != "" {
+		_ = uc.sessionService.DeleteSession(ctx, family.AccessToken)
+	}
+
+	return response, nil
+}
+
+// CreateClientSession creates a session for a user who approved an OpenID Connect
+// client, in the tenant the approval was for. The session is bound to client.ClientID
+// and carries the approved scopes; the portal's own endpoints refuse it. Tenant
+// policies apply as on any login; a limited session is refused since the client
+// cannot complete enrollment or a password change for the user.
+func (uc *AuthUseCase) CreateClientSession(ctx context.Context, userID, tenantID int64, client model.ClientInfo) (*model.PhantomLoginResponse, error) {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil || !user.IsActive {
+		return nil, errors.ErrUserInactive
+	}
+
+	membership, err := uc.membershipRepo.FindByUserAndTenant(ctx, user.ID, tenantID)
+	if err != nil {
+		return nil, errors.ErrOAuthAccessDenied
+	}
+
+	response, err := uc.createLoginSession(ctx, user, membership, client, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.PasswordChangeRequired {
+		_ = uc.sessionService.DeleteSession(ctx, response.AccessToken)
+		return nil, errors.ErrPasswordExpired
+	}
+	if response.MFA != nil && response.MFA.EnrollmentRequired {
+		_ = uc.sessionService.DeleteSession(ctx, response.AccessToken)
+		return nil, errors.ErrMFAEnrollmentRequired
+	}
+
+	return response, nil
+}
+
+// portalSession returns the session of a reference token presented to the portal
+// itself. Sessions issued to OpenID Connect clients only act for the user towards ERP
+// services, so the portal treats them as unknown.
+func (uc *AuthUseCase) portalSession(ctx context.Context, refToken string) (*model.SessionValue, error) {
+	sessionValue, err := uc.sessionService.GetSession(ctx, refToken)
+	if err != nil {
+		return nil, err
+	}
+	if sessionValue.ClientID != "" {
+		return nil, errors.ErrSessionNotFound
+	}
+	return sessionValue, nil
 }
 
 // GetSessionContext retrieves the full session context
 func (uc *AuthUseCase) GetSessionContext(ctx context.Context, refToken string) (*model.SessionValue, error) {
 	return uc.sessionService.GetSession(ctx, refToken)
 }
+
+// ListSessions returns all active sessions of the user owning the given reference token
+func (uc *AuthUseCase) ListSessions(ctx context.Context, refToken string) ([]model.ActiveSessionInfo, error) {
+	current, err := uc.portalSession(ctx, refToken)
+	if err != nil {
+		return nil, errors.ErrSessionNotFound
+	}
+
+	sessions, err := uc.sessionService.GetUserSessionDetails(ctx, current.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.ActiveSessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, model.ActiveSessionInfo{
+			SessionID:   session.SessionID(s.RefToken),
+			TenantID:    s.Value.TenantID,
+			TenantSlug:  s.Value.TenantSlug,
+			Device:      s.Value.Device,
+			UserAgent:   s.Value.UserAgent,
+			IPAddress:   s.Value.ClientIP,
+			LoginMethod: s.Value.LoginMethod,
+			IssuedAt:    s.Value.IssuedAt,
+			LastSeenAt:  s.Value.LastSeenAt,
+			ExpiresAt:   s.Value.ExpiresAt,
+			Current:     s.RefToken == refToken,
+
+			ImpersonatedBy: s.Value.Impersonator,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].IssuedAt > result[j].IssuedAt
+	})
+
+	return result, nil
+}
+
+// RevokeSession terminates one of the caller's own sessions by its session ID
+func (uc *AuthUseCase) RevokeSession(ctx context.Context, refToken string, sessionID string) error {
+	current, err := uc.portalSession(ctx, refToken)
+	if err != nil {
+		return errors.ErrSessionNotFound
+	}
+
+	// An admin acting as the user must not log them out of their own devices
+	if current.Impersonator != nil {
+		return errors.ErrImpersonationNotAllowed
+	}
+
+	sessions, err := uc.sessionService.GetAllUserSessions(ctx, current.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range sessions {
+		if session.SessionID(token) == sessionID {
+			return uc.sessionService.DeleteSession(ctx, token)
+		}
+	}
+
+	return errors.ErrSessionNotFound
+}
+
+// RevokeAllSessions terminates all of the caller's sessions, optionally keeping the current one
+func (uc *AuthUseCase) RevokeAllSessions(ctx context.Context, refToken string, keepCurrent bool) error {
+	current, err := uc.portalSession(ctx, refToken)
+	if err != nil {
+		return errors.ErrSessionNotFound
+	}
+
+	// An admin acting as the user must not log them out of their own devices
+	if current.Impersonator != nil {
+		return errors.ErrImpersonationNotAllowed
+	}
+
+	if !keepCurrent {
+		return uc.sessionService.DeleteAllUserSessions(ctx, current.UserID)
+	}
+
+	sessions, err := uc.sessionService.GetAllUserSessions(ctx, current.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range sessions {
+		if token == refToken {
+			continue
+		}
+		if err := uc.sessionService.DeleteSession(ctx, token); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Impersonate creates a session acting as another user in one of their tenants, for
+// platform admins reproducing what the user sees. The session carries the admin as
+// impersonator, lives for the short impersonation TTL, cannot be refreshed and is
+// recorded in the audit trail the user can read.
+func (uc *AuthUseCase) Impersonate(ctx context.Context, impersonatorID int64, req *model.ImpersonateRequest) (*model.PhantomLoginResponse, error) {
+	impersonator, err := uc.userRepo.FindByID(ctx, impersonatorID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+
+	isAdmin, err := isPlatformAdmin(ctx, uc.tenantRepo, uc.membershipRepo, uc.tenantRoleRepo, impersonator.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, errors.ErrNotPlatformAdmin
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, req.UserID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	if !user.IsActive {
+		return nil, errors.ErrUserInactive
+	}
+
+	// Admins cannot borrow each other's identity
+	if user.ID == impersonator.ID {
+		return nil, errors.ErrCannotImpersonate
+	}
+	targetIsAdmin, err := isPlatformAdmin(ctx, uc.tenantRepo, uc.membershipRepo, uc.tenantRoleRepo, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if targetIsAdmin {
+		return nil, errors.ErrCannotImpersonate
+	}
+
+	membership, err := uc.membershipRepo.FindByUserAndTenant(ctx, user.ID, req.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("user does not have access to the specified tenant")
+	}
+
+	_, tenantCtx, err := uc.buildTenantContext(ctx, membership)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionValue := &model.SessionValue{
+		UserID:      user.ID,
+		UserUUID:    user.UUID,
+		TenantID:    tenantCtx.Tenant.ID,
+		TenantSlug:  tenantCtx.Tenant.Slug,
+		Roles:       tenantCtx.Roles,
+		Permissions: tenantCtx.Permissions,
+		Scope:       tenantCtx.Scope,
+		Email:       user.Email,
+		Name:        user.Name,
+		UserAgent:   req.UserAgent,
+		ClientIP:    req.ClientIP,
+		Device:      utils.ParseDeviceLabel(req.UserAgent),
+		LoginMethod: model.LoginMethodImpersonation,
+		Impersonator: &model.Impersonator{
+			UserID: impersonator.ID,
+			Email:  impersonator.Email,
+			Name:   impersonator.Name,
+		},
+	}
+
+	refToken, err := uc.sessionService.CreateSession(ctx, sessionValue, uc.sessionService.ImpersonationPolicy())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	// No audit record, no session
+	if _, err := uc.impersonationRepo.Create(ctx, &entity.ImpersonationLog{
+		ImpersonatorID: impersonator.ID,
+		UserID:         user.ID,
+		TenantID:       tenantCtx.Tenant.ID,
+		Reason:         req.Reason,
+		SessionID:      session.SessionID(refToken),
+		IPAddress:      req.ClientIP,
+		UserAgent:      req.UserAgent,
+		ExpiresAt:      time.Unix(sessionValue.MaxExpiresAt, 0),
+	}); err != nil {
+		_ = uc.sessionService.DeleteSession(ctx, refToken)
+		return nil, fmt.Errorf("failed to record impersonation: %w", err)
+	}
+	log.Printf("User %d started impersonating user %d in tenant %d: %s", impersonator.ID, user.ID, tenantCtx.Tenant.ID, req.Reason)
+
+	return &model.PhantomLoginResponse{
+		AccessToken: refToken,
+		ExpiresIn:   int(sessionValue.ExpiresAt - time.Now().Unix()),
+		TokenType:   "Bearer",
+		User: model.UserSessionInfo{
+			ID:    user.ID,
+			UUID:  user.UUID,
+			Email: user.Email,
+			Name:  user.Name,
+		},
+		Tenant:       *tenantCtx.Tenant,
+		Impersonator: sessionValue.Impersonator,
+	}, nil
+}
+
+// ListImpersonations returns the audit trail of admins who acted as the user, newest first
+func (uc *AuthUseCase) ListImpersonations(ctx context.Context, userID int64, page, pageSize int) (*model.PaginationResponse[model.ImpersonationLogResponse], error) {
+	logs, total, err := uc.impersonationRepo.FindByUserID(ctx, userID, pageSize, model.Offset(page, pageSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch impersonations: %w", err)
+	}
+
+	impersonators := make(map[int64]model.Impersonator)
+	result := make([]model.ImpersonationLogResponse, 0, len(logs))
+	for _, l := range logs {
+		impersonator, ok := impersonators[l.ImpersonatorID]
+		if !ok {
+			impersonator = model.Impersonator{UserID: l.ImpersonatorID}
+			if admin, err := uc.userRepo.FindByID(ctx, l.ImpersonatorID); err == nil {
+				impersonator.Email = admin.Email
+				impersonator.Name = admin.Name
+			}
+			impersonators[l.ImpersonatorID] = impersonator
+		}
+
+		result = append(result, model.ImpersonationLogResponse{
+			ID:           l.ID,
+			Impersonator: impersonator,
+			TenantID:     l.TenantID,
+			Reason:       l.Reason,
+			SessionID:    l.SessionID,
+			IPAddress:    l.IPAddress,
+			StartedAt:    l.CreatedAt,
+			ExpiresAt:    l.ExpiresAt,
+		})
+	}
+
+	return model.NewPaginationResponse(result, page, pageSize, int(total)), nil
+}
+
+// isPlatformAdmin reports whether the user holds the platform admin role in the system tenant
+func isPlatformAdmin(
+	ctx context.Context,
+	tenantRepo *repository.TenantRepository,
+	membershipRepo *repository.MembershipRepository,
+	tenantRoleRepo *repository.TenantRoleRepository,
+	userID int64,
+) (bool, error) {
+	systemTenant, err := tenantRepo.FindBySlug(ctx, entity.SystemTenantSlug)
+	if err != nil {
+		return false, nil // Without a system tenant nobody administers the platform
+	}
+
+	membership, err := membershipRepo.FindByUserAndTenant(ctx, userID, systemTenant.ID)
+	if err != nil {
+		return false, nil
+	}
+
+	role, err := tenantRoleRepo.FindByID(ctx, membership.RoleID)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch role: %w", err)
+	}
+	return role.Name == entity.PlatformAdminRoleName, nil
+}
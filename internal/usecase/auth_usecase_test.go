@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/repository"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+func TestAuthOAuthLoginNonce(t *testing.T) {
+	ctx := context.Background()
+	const loginNonce = "5f2c9a0d7e8b41c3a6f0e1d2c3b4a596"
+
+	tests := []struct {
+		name       string
+		nonceHash  string
+		loginNonce string
+		want       error
+	}{
+		// Past the nonce check the login fails on the (missing) user instead
+		{"nonce of the browser that started the login", hashLoginNonce(loginNonce), loginNonce, errors.ErrUserNotFound},
+		{"other nonce", hashLoginNonce(loginNonce), "0000000000000000000000000000000", errors.ErrOAuthLoginCodeInvalid},
+		{"code without a nonce", "", loginNonce, errors.ErrOAuthLoginCodeInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newTestDB(t)
+			sessionService, _ := newTestSessionService(t)
+			uc := NewAuthUseCase(repository.NewUserRepository(db), nil, nil, nil, nil, nil, sessionService, nil, nil, nil, nil, nil, &config.OAuthConfig{})
+
+			code, err := sessionService.CreateOAuthLogin(ctx, &model.OAuthLoginValue{UserID: 1, Provider: "google", LoginNonceHash: tt.nonceHash}, time.Minute)
+			if err != nil {
+				t.Fatalf("CreateOAuthLogin() error = %v", err)
+			}
+			if tt.want == errors.ErrUserNotFound {
+				mock.ExpectQuery(`FROM "users"`).WillReturnError(gorm.ErrRecordNotFound)
+			}
+
+			req := &model.OAuthExchangeRequest{Code: code, LoginNonce: tt.loginNonce}
+			if _, _, _, err := uc.OAuthLogin(ctx, req); err != tt.want {
+				t.Fatalf("OAuthLogin() error = %v, want %v", err, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+
+			// The code is spent either way; a wrong nonce can't be retried
+			req.LoginNonce = loginNonce
+			if _, _, _, err := uc.OAuthLogin(ctx, req); err != errors.ErrOAuthLoginCodeInvalid {
+				t.Errorf("OAuthLogin() of a used code error = %v, want %v", err, errors.ErrOAuthLoginCodeInvalid)
+			}
+		})
+	}
+}
@@ -2,44 +2,84 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
 	"fmt"
+	"go-gin-clean/internal/gateway/cache"
+	"go-gin-clean/internal/gateway/security"
 	"go-gin-clean/internal/gateway/session"
 	"go-gin-clean/internal/model"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+	"log"
 	"strings"
+	"time"
 )
 
 type IntrospectionUseCase struct {
-	sessionService *session.SessionService
+	sessionService  *session.SessionService
+	apiTokenUseCase *APITokenUseCase
+	jwtService      *security.JWTService
+	config          *config.IntrospectionConfig
+	cache           *cache.LocalCache[*introspectedToken] // nil when the local cache is disabled
 }
 
-func NewIntrospectionUseCase(sessionService *session.SessionService) *IntrospectionUseCase {
-	return &IntrospectionUseCase{
-		sessionService: sessionService,
+// introspectedToken is a resolved token as the local cache keeps it. The session JWT
+// is minted once per entry, so cache hits don't sign a new one.
+type introspectedToken struct {
+	session      *model.SessionValue
+	sessionToken string // Signed session JWT, when enabled
+}
+
+func NewIntrospectionUseCase(sessionService *session.SessionService, apiTokenUseCase *APITokenUseCase, jwtService *security.JWTService, cfg *config.IntrospectionConfig) *IntrospectionUseCase {
+	uc := &IntrospectionUseCase{
+		sessionService:  sessionService,
+		apiTokenUseCase: apiTokenUseCase,
+		jwtService:      jwtService,
+		config:          cfg,
+	}
+	if cfg.CacheEnabled {
+		uc.cache = cache.NewLocalCache[*introspectedToken](cfg.CacheSize, cfg.CacheTTL)
+	}
+	return uc
+}
+
+// StartCacheInvalidation drops cached sessions and API tokens as other instances
+// change, delete or revoke them, until ctx is done. It is a no-op when the local
+// cache is disabled.
+func (uc *IntrospectionUseCase) StartCacheInvalidation(ctx context.Context) {
+	if uc.cache == nil {
+		return
+	}
+
+	for cacheKey := range uc.sessionService.SubscribeInvalidations(ctx) {
+		uc.cache.Delete(cacheKey)
 	}
 }
 
-// IntrospectToken validates a reference token and returns session context for Kong
+// CacheStats reports the local cache's size and hit rate (nil when disabled)
+func (uc *IntrospectionUseCase) CacheStats() *cache.LocalCacheStats {
+	if uc.cache == nil {
+		return nil
+	}
+	stats := uc.cache.Stats()
+	return &stats
+}
+
+// IntrospectToken validates a reference token or API token and returns session context for Kong
 // This is called by Kong's auth-request plugin to validate the phantom token
 func (uc *IntrospectionUseCase) IntrospectToken(ctx context.Context, token string) (*model.IntrospectionResponse, error) {
 	// Remove "Bearer " prefix if present
 	token = strings.TrimPrefix(token, "Bearer ")
 	token = strings.TrimSpace(token)
 
-	// Validate token format (ref_<64 hex chars>)
-	if !strings.HasPrefix(token, "ref_") || len(token) != 68 {
-		return &model.IntrospectionResponse{
-			Active: false,
-		}, nil
-	}
-
-	// Retrieve session from Redis
-	sessionValue, err := uc.sessionService.GetSession(ctx, token)
-	if err != nil {
-		// Session not found or expired
+	introspected, ok := uc.lookupSession(ctx, token)
+	if !ok {
 		return &model.IntrospectionResponse{
 			Active: false,
 		}, nil
 	}
+	sessionValue := introspected.session
 
 	// Session is valid, return context
 	// Note: Roles is an array, we'll use the first role if available
@@ -48,16 +88,223 @@ func (uc *IntrospectionUseCase) IntrospectToken(ctx context.Context, token strin
 		roleName = sessionValue.Roles[0]
 	}
 
-	return &model.IntrospectionResponse{
+	resp := &model.IntrospectionResponse{
 		Active:      true,
-		Sub:         fmt.Sprintf("user_%d", sessionValue.UserID),
+		Sub:         sessionSubject(sessionValue),
 		TenantID:    sessionValue.TenantID,
 		UserID:      sessionValue.UserID,
 		RoleID:      0, // We don't store role ID in session, only names
 		RoleName:    roleName,
 		Permissions: sessionValue.Permissions,
 		Exp:         sessionValue.ExpiresAt,
-	}, nil
+		Restriction: sessionValue.Restriction,
+	}
+	if sessionValue.Impersonator != nil {
+		resp.ImpersonatedBy = sessionValue.Impersonator.UserID
+	}
+	resp.APITokenID = sessionValue.APITokenID
+	resp.ClientID = sessionValue.ClientID
+
+	// Signed JWT of the session that upstream services verify offline, when enabled
+	resp.Token = introspected.sessionToken
+
+	return resp, nil
+}
+
+// IssueSessionToken mints a short-lived signed JWT holding the session context.
+// It never outlives the session it was minted from.
+func (uc *IntrospectionUseCase) IssueSessionToken(refToken string, sessionValue *model.SessionValue) (string, time.Time, error) {
+	var actor string
+	if sessionValue.Impersonator != nil {
+		actor = fmt.Sprintf("user_%d", sessionValue.Impersonator.UserID)
+	}
+
+	return uc.jwtService.GenerateSessionToken(&model.SessionTokenClaims{
+		SessionID:   session.SessionID(refToken),
+		UserID:      sessionValue.UserID,
+		UserUUID:    sessionValue.UserUUID,
+		Email:       sessionValue.Email,
+		TenantID:    sessionValue.TenantID,
+		TenantSlug:  sessionValue.TenantSlug,
+		Roles:       sessionValue.Roles,
+		Permissions: sessionValue.Permissions,
+		Scope:       sessionValue.Scope,
+		ExpiresAt:   time.Unix(sessionValue.ExpiresAt, 0),
+		Subject:     sessionSubject(sessionValue),
+		Actor:       actor,
+		ClientID:    sessionValue.ClientID,
+		Restriction: sessionValue.Restriction,
+	})
+}
+
+// lookupSession resolves a reference token to its live session and records the activity.
+// API tokens and client access tokens resolve to an equivalent context, so Kong
+// treats them like sessions. It reports false for malformed, unknown and expired tokens.
+func (uc *IntrospectionUseCase) lookupSession(ctx context.Context, token string) (*introspectedToken, bool) {
+	// Validate token format (ref_, pat_ or cct_ followed by 64 hex chars)
+	isAPIToken := strings.HasPrefix(token, model.APITokenPrefix)
+	isClientToken := strings.HasPrefix(token, model.ClientTokenPrefix)
+	if !(strings.HasPrefix(token, "ref_") || isAPIToken || isClientToken) || len(token) != 68 {
+		return nil, false
+	}
+
+	// API tokens are cached under their stored hash, which is what a revocation
+	// broadcasts
+	cacheKey := token
+	if isAPIToken {
+		cacheKey = hashAPIToken(token)
+	}
+
+	// Hot tokens are served from memory; the entry's short TTL bounds how long the
+	// idle expiry and last-seen time can lag behind
+	if uc.cache != nil {
+		if cached, ok := uc.cache.Get(cacheKey); ok && time.Now().Unix() <= cached.session.ExpiresAt {
+			return cached, true
+		}
+	}
+
+	if isAPIToken {
+		sessionValue, err := uc.apiTokenUseCase.Resolve(ctx, token)
+		if err != nil {
+			return nil, false
+		}
+		return uc.remember(token, cacheKey, sessionValue)
+	}
+
+	if isClientToken {
+		clientToken, err := uc.sessionService.GetClientToken(ctx, token)
+		if err != nil {
+			return nil, false
+		}
+		return uc.remember(token, cacheKey, clientSessionValue(clientToken))
+	}
+
+	// Retrieve session from Redis
+	sessionValue, err := uc.sessionService.GetSession(ctx, token)
+	if err != nil {
+		// Session not found or expired
+		return nil, false
+	}
+
+	// Slide the idle expiry once less than half of the idle window is left,
+	// so busy sessions don't rewrite Redis on every request
+	if sessionValue.ExpiresAt-time.Now().Unix() < sessionValue.IdleTimeout/2 {
+		if err := uc.sessionService.TouchSession(ctx, token, sessionValue); err == errors.ErrSessionLifetimeExceeded {
+			return nil, false
+		}
+	}
+
+	// Last-seen tracking is best effort and must never fail introspection
+	_ = uc.sessionService.MarkSeen(ctx, token, sessionValue)
+
+	return uc.remember(token, cacheKey, sessionValue)
+}
+
+// remember mints the session JWT of a resolved token, when enabled, and caches both
+// under cacheKey
+func (uc *IntrospectionUseCase) remember(token string, cacheKey string, sessionValue *model.SessionValue) (*introspectedToken, bool) {
+	introspected := &introspectedToken{session: sessionValue}
+
+	if uc.config.IssueJWT {
+		sessionToken, _, err := uc.IssueSessionToken(token, sessionValue)
+		if err != nil {
+			log.Printf("Failed to issue session token: %v", err)
+			return nil, false
+		}
+		introspected.sessionToken = sessionToken
+	}
+
+	if uc.cache != nil {
+		uc.cache.Set(cacheKey, introspected)
+	}
+	return introspected, true
+}
+
+// AuthenticateClient verifies the credentials of a resource server calling the
+// RFC 7662 introspection endpoint
+func (uc *IntrospectionUseCase) AuthenticateClient(clientID string, clientSecret string) bool {
+	expected, ok := uc.config.Clients[clientID]
+	if !ok || clientSecret == "" {
+		return false
+	}
+
+	// Compare digests so the comparison time doesn't depend on the secret length
+	a := sha256.Sum256([]byte(clientSecret))
+	b := sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
+
+// Introspect implements RFC 7662 token introspection for access (reference) and
+// refresh tokens. The hint only decides which token type is looked up first.
+func (uc *IntrospectionUseCase) Introspect(ctx context.Context, req *model.TokenIntrospectionRequest) *model.TokenIntrospectionResponse {
+	token := strings.TrimSpace(req.Token)
+
+	lookups := []func(context.Context, string) *model.TokenIntrospectionResponse{
+		uc.introspectAccessToken,
+		uc.introspectRefreshToken,
+	}
+	if req.TokenTypeHint == model.TokenTypeHintRefreshToken {
+		lookups[0], lookups[1] = lookups[1], lookups[0]
+	}
+
+	for _, lookup := range lookups {
+		if resp := lookup(ctx, token); resp != nil {
+			return resp
+		}
+	}
+
+	return &model.TokenIntrospectionResponse{Active: false}
+}
+
+func (uc *IntrospectionUseCase) introspectAccessToken(ctx context.Context, token string) *model.TokenIntrospectionResponse {
+	introspected, ok := uc.lookupSession(ctx, token)
+	if !ok {
+		return nil
+	}
+	sessionValue := introspected.session
+
+	resp := &model.TokenIntrospectionResponse{
+		Active:      true,
+		Scope:       sessionValue.Scope,
+		ClientID:    sessionValue.ClientID,
+		Username:    sessionValue.Email,
+		TokenType:   "Bearer",
+		Exp:         sessionValue.ExpiresAt,
+		Iat:         sessionValue.IssuedAt,
+		Sub:         sessionSubject(sessionValue),
+		Iss:         uc.config.Issuer,
+		TenantID:    sessionValue.TenantID,
+		TenantSlug:  sessionValue.TenantSlug,
+		UserID:      sessionValue.UserID,
+		Roles:       sessionValue.Roles,
+		Permissions: sessionValue.Permissions,
+		Restriction: sessionValue.Restriction,
+	}
+	if sessionValue.Impersonator != nil {
+		resp.Act = &model.ActorClaim{Sub: fmt.Sprintf("user_%d", sessionValue.Impersonator.UserID)}
+	}
+	return resp
+}
+
+func (uc *IntrospectionUseCase) introspectRefreshToken(ctx context.Context, token string) *model.TokenIntrospectionResponse {
+	if !strings.HasPrefix(token, "rt_") {
+		return nil
+	}
+
+	value, family, err := uc.sessionService.GetRefreshToken(ctx, token)
+	if err != nil {
+		return nil
+	}
+
+	return &model.TokenIntrospectionResponse{
+		Active:   true,
+		Exp:      value.ExpiresAt,
+		Iat:      value.IssuedAt,
+		Sub:      fmt.Sprintf("user_%d", family.UserID),
+		Iss:      uc.config.Issuer,
+		TenantID: family.TenantID,
+		UserID:   family.UserID,
+	}
 }
 
 // GetHeadersForUpstream generates the headers that Kong should inject
@@ -66,7 +313,7 @@ func (uc *IntrospectionUseCase) GetHeadersForUpstream(resp *model.IntrospectionR
 		return nil
 	}
 
-	return map[string]string{
+	headers := map[string]string{
 		"X-Tenant-ID":    fmt.Sprintf("%d", resp.TenantID),
 		"X-User-ID":      fmt.Sprintf("%d", resp.UserID),
 		"X-Role-ID":      fmt.Sprintf("%d", resp.RoleID),
@@ -74,4 +321,48 @@ func (uc *IntrospectionUseCase) GetHeadersForUpstream(resp *model.IntrospectionR
 		"X-Permissions":  strings.Join(resp.Permissions, ","),
 		"X-Authenticated": "true",
 	}
+	if resp.Token != "" {
+		// Kong forwards this as the upstream Authorization header in place of the phantom token
+		headers["Authorization"] = "Bearer " + resp.Token
+	}
+	if resp.Restriction != "" {
+		headers["X-Session-Restriction"] = resp.Restriction
+	}
+	if resp.ImpersonatedBy != 0 {
+		headers["X-Impersonated-By"] = fmt.Sprintf("%d", resp.ImpersonatedBy)
+	}
+	if resp.APITokenID != 0 {
+		headers["X-API-Token-ID"] = fmt.Sprintf("%d", resp.APITokenID)
+	}
+	if resp.ClientID != "" {
+		headers["X-Client-ID"] = resp.ClientID
+	}
+
+	return headers
+}
+
+// sessionSubject identifies who a session acts for: the OAuth client for client
+// credentials tokens, the user otherwise
+func sessionSubject(sessionValue *model.SessionValue) string {
+	if sessionValue.ClientID != "" && sessionValue.UserID == 0 {
+		return sessionValue.ClientID
+	}
+	return fmt.Sprintf("user_%d", sessionValue.UserID)
+}
+
+// clientSessionValue shapes a client access token like a session: no user and no
+// role, the granted scopes as permissions
+func clientSessionValue(clientToken *model.ClientTokenValue) *model.SessionValue {
+	permissions := strings.Fields(clientToken.Scope)
+	return &model.SessionValue{
+		TenantID:     clientToken.TenantID,
+		Roles:        []string{},
+		Permissions:  permissions,
+		Scope:        clientToken.Scope,
+		LoginMethod:  model.LoginMethodClientCredentials,
+		ClientID:     clientToken.ClientID,
+		IssuedAt:     clientToken.IssuedAt,
+		ExpiresAt:    clientToken.ExpiresAt,
+		MaxExpiresAt: clientToken.ExpiresAt,
+	}
 }
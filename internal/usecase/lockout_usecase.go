@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-gin-clean/internal/gateway/cache"
+	"go-gin-clean/internal/gateway/messaging"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/repository"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+)
+
+// LockoutUseCase protects the password endpoints against guessing. Callers check it
+// before verifying a password and report the outcome afterwards.
+type LockoutUseCase struct {
+	userRepo      *repository.UserRepository
+	loginGuard    *cache.LoginGuard
+	userPublisher *messaging.UserPublisher
+	cfg           *config.LockoutConfig
+}
+
+func NewLockoutUseCase(
+	userRepo *repository.UserRepository,
+	loginGuard *cache.LoginGuard,
+	userPublisher *messaging.UserPublisher,
+	cfg *config.LockoutConfig,
+) *LockoutUseCase {
+	return &LockoutUseCase{
+		userRepo:      userRepo,
+		loginGuard:    loginGuard,
+		userPublisher: userPublisher,
+		cfg:           cfg,
+	}
+}
+
+// Check refuses the attempt while the account or source IP is locked, or while the
+// account is still waiting out the delay after its last failure
+func (uc *LockoutUseCase) Check(ctx context.Context, email string, clientIP string) error {
+	block, err := uc.loginGuard.Check(ctx, email, clientIP)
+	if err != nil {
+		return err
+	}
+	if block == nil {
+		return nil
+	}
+	if block.Locked {
+		return errors.ErrAccountLocked
+	}
+	return errors.ErrTooManyLoginAttempts
+}
+
+// RecordFailure counts a wrong password. When it locks the account, a lockout event
+// is published for accounts that exist. Errors are only logged so the caller can still
+// answer with invalid credentials.
+func (uc *LockoutUseCase) RecordFailure(ctx context.Context, email string, clientIP string) {
+	failure, err := uc.loginGuard.RecordFailure(ctx, email, clientIP)
+	if err != nil {
+		log.Println("Failed to record login failure:", err)
+		return
+	}
+
+	if failure.IPLocked {
+		log.Printf("Login locked for IP %s after repeated failures", clientIP)
+	}
+	if !failure.AccountLocked {
+		return
+	}
+
+	user, err := uc.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return
+	}
+
+	message := model.AccountLockedEvent{
+		UserEvent: model.UserEvent{
+			UserPKID: user.ID,
+			Name:     user.Name,
+		},
+		Email:          user.Email,
+		IPAddress:      clientIP,
+		FailedAttempts: failure.AccountFailures,
+		LockedUntil:    time.Now().Add(uc.cfg.LockoutDuration).Unix(),
+	}
+
+	go func() {
+		if err := uc.userPublisher.AccountLockedEventPublish(message); err != nil {
+			log.Println("Failed to publish user account locked event:", err)
+		}
+	}()
+}
+
+// RecordSuccess clears the account's failures after a correct password
+func (uc *LockoutUseCase) RecordSuccess(ctx context.Context, email string) {
+	if err := uc.loginGuard.RecordSuccess(ctx, email); err != nil {
+		log.Println("Failed to reset login failures:", err)
+	}
+}
+
+// Unlock lifts a lock before it expires on its own
+func (uc *LockoutUseCase) Unlock(ctx context.Context, email string) error {
+	unlocked, err := uc.loginGuard.Unlock(ctx, email)
+	if err != nil {
+		return err
+	}
+	if !unlocked {
+		return errors.ErrAccountNotLocked
+	}
+	return nil
+}
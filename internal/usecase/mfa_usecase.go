@@ -0,0 +1,480 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-gin-clean/internal/entity"
+	"go-gin-clean/internal/gateway/security"
+	"go-gin-clean/internal/gateway/session"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/repository"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type MFAUseCase struct {
+	userRepo        *repository.UserRepository
+	mfaRepo         *repository.MFARepository
+	totpService     *security.TOTPService
+	passkeyUseCase  *PasskeyUseCase
+	aesService      *security.AESService
+	passwordService *security.PasswordService
+	sessionService  *session.SessionService
+	lockoutUseCase  *LockoutUseCase
+	cfg             *config.MFAConfig
+}
+
+func NewMFAUseCase(
+	userRepo *repository.UserRepository,
+	mfaRepo *repository.MFARepository,
+	totpService *security.TOTPService,
+	passkeyUseCase *PasskeyUseCase,
+	aesService *security.AESService,
+	passwordService *security.PasswordService,
+	sessionService *session.SessionService,
+	lockoutUseCase *LockoutUseCase,
+	cfg *config.MFAConfig,
+) *MFAUseCase {
+	return &MFAUseCase{
+		userRepo:        userRepo,
+		mfaRepo:         mfaRepo,
+		totpService:     totpService,
+		passkeyUseCase:  passkeyUseCase,
+		aesService:      aesService,
+		passwordService: passwordService,
+		sessionService:  sessionService,
+		lockoutUseCase:  lockoutUseCase,
+		cfg:             cfg,
+	}
+}
+
+// GetStatus reports which second factors the user has set up
+func (uc *MFAUseCase) GetStatus(ctx context.Context, userID int64) (*model.MFAStatusResponse, error) {
+	passkeys, err := uc.passkeyUseCase.CountPasskeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &model.MFAStatusResponse{Passkeys: passkeys}
+
+	mfa, err := uc.findEnabled(ctx, userID)
+	if err == errors.ErrMFANotEnabled {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status.TOTPEnabled = true
+	if mfa.TOTPConfirmedAt != nil {
+		confirmedAt := mfa.TOTPConfirmedAt.Unix()
+		status.TOTPConfirmedAt = &confirmedAt
+	}
+
+	status.RecoveryCodesRemaining, err = uc.mfaRepo.CountUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return status, nil
+}
+
+// IsEnabled reports whether logins of the user require a second factor, which is the
+// case once TOTP is enabled or a passkey is registered
+func (uc *MFAUseCase) IsEnabled(ctx context.Context, userID int64) (bool, error) {
+	enabled, err := uc.mfaRepo.HasSecondFactor(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load MFA settings: %w", err)
+	}
+	return enabled, nil
+}
+
+// EvaluatePolicy applies the tenant's MFA policy to a member with the given role who
+// joined at joinedAt. It returns nil when the tenant does not use an MFA policy.
+func (uc *MFAUseCase) EvaluatePolicy(ctx context.Context, userID int64, tenant *entity.Tenant, roleName string, joinedAt time.Time) (*model.MFAPolicyStatus, error) {
+	policy := tenant.GetConfig().MFA
+	if policy == nil || policy.Enforcement == "" || policy.Enforcement == entity.MFAEnforcementOff {
+		return nil, nil
+	}
+
+	enrolled, err := uc.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.MFAPolicyStatus{
+		Enforcement: policy.Enforcement,
+		Required:    policy.RequiresMFA(roleName),
+		Enrolled:    enrolled,
+	}
+	if status.Required && !enrolled {
+		deadline := policy.EnrollmentDeadline(joinedAt)
+		status.Deadline = deadline.Unix()
+		status.EnrollmentRequired = !time.Now().Before(deadline)
+	}
+
+	return status, nil
+}
+
+// BeginTOTPEnrollment generates a new secret for the user. It only protects logins
+// after ConfirmTOTPEnrollment proves the authenticator app produces valid codes.
+func (uc *MFAUseCase) BeginTOTPEnrollment(ctx context.Context, userID int64) (*model.TOTPEnrollmentResponse, error) {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+
+	if _, err := uc.findEnabled(ctx, userID); err == nil {
+		return nil, errors.ErrMFAAlreadyEnabled
+	} else if err != errors.ErrMFANotEnabled {
+		return nil, err
+	}
+
+	secret, err := uc.totpService.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedSecret, err := uc.aesService.EncryptInternal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+
+	if err := uc.mfaRepo.SavePendingSecret(ctx, userID, encryptedSecret); err != nil {
+		return nil, fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+
+	return &model.TOTPEnrollmentResponse{
+		Secret:      secret,
+		OTPAuthURI:  uc.totpService.ProvisioningURI(user.Email, secret),
+		Issuer:      uc.totpService.Issuer(),
+		AccountName: user.Email,
+		Digits:      uc.totpService.Digits(),
+		Period:      uc.totpService.Period(),
+	}, nil
+}
+
+// ConfirmTOTPEnrollment enables TOTP once the user enters a valid code from the
+// pending secret, and returns the initial set of recovery codes
+func (uc *MFAUseCase) ConfirmTOTPEnrollment(ctx context.Context, userID int64, code string) (*model.RecoveryCodesResponse, error) {
+	mfa, err := uc.mfaRepo.FindByUserID(ctx, userID)
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.ErrMFANotEnrolling
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load MFA settings: %w", err)
+	}
+	if mfa.TOTPEnabled {
+		return nil, errors.ErrMFAAlreadyEnabled
+	}
+
+	step, ok, err := uc.validateTOTP(mfa, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.ErrMFACodeInvalid
+	}
+
+	codes, hashes, err := uc.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.mfaRepo.EnableTOTP(ctx, userID, step, hashes); err == gorm.ErrRecordNotFound {
+		return nil, errors.ErrMFAAlreadyEnabled
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+
+	return &model.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP turns off the second factor after re-checking the password and a current code
+func (uc *MFAUseCase) DisableTOTP(ctx context.Context, userID int64, req *model.DisableTOTPRequest) error {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.ErrUserNotFound
+	}
+
+	if err := uc.checkPassword(ctx, user, req.Password); err != nil {
+		return err
+	}
+
+	if _, err := uc.verifyAccountCode(ctx, user, req.Code); err != nil {
+		return err
+	}
+
+	if err := uc.mfaRepo.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to disable TOTP: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after re-checking the password
+// and a current code
+func (uc *MFAUseCase) RegenerateRecoveryCodes(ctx context.Context, userID int64, req *model.RegenerateRecoveryCodesRequest) (*model.RecoveryCodesResponse, error) {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+
+	if err := uc.checkPassword(ctx, user, req.Password); err != nil {
+		return nil, err
+	}
+
+	if _, err := uc.verifyAccountCode(ctx, user, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := uc.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+
+	return &model.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// VerifyCode checks a TOTP code, or else a recovery code, of a user with TOTP enabled.
+// Every code is accepted once. It returns which method the code belonged to.
+func (uc *MFAUseCase) VerifyCode(ctx context.Context, userID int64, code string) (string, error) {
+	mfa, err := uc.findEnabled(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	step, ok, err := uc.validateTOTP(mfa, code)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		advanced, err := uc.mfaRepo.AdvanceTOTPStep(ctx, userID, step)
+		if err != nil {
+			return "", fmt.Errorf("failed to record TOTP use: %w", err)
+		}
+		if !advanced {
+			return "", errors.ErrMFACodeInvalid
+		}
+		return model.MFAMethodTOTP, nil
+	}
+
+	used, err := uc.mfaRepo.UseRecoveryCode(ctx, userID, security.HashRecoveryCode(code))
+	if err != nil {
+		return "", fmt.Errorf("failed to check recovery code: %w", err)
+	}
+	if !used {
+		return "", errors.ErrMFACodeInvalid
+	}
+	return model.MFAMethodRecoveryCode, nil
+}
+
+// StartChallenge parks a password-verified login until the second factor is provided
+func (uc *MFAUseCase) StartChallenge(ctx context.Context, user *entity.User, tenantID int64, loginMethod string) (*model.MFAChallengeResponse, error) {
+	methods := []string{}
+	if _, err := uc.findEnabled(ctx, user.ID); err == nil {
+		methods = append(methods, model.MFAMethodTOTP, model.MFAMethodRecoveryCode)
+	} else if err != errors.ErrMFANotEnabled {
+		return nil, err
+	}
+
+	passkeys, err := uc.passkeyUseCase.CountPasskeys(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if passkeys > 0 {
+		methods = append(methods, model.MFAMethodPasskey)
+	}
+
+	token, err := uc.sessionService.CreateMFAChallenge(ctx, &model.MFAChallengeValue{
+		UserID:      user.ID,
+		TenantID:    tenantID,
+		LoginMethod: loginMethod,
+	}, uc.cfg.ChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.MFAChallengeResponse{
+		MFARequired:    true,
+		ChallengeToken: token,
+		ExpiresIn:      int(uc.cfg.ChallengeTTL.Seconds()),
+		Methods:        methods,
+	}, nil
+}
+
+// CompleteChallenge verifies the code for a pending login and consumes the challenge.
+// A challenge is discarded after too many wrong codes, and wrong codes count towards
+// the account's lockout like wrong passwords.
+func (uc *MFAUseCase) CompleteChallenge(ctx context.Context, token string, code string) (*model.MFAChallengeValue, string, error) {
+	challenge, err := uc.sessionService.GetMFAChallenge(ctx, token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, "", errors.ErrUserNotFound
+	}
+
+	if err := uc.lockoutUseCase.Check(ctx, user.Email, ""); err != nil {
+		return nil, "", err
+	}
+
+	remaining, err := uc.sessionService.ReserveMFAChallengeAttempt(ctx, token, uc.cfg.MaxAttempts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	method, err := uc.VerifyCode(ctx, challenge.UserID, code)
+	if err == errors.ErrMFACodeInvalid || err == errors.ErrMFANotEnabled {
+		// Users with only passkeys have no codes, any code is wrong
+		uc.lockoutUseCase.RecordFailure(ctx, user.Email, "")
+		return nil, "", uc.challengeFailure(ctx, token, remaining, errors.ErrMFACodeInvalid)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	uc.lockoutUseCase.RecordSuccess(ctx, user.Email)
+
+	if err := uc.consumeChallenge(ctx, token); err != nil {
+		return nil, "", err
+	}
+
+	return challenge, method, nil
+}
+
+// BeginPasskeyChallenge starts answering a pending login's challenge with a passkey
+func (uc *MFAUseCase) BeginPasskeyChallenge(ctx context.Context, token string) (*model.PasskeyCeremonyResponse, error) {
+	challenge, err := uc.sessionService.GetMFAChallenge(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.passkeyUseCase.BeginSecondFactor(ctx, challenge.UserID, token)
+}
+
+// CompletePasskeyChallenge verifies the passkey assertion for a pending login and
+// consumes the challenge. Failed assertions count towards the challenge's attempts.
+func (uc *MFAUseCase) CompletePasskeyChallenge(ctx context.Context, token string, ceremonyToken string, assertion []byte) (*model.MFAChallengeValue, error) {
+	challenge, err := uc.sessionService.GetMFAChallenge(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining, err := uc.sessionService.ReserveMFAChallengeAttempt(ctx, token, uc.cfg.MaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.passkeyUseCase.FinishSecondFactor(ctx, challenge.UserID, token, ceremonyToken, assertion)
+	if err == errors.ErrPasskeyInvalid || err == errors.ErrPasskeyNotFound {
+		return nil, uc.challengeFailure(ctx, token, remaining, errors.ErrPasskeyInvalid)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.consumeChallenge(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// challengeFailure returns err for a failed attempt, or discards the challenge and
+// returns ErrMFAChallengeInvalid when it was the last one
+func (uc *MFAUseCase) challengeFailure(ctx context.Context, token string, remaining int, err error) error {
+	if remaining == 0 {
+		_, _ = uc.sessionService.ConsumeMFAChallenge(ctx, token)
+		return errors.ErrMFAChallengeInvalid
+	}
+	return err
+}
+
+// checkPassword re-checks the password of a signed-in user before a sensitive change.
+// Wrong passwords count towards the account's lockout.
+func (uc *MFAUseCase) checkPassword(ctx context.Context, user *entity.User, password string) error {
+	if err := uc.lockoutUseCase.Check(ctx, user.Email, ""); err != nil {
+		return err
+	}
+
+	if err := uc.passwordService.ComparePassword(user.Password, password); err != nil {
+		uc.lockoutUseCase.RecordFailure(ctx, user.Email, "")
+		return errors.ErrInvalidCredentials
+	}
+	uc.lockoutUseCase.RecordSuccess(ctx, user.Email)
+	return nil
+}
+
+// verifyAccountCode is VerifyCode for a signed-in user. Wrong codes count towards the
+// account's lockout, so codes cannot be guessed faster than passwords.
+func (uc *MFAUseCase) verifyAccountCode(ctx context.Context, user *entity.User, code string) (string, error) {
+	if err := uc.lockoutUseCase.Check(ctx, user.Email, ""); err != nil {
+		return "", err
+	}
+
+	method, err := uc.VerifyCode(ctx, user.ID, code)
+	if err == errors.ErrMFACodeInvalid {
+		uc.lockoutUseCase.RecordFailure(ctx, user.Email, "")
+	}
+	return method, err
+}
+
+// consumeChallenge deletes a challenge that was answered correctly. It fails when a
+// concurrent request already used it.
+func (uc *MFAUseCase) consumeChallenge(ctx context.Context, token string) error {
+	consumed, err := uc.sessionService.ConsumeMFAChallenge(ctx, token)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return errors.ErrMFAChallengeInvalid
+	}
+	return nil
+}
+
+// findEnabled loads the user's MFA settings, or ErrMFANotEnabled when TOTP is not active
+func (uc *MFAUseCase) findEnabled(ctx context.Context, userID int64) (*entity.UserMFA, error) {
+	mfa, err := uc.mfaRepo.FindByUserID(ctx, userID)
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.ErrMFANotEnabled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load MFA settings: %w", err)
+	}
+	if !mfa.TOTPEnabled {
+		return nil, errors.ErrMFANotEnabled
+	}
+	return mfa, nil
+}
+
+func (uc *MFAUseCase) validateTOTP(mfa *entity.UserMFA, code string) (int64, bool, error) {
+	secret, err := uc.aesService.DecryptInternal(mfa.TOTPSecret)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+
+	step, ok := uc.totpService.Validate(secret, code, time.Now())
+	return step, ok, nil
+}
+
+// newRecoveryCodes returns the plain codes to show once and the hashes to store
+func (uc *MFAUseCase) newRecoveryCodes() ([]string, []string, error) {
+	codes, err := security.GenerateRecoveryCodes(uc.cfg.RecoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, security.HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,334 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"go-gin-clean/internal/entity"
+	"go-gin-clean/internal/gateway/session"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/repository"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+)
+
+// OAuthClientUseCase registers OAuth clients and issues them access tokens with the
+// client credentials grant. Registering clients is reserved to platform admins.
+// OpenID Connect logins of the same clients are handled by OIDCUseCase.
+type OAuthClientUseCase struct {
+	clientRepo     *repository.OAuthClientRepository
+	tenantRepo     *repository.TenantRepository
+	membershipRepo *repository.MembershipRepository
+	tenantRoleRepo *repository.TenantRoleRepository
+	sessionService *session.SessionService
+	cfg            *config.OAuthServerConfig
+}
+
+func NewOAuthClientUseCase(
+	clientRepo *repository.OAuthClientRepository,
+	tenantRepo *repository.TenantRepository,
+	membershipRepo *repository.MembershipRepository,
+	tenantRoleRepo *repository.TenantRoleRepository,
+	sessionService *session.SessionService,
+	cfg *config.OAuthServerConfig,
+) *OAuthClientUseCase {
+	return &OAuthClientUseCase{
+		clientRepo:     clientRepo,
+		tenantRepo:     tenantRepo,
+		membershipRepo: membershipRepo,
+		tenantRoleRepo: tenantRoleRepo,
+		sessionService: sessionService,
+		cfg:            cfg,
+	}
+}
+
+// ListClients returns a page of the registered clients, without their secrets
+func (uc *OAuthClientUseCase) ListClients(ctx context.Context, requestorUserID int64, page, pageSize int) (*model.PaginationResponse[model.OAuthClientResponse], error) {
+	if err := uc.requirePlatformAdmin(ctx, requestorUserID); err != nil {
+		return nil, err
+	}
+
+	clients, total, err := uc.clientRepo.FindAll(ctx, pageSize, model.Offset(page, pageSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OAuth clients: %w", err)
+	}
+
+	result := make([]model.OAuthClientResponse, 0, len(clients))
+	for i := range clients {
+		result = append(result, toOAuthClientResponse(&clients[i]))
+	}
+	return model.NewPaginationResponse(result, page, pageSize, int(total)), nil
+}
+
+// CreateClient registers a client for one tenant or, without a tenant, for the whole
+// platform. The generated secret is returned once; only its hash is stored. Public
+// clients get no secret and must register the redirect URIs they sign users in from.
+func (uc *OAuthClientUseCase) CreateClient(ctx context.Context, requestorUserID int64, req *model.OAuthClientRequest) (*model.OAuthClientResponse, error) {
+	if err := uc.requirePlatformAdmin(ctx, requestorUserID); err != nil {
+		return nil, err
+	}
+
+	scopes := strings.Fields(req.Scopes)
+	if len(scopes) == 0 || strings.Contains(req.Scopes, ",") {
+		return nil, errors.ErrOAuthClientScopesInvalid
+	}
+
+	redirectURIs := strings.Fields(req.RedirectURIs)
+	if req.Public && len(redirectURIs) == 0 {
+		return nil, errors.ErrOAuthRedirectURIRequired
+	}
+
+	if req.TenantID != nil {
+		if _, err := uc.tenantRepo.FindByID(ctx, *req.TenantID); err != nil {
+			return nil, errors.ErrTenantNotFound
+		}
+	}
+
+	clientID, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	var secret, secretHash string
+	if !req.Public {
+		secret, secretHash, err = uc.newClientSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := uc.clientRepo.Create(ctx, &entity.OAuthClient{
+		ClientID:     clientID,
+		ClientSecret: secretHash,
+		Name:         req.Name,
+		Description:  req.Description,
+		RedirectURIs: strings.Join(redirectURIs, " "),
+		Scopes:       strings.Join(scopes, " "),
+		TenantID:     req.TenantID,
+		IsPublic:     req.Public,
+		IsActive:     true,
+		CreatedBy:    &requestorUserID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save OAuth client: %w", err)
+	}
+
+	resp := toOAuthClientResponse(client)
+	resp.ClientSecret = secret
+	return &resp, nil
+}
+
+// RotateSecret replaces the client's secret and revokes its live access tokens
+func (uc *OAuthClientUseCase) RotateSecret(ctx context.Context, requestorUserID int64, id int64) (*model.OAuthClientResponse, error) {
+	if err := uc.requirePlatformAdmin(ctx, requestorUserID); err != nil {
+		return nil, err
+	}
+
+	client, err := uc.clientRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.ErrOAuthClientNotFound
+	}
+	if client.IsPublic {
+		return nil, errors.ErrOAuthPublicClient
+	}
+
+	secret, secretHash, err := uc.newClientSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.clientRepo.UpdateSecret(ctx, client.ID, secretHash); err != nil {
+		return nil, fmt.Errorf("failed to update client secret: %w", err)
+	}
+	uc.revokeTokens(ctx, client)
+
+	resp := toOAuthClientResponse(client)
+	resp.ClientSecret = secret
+	return &resp, nil
+}
+
+// UpdateStatus enables or disables a client; disabling revokes its live access tokens
+// and the sessions users opened in it
+func (uc *OAuthClientUseCase) UpdateStatus(ctx context.Context, requestorUserID int64, id int64, isActive bool) error {
+	if err := uc.requirePlatformAdmin(ctx, requestorUserID); err != nil {
+		return err
+	}
+
+	client, err := uc.clientRepo.FindByID(ctx, id)
+	if err != nil {
+		return errors.ErrOAuthClientNotFound
+	}
+
+	if err := uc.clientRepo.UpdateStatus(ctx, client.ID, isActive); err != nil {
+		return fmt.Errorf("failed to update client status: %w", err)
+	}
+	if !isActive {
+		uc.revokeTokens(ctx, client)
+		uc.revokeSessions(ctx, client)
+	}
+	return nil
+}
+
+// DeleteClient removes a client and revokes its live access tokens and the sessions
+// users opened in it
+func (uc *OAuthClientUseCase) DeleteClient(ctx context.Context, requestorUserID int64, id int64) error {
+	if err := uc.requirePlatformAdmin(ctx, requestorUserID); err != nil {
+		return err
+	}
+
+	client, err := uc.clientRepo.FindByID(ctx, id)
+	if err != nil {
+		return errors.ErrOAuthClientNotFound
+	}
+
+	if err := uc.clientRepo.Delete(ctx, client.ID); err != nil {
+		return fmt.Errorf("failed to delete OAuth client: %w", err)
+	}
+	uc.revokeTokens(ctx, client)
+	uc.revokeSessions(ctx, client)
+	return nil
+}
+
+// IssueClientCredentialsToken authenticates a client and issues it an access token
+// (RFC 6749 section 4.4). The token carries the requested scopes, or every scope the
+// client is allowed when none are requested, and is introspected like a session.
+func (uc *OAuthClientUseCase) IssueClientCredentialsToken(ctx context.Context, clientID, clientSecret string, req *model.OAuthTokenRequest) (*model.OAuthTokenResponse, error) {
+	client, err := uc.AuthenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GrantType != model.GrantTypeClientCredentials {
+		return nil, errors.ErrOAuthGrantUnsupported
+	}
+
+	allowed := client.ScopeList()
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		scopes = allowed
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(allowed, scope) {
+			return nil, errors.ErrOAuthScopeInvalid
+		}
+	}
+	scope := strings.Join(scopes, " ")
+
+	var tenantID int64
+	if client.TenantID != nil {
+		tenantID = *client.TenantID
+	}
+
+	ttl := uc.cfg.ClientTokenTTL
+	if ttl == 0 {
+		ttl = session.DefaultClientTokenTTL
+	}
+
+	token, err := uc.sessionService.CreateClientToken(ctx, &model.ClientTokenValue{
+		ClientPKID: client.ID,
+		ClientID:   client.ClientID,
+		TenantID:   tenantID,
+		Scope:      scope,
+	}, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue client token: %w", err)
+	}
+
+	return &model.OAuthTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(ttl.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// AuthenticateClient verifies the credentials of an active confidential client
+func (uc *OAuthClientUseCase) AuthenticateClient(ctx context.Context, clientID, clientSecret string) (*entity.OAuthClient, error) {
+	if clientID == "" || clientSecret == "" {
+		return nil, errors.ErrOAuthClientInvalid
+	}
+
+	client, err := uc.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil || !client.IsActive || client.IsPublic {
+		return nil, errors.ErrOAuthClientInvalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(client.ClientSecret), []byte(hashClientSecret(clientSecret))) != 1 {
+		return nil, errors.ErrOAuthClientInvalid
+	}
+	return client, nil
+}
+
+func (uc *OAuthClientUseCase) requirePlatformAdmin(ctx context.Context, userID int64) error {
+	isAdmin, err := isPlatformAdmin(ctx, uc.tenantRepo, uc.membershipRepo, uc.tenantRoleRepo, userID)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.ErrNotPlatformAdmin
+	}
+	return nil
+}
+
+// newClientSecret generates a client secret and its hash
+func (uc *OAuthClientUseCase) newClientSecret() (string, string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, hashClientSecret(secret), nil
+}
+
+// hashClientSecret is the form a client secret is stored in. Like API tokens, the
+// secret is random, so an unsalted fast hash is enough and clients calling the token
+// and introspection endpoints on every request don't pay for a password hash.
+func hashClientSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// revokeTokens drops the client's live access tokens. Failures are logged; the
+// tokens still expire on their own.
+func (uc *OAuthClientUseCase) revokeTokens(ctx context.Context, client *entity.OAuthClient) {
+	if _, err := uc.sessionService.DeleteClientTokens(ctx, client.ID); err != nil {
+		log.Printf("Failed to revoke access tokens of OAuth client %s: %v", client.ClientID, err)
+	}
+}
+
+// revokeSessions ends the sessions users opened in the client with OpenID Connect,
+// refresh tokens included. Failures are logged like in revokeTokens.
+func (uc *OAuthClientUseCase) revokeSessions(ctx context.Context, client *entity.OAuthClient) {
+	if err := uc.sessionService.RevokeClientFamilies(ctx, client.ClientID); err != nil {
+		log.Printf("Failed to revoke sessions of OAuth client %s: %v", client.ClientID, err)
+	}
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func toOAuthClientResponse(c *entity.OAuthClient) model.OAuthClientResponse {
+	return model.OAuthClientResponse{
+		PKID:         c.ID,
+		ClientID:     c.ClientID,
+		Name:         c.Name,
+		Description:  c.Description,
+		RedirectURIs: c.RedirectURIs,
+		Scopes:       c.Scopes,
+		TenantID:     c.TenantID,
+		Public:       c.IsPublic,
+		IsActive:     c.IsActive,
+		CreatedAt:    c.CreatedAt.Format(time.RFC3339),
+	}
+}
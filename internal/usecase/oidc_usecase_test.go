@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/repository"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestOIDCUserInfo(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		session   model.SessionValue
+		want      error
+		wantName  bool
+		wantEmail bool
+	}{
+		{
+			name:    "openid only",
+			session: model.SessionValue{ClientID: "grafana", Scope: "openid"},
+		},
+		{
+			name:     "profile scope",
+			session:  model.SessionValue{ClientID: "grafana", Scope: "openid profile"},
+			wantName: true,
+		},
+		{
+			name:      "email scope",
+			session:   model.SessionValue{ClientID: "grafana", Scope: "email openid"},
+			wantEmail: true,
+		},
+		{
+			name:      "all scopes",
+			session:   model.SessionValue{ClientID: "grafana", Scope: "openid profile email"},
+			wantName:  true,
+			wantEmail: true,
+		},
+		{
+			name:    "client session without openid",
+			session: model.SessionValue{ClientID: "grafana", Scope: "profile email"},
+			want:    errors.ErrOAuthTokenInvalid,
+		},
+		{
+			name:    "portal session",
+			session: model.SessionValue{Scope: "openid profile email users.read"},
+			want:    errors.ErrOAuthTokenInvalid,
+		},
+		{
+			name:    "limited session",
+			session: model.SessionValue{ClientID: "grafana", Scope: "openid", Restriction: model.SessionRestrictionMFAEnrollment},
+			want:    errors.ErrOAuthTokenInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newTestDB(t)
+			sessionService, _ := newTestSessionService(t)
+			uc := NewOIDCUseCase(nil, nil, repository.NewUserRepository(db), nil, nil, sessionService, nil, nil, nil, &config.OAuthServerConfig{})
+
+			sessionValue := tt.session
+			sessionValue.UserID = 1
+			sessionValue.TenantID = 10
+			accessToken, err := sessionService.CreateSession(ctx, &sessionValue, sessionService.ResolvePolicy(nil))
+			if err != nil {
+				t.Fatalf("CreateSession() error = %v", err)
+			}
+
+			if tt.want == nil {
+				mock.ExpectQuery(`FROM "users"`).WillReturnRows(
+					sqlmock.NewRows([]string{"id", "uuid", "name", "avatar", "email", "is_verified"}).
+						AddRow(1, "0b6e5f5c-8d0c-4a8e-9f00-2f1d2d6c7e11", "Jane Doe", "https://example.com/jane.png", "jane@example.com", true),
+				)
+			}
+
+			userInfo, err := uc.UserInfo(ctx, accessToken)
+			if err != tt.want {
+				t.Fatalf("UserInfo() error = %v, want %v", err, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+			if err != nil {
+				return
+			}
+
+			if userInfo.Sub == "" || userInfo.TenantID != 10 {
+				t.Errorf("UserInfo() = %+v, want the user's subject in tenant 10", userInfo)
+			}
+			if gotName := userInfo.Name != "" || userInfo.Picture != ""; gotName != tt.wantName {
+				t.Errorf("name and picture returned = %v, want %v", gotName, tt.wantName)
+			}
+			if gotEmail := userInfo.Email != "" || userInfo.EmailVerified != nil; gotEmail != tt.wantEmail {
+				t.Errorf("email and email_verified returned = %v, want %v", gotEmail, tt.wantEmail)
+			}
+		})
+	}
+
+	// Unknown tokens are invalid too
+	sessionService, _ := newTestSessionService(t)
+	uc := NewOIDCUseCase(nil, nil, nil, nil, nil, sessionService, nil, nil, nil, &config.OAuthServerConfig{})
+	if _, err := uc.UserInfo(ctx, "ref_unknown"); err != errors.ErrOAuthTokenInvalid {
+		t.Errorf("UserInfo() of an unknown token error = %v, want %v", err, errors.ErrOAuthTokenInvalid)
+	}
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// BASE64URL(SHA256(verifier)) without padding, computed outside Go
+	const verifier = "dBjftJeZ4CVP-mJ0kWKvTfRzFm6f8Axd7kSbxEq1Aa0"
+	const challenge = "rzZMExHvnQQe57uFrF2e6_sJIc6IkEElEj5Ywq0QqxY"
+
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{"S256 challenge", verifier, challenge, true},
+		{"wrong verifier", "eBjftJeZ4CVP-mJ0kWKvTfRzFm6f8Axd7kSbxEq1Aa0", challenge, false},
+		{"plain method", verifier, verifier, false},
+		{"padded challenge", verifier, challenge + "=", false},
+		{"no challenge", verifier, "", false},
+		{"verifier too short", verifier[:42], challenge, false},
+		{"verifier too long", strings.Repeat("a", 129), challenge, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyCodeChallenge(tt.verifier, tt.challenge); got != tt.want {
+				t.Errorf("verifyCodeChallenge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
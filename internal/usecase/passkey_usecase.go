@@ -0,0 +1,430 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go-gin-clean/internal/entity"
+	"go-gin-clean/internal/gateway/security"
+	"go-gin-clean/internal/gateway/session"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/repository"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// defaultPasskeyName labels passkeys registered without a name
+const defaultPasskeyName = "Passkey"
+
+type PasskeyUseCase struct {
+	userRepo        *repository.UserRepository
+	credentialRepo  *repository.WebAuthnCredentialRepository
+	webAuthnService *security.WebAuthnService
+	sessionService  *session.SessionService
+	passwordService *security.PasswordService
+	lockoutUseCase  *LockoutUseCase
+	cfg             *config.WebAuthnConfig
+}
+
+func NewPasskeyUseCase(
+	userRepo *repository.UserRepository,
+	credentialRepo *repository.WebAuthnCredentialRepository,
+	webAuthnService *security.WebAuthnService,
+	sessionService *session.SessionService,
+	passwordService *security.PasswordService,
+	lockoutUseCase *LockoutUseCase,
+	cfg *config.WebAuthnConfig,
+) *PasskeyUseCase {
+	return &PasskeyUseCase{
+		userRepo:        userRepo,
+		credentialRepo:  credentialRepo,
+		webAuthnService: webAuthnService,
+		sessionService:  sessionService,
+		passwordService: passwordService,
+		lockoutUseCase:  lockoutUseCase,
+		cfg:             cfg,
+	}
+}
+
+// ListPasskeys returns the passkeys registered by the user
+func (uc *PasskeyUseCase) ListPasskeys(ctx context.Context, userID int64) ([]model.PasskeyResponse, error) {
+	credentials, err := uc.credentialRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load passkeys: %w", err)
+	}
+
+	passkeys := make([]model.PasskeyResponse, 0, len(credentials))
+	for i := range credentials {
+		passkeys = append(passkeys, toPasskeyResponse(&credentials[i]))
+	}
+	return passkeys, nil
+}
+
+// CountPasskeys returns how many passkeys the user has registered
+func (uc *PasskeyUseCase) CountPasskeys(ctx context.Context, userID int64) (int64, error) {
+	count, err := uc.credentialRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count passkeys: %w", err)
+	}
+	return count, nil
+}
+
+// BeginRegistration starts registering a new passkey for the user after re-checking
+// the password, so a stolen session cannot add a credential of its own
+func (uc *PasskeyUseCase) BeginRegistration(ctx context.Context, userID int64, password string) (*model.PasskeyCeremonyResponse, error) {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+
+	if err := uc.checkPassword(ctx, user, password); err != nil {
+		return nil, err
+	}
+
+	passkeyUser, _, err := uc.loadPasskeyUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	options, sessionData, err := uc.webAuthnService.BeginRegistration(passkeyUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start passkey registration: %w", err)
+	}
+
+	return uc.startCeremony(ctx, &model.PasskeyCeremonyValue{
+		Purpose: model.PasskeyCeremonyRegistration,
+		UserID:  userID,
+	}, sessionData, options)
+}
+
+// FinishRegistration verifies the authenticator's attestation and stores the new passkey
+func (uc *PasskeyUseCase) FinishRegistration(ctx context.Context, userID int64, req *model.FinishPasskeyRegistrationRequest) (*model.PasskeyResponse, error) {
+	ceremony, sessionData, err := uc.finishCeremony(ctx, req.CeremonyToken, model.PasskeyCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.UserID != userID {
+		return nil, errors.ErrPasskeyCeremonyInvalid
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+
+	passkeyUser, _, err := uc.loadPasskeyUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := uc.webAuthnService.FinishRegistration(passkeyUser, *sessionData, req.Credential)
+	if err != nil {
+		return nil, errors.ErrPasskeyInvalid
+	}
+
+	if _, err := uc.credentialRepo.FindByCredentialID(ctx, credential.ID); err == nil {
+		return nil, errors.ErrPasskeyExists
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("failed to check passkey: %w", err)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = defaultPasskeyName
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	stored, err := uc.credentialRepo.Create(ctx, &entity.WebAuthnCredential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save passkey: %w", err)
+	}
+
+	passkey := toPasskeyResponse(stored)
+	return &passkey, nil
+}
+
+// DeletePasskey removes one of the user's passkeys after re-checking the password
+func (uc *PasskeyUseCase) DeletePasskey(ctx context.Context, userID int64, passkeyID int64, password string) error {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.ErrUserNotFound
+	}
+
+	if err := uc.checkPassword(ctx, user, password); err != nil {
+		return err
+	}
+
+	deleted, err := uc.credentialRepo.DeleteByIDAndUserID(ctx, passkeyID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+	if !deleted {
+		return errors.ErrPasskeyNotFound
+	}
+	return nil
+}
+
+// BeginLogin starts a passwordless login; the authenticator picks the account
+func (uc *PasskeyUseCase) BeginLogin(ctx context.Context) (*model.PasskeyCeremonyResponse, error) {
+	options, sessionData, err := uc.webAuthnService.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start passkey login: %w", err)
+	}
+
+	return uc.startCeremony(ctx, &model.PasskeyCeremonyValue{
+		Purpose: model.PasskeyCeremonyLogin,
+	}, sessionData, options)
+}
+
+// FinishLogin verifies a passwordless login and returns the user the passkey belongs to
+func (uc *PasskeyUseCase) FinishLogin(ctx context.Context, ceremonyToken string, assertion []byte) (*entity.User, error) {
+	_, sessionData, err := uc.finishCeremony(ctx, ceremonyToken, model.PasskeyCeremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner *entity.User
+	var stored []entity.WebAuthnCredential
+	lookup := func(credentialID []byte, userHandle []byte) (*security.PasskeyUser, error) {
+		credential, err := uc.credentialRepo.FindByCredentialID(ctx, credentialID)
+		if err != nil {
+			return nil, errors.ErrPasskeyNotFound
+		}
+
+		user, err := uc.userRepo.FindByID(ctx, credential.UserID)
+		if err != nil {
+			return nil, errors.ErrUserNotFound
+		}
+
+		passkeyUser, credentials, err := uc.loadPasskeyUser(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+
+		owner, stored = user, credentials
+		return passkeyUser, nil
+	}
+
+	_, credential, err := uc.webAuthnService.FinishDiscoverableLogin(lookup, *sessionData, assertion)
+	if err != nil {
+		return nil, errors.ErrPasskeyInvalid
+	}
+
+	if err := uc.recordUse(ctx, stored, credential); err != nil {
+		return nil, err
+	}
+
+	return owner, nil
+}
+
+// BeginSecondFactor starts answering the MFA challenge of a password login with one
+// of the user's passkeys
+func (uc *PasskeyUseCase) BeginSecondFactor(ctx context.Context, userID int64, challengeToken string) (*model.PasskeyCeremonyResponse, error) {
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+
+	passkeyUser, _, err := uc.loadPasskeyUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(passkeyUser.Credentials) == 0 {
+		return nil, errors.ErrPasskeyNotFound
+	}
+
+	options, sessionData, err := uc.webAuthnService.BeginLogin(passkeyUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start passkey verification: %w", err)
+	}
+
+	return uc.startCeremony(ctx, &model.PasskeyCeremonyValue{
+		Purpose:        model.PasskeyCeremonyMFA,
+		UserID:         userID,
+		ChallengeToken: challengeToken,
+	}, sessionData, options)
+}
+
+// FinishSecondFactor verifies the passkey assertion answering the given MFA challenge
+func (uc *PasskeyUseCase) FinishSecondFactor(ctx context.Context, userID int64, challengeToken string, ceremonyToken string, assertion []byte) error {
+	ceremony, sessionData, err := uc.finishCeremony(ctx, ceremonyToken, model.PasskeyCeremonyMFA)
+	if err != nil {
+		return err
+	}
+	if ceremony.UserID != userID || ceremony.ChallengeToken != challengeToken {
+		return errors.ErrPasskeyCeremonyInvalid
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.ErrUserNotFound
+	}
+
+	passkeyUser, stored, err := uc.loadPasskeyUser(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	credential, err := uc.webAuthnService.FinishLogin(passkeyUser, *sessionData, assertion)
+	if err != nil {
+		return errors.ErrPasskeyInvalid
+	}
+
+	return uc.recordUse(ctx, stored, credential)
+}
+
+// loadPasskeyUser builds the WebAuthn view of a user. Passkeys flagged as cloned are
+// left out so they can no longer sign in.
+// checkPassword re-checks the password of a signed-in user before their passkeys
+// change. Wrong passwords count towards the account's lockout.
+func (uc *PasskeyUseCase) checkPassword(ctx context.Context, user *entity.User, password string) error {
+	if err := uc.lockoutUseCase.Check(ctx, user.Email, ""); err != nil {
+		return err
+	}
+
+	if err := uc.passwordService.ComparePassword(user.Password, password); err != nil {
+		uc.lockoutUseCase.RecordFailure(ctx, user.Email, "")
+		return errors.ErrInvalidCredentials
+	}
+	uc.lockoutUseCase.RecordSuccess(ctx, user.Email)
+	return nil
+}
+
+func (uc *PasskeyUseCase) loadPasskeyUser(ctx context.Context, user *entity.User) (*security.PasskeyUser, []entity.WebAuthnCredential, error) {
+	handle, err := uuid.Parse(user.UUID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+
+	stored, err := uc.credentialRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load passkeys: %w", err)
+	}
+
+	credentials := make([]webauthn.Credential, 0, len(stored))
+	for _, c := range stored {
+		if c.CloneWarning {
+			continue
+		}
+
+		transports := make([]protocol.AuthenticatorTransport, 0)
+		for _, transport := range c.TransportList() {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: uint32(c.SignCount),
+			},
+		})
+	}
+
+	return &security.PasskeyUser{
+		ID:          handle[:],
+		Name:        user.Email,
+		DisplayName: user.Name,
+		Credentials: credentials,
+	}, stored, nil
+}
+
+// recordUse saves the sign counter of a verified assertion. A counter that did not
+// increase means the passkey may have been cloned, so it is flagged and rejected.
+func (uc *PasskeyUseCase) recordUse(ctx context.Context, stored []entity.WebAuthnCredential, credential *webauthn.Credential) error {
+	for _, c := range stored {
+		if !bytes.Equal(c.CredentialID, credential.ID) {
+			continue
+		}
+
+		cloneWarning := credential.Authenticator.CloneWarning
+		if err := uc.credentialRepo.RecordUse(ctx, c.ID, int64(credential.Authenticator.SignCount), cloneWarning, credential.Flags.BackupState); err != nil {
+			return fmt.Errorf("failed to record passkey use: %w", err)
+		}
+		if cloneWarning {
+			return errors.ErrPasskeyInvalid
+		}
+		return nil
+	}
+	return errors.ErrPasskeyNotFound
+}
+
+// startCeremony parks the WebAuthn session data in Redis and returns the options for the browser
+func (uc *PasskeyUseCase) startCeremony(ctx context.Context, value *model.PasskeyCeremonyValue, sessionData *webauthn.SessionData, options any) (*model.PasskeyCeremonyResponse, error) {
+	sessionJSON, err := json.Marshal(sessionData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal WebAuthn session: %w", err)
+	}
+	value.SessionData = sessionJSON
+
+	token, err := uc.sessionService.CreatePasskeyCeremony(ctx, value, uc.cfg.CeremonyTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.PasskeyCeremonyResponse{
+		CeremonyToken: token,
+		ExpiresIn:     int(uc.cfg.CeremonyTTL.Seconds()),
+		Options:       options,
+	}, nil
+}
+
+// finishCeremony consumes a ceremony of the given purpose and decodes its session data
+func (uc *PasskeyUseCase) finishCeremony(ctx context.Context, token string, purpose string) (*model.PasskeyCeremonyValue, *webauthn.SessionData, error) {
+	ceremony, err := uc.sessionService.ConsumePasskeyCeremony(ctx, token, purpose)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sessionData webauthn.SessionData
+	if err := json.Unmarshal(ceremony.SessionData, &sessionData); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal WebAuthn session: %w", err)
+	}
+	return ceremony, &sessionData, nil
+}
+
+func toPasskeyResponse(c *entity.WebAuthnCredential) model.PasskeyResponse {
+	passkey := model.PasskeyResponse{
+		ID:             c.ID,
+		Name:           c.Name,
+		Transports:     c.TransportList(),
+		BackupEligible: c.BackupEligible,
+		BackupState:    c.BackupState,
+		CreatedAt:      c.CreatedAt.Unix(),
+	}
+	if c.LastUsedAt != nil {
+		lastUsedAt := c.LastUsedAt.Unix()
+		passkey.LastUsedAt = &lastUsedAt
+	}
+	return passkey
+}
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go-gin-clean/internal/entity"
+	"go-gin-clean/internal/gateway/security"
+	"go-gin-clean/internal/repository"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+)
+
+// PasswordPolicyUseCase resolves the password policy that applies to a user and
+// enforces it wherever a password is set
+type PasswordPolicyUseCase struct {
+	membershipRepo    *repository.MembershipRepository
+	tenantRepo        *repository.TenantRepository
+	historyRepo       *repository.PasswordHistoryRepository
+	passwordService   *security.PasswordService
+	breachedPasswords *security.BreachedPasswordService
+	platform          security.PasswordPolicy
+}
+
+func NewPasswordPolicyUseCase(
+	membershipRepo *repository.MembershipRepository,
+	tenantRepo *repository.TenantRepository,
+	historyRepo *repository.PasswordHistoryRepository,
+	passwordService *security.PasswordService,
+	breachedPasswords *security.BreachedPasswordService,
+	cfg *config.PasswordPolicyConfig,
+) *PasswordPolicyUseCase {
+	return &PasswordPolicyUseCase{
+		membershipRepo:    membershipRepo,
+		tenantRepo:        tenantRepo,
+		historyRepo:       historyRepo,
+		passwordService:   passwordService,
+		breachedPasswords: breachedPasswords,
+		platform:          security.NewPasswordPolicy(cfg),
+	}
+}
+
+// TenantPolicy returns the platform policy with the tenant's overrides applied
+func (uc *PasswordPolicyUseCase) TenantPolicy(tenant *entity.Tenant) security.PasswordPolicy {
+	return uc.platform.WithTenant(tenant.GetConfig().PasswordPolicy)
+}
+
+// UserPolicy returns the policy of the user's tenants, the strictest rules winning
+// when there are several. Users without a tenant get the platform policy.
+func (uc *PasswordPolicyUseCase) UserPolicy(ctx context.Context, userID int64) (security.PasswordPolicy, error) {
+	memberships, err := uc.membershipRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return security.PasswordPolicy{}, fmt.Errorf("failed to fetch memberships: %w", err)
+	}
+
+	var policy *security.PasswordPolicy
+	for _, m := range memberships {
+		tenant, err := uc.tenantRepo.FindByID(ctx, m.TenantID)
+		if err != nil {
+			continue // Skip if tenant not found
+		}
+
+		tenantPolicy := uc.TenantPolicy(tenant)
+		if policy != nil {
+			tenantPolicy = policy.Stricter(tenantPolicy)
+		}
+		policy = &tenantPolicy
+	}
+
+	if policy == nil {
+		return uc.platform, nil
+	}
+	return *policy, nil
+}
+
+// ValidateNewUser checks the password of an account being created, before it belongs
+// to any tenant
+func (uc *PasswordPolicyUseCase) ValidateNewUser(name string, email string, password string) error {
+	return uc.validate(uc.platform, password, name, email)
+}
+
+// ValidateChange checks a new password for an existing user, including that it is
+// not one of the user's recent passwords
+func (uc *PasswordPolicyUseCase) ValidateChange(ctx context.Context, user *entity.User, password string) error {
+	policy, err := uc.UserPolicy(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.validate(policy, password, user.Name, user.Email); err != nil {
+		return err
+	}
+
+	if policy.HistorySize <= 0 {
+		return nil
+	}
+
+	// The current password counts as the first of the last HistorySize passwords
+	if user.Password != "" && uc.passwordService.ComparePassword(user.Password, password) == nil {
+		return errors.ErrPasswordReused
+	}
+
+	history, err := uc.historyRepo.FindRecentByUserID(ctx, user.ID, policy.HistorySize-1)
+	if err != nil {
+		return fmt.Errorf("failed to fetch password history: %w", err)
+	}
+	for _, h := range history {
+		if uc.passwordService.ComparePassword(h.PasswordHash, password) == nil {
+			return errors.ErrPasswordReused
+		}
+	}
+
+	return nil
+}
+
+// validate checks password against policy and then the breached-password corpus, which
+// applies to every tenant
+func (uc *PasswordPolicyUseCase) validate(policy security.PasswordPolicy, password string, personal ...string) error {
+	if err := policy.Validate(password, personal...); err != nil {
+		return err
+	}
+	if uc.breachedPasswords.IsBreached(password) {
+		return errors.ErrPasswordBreached
+	}
+	return nil
+}
+
+// RecordChange keeps the hash of the password the user just replaced for later reuse
+// checks. Errors are only logged, the password change itself has succeeded.
+func (uc *PasswordPolicyUseCase) RecordChange(ctx context.Context, userID int64, previousHash string) {
+	if previousHash == "" {
+		return
+	}
+
+	policy, err := uc.UserPolicy(ctx, userID)
+	if err != nil {
+		log.Println("Failed to record password history:", err)
+		return
+	}
+
+	if _, err := uc.historyRepo.Create(ctx, &entity.PasswordHistory{UserID: userID, PasswordHash: previousHash}); err != nil {
+		log.Println("Failed to record password history:", err)
+		return
+	}
+	if err := uc.historyRepo.Prune(ctx, userID, policy.HistorySize); err != nil {
+		log.Println("Failed to prune password history:", err)
+	}
+}
+
+// IsExpired reports whether the user's password is older than their policy allows
+func (uc *PasswordPolicyUseCase) IsExpired(ctx context.Context, user *entity.User) (bool, error) {
+	if user.Password == "" {
+		return false, nil // OAuth accounts have no password to expire
+	}
+
+	policy, err := uc.UserPolicy(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	return policy.IsExpired(user.PasswordChangedAt), nil
+}
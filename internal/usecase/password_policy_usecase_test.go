@@ -0,0 +1,235 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-gin-clean/internal/entity"
+	"go-gin-clean/internal/gateway/security"
+	"go-gin-clean/internal/repository"
+	"go-gin-clean/pkg/config"
+	"go-gin-clean/pkg/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB returns a GORM handle on a mocked Postgres connection
+func newTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return db, mock
+}
+
+func newTestPasswordPolicyUseCase(t *testing.T, cfg *config.PasswordPolicyConfig) (*PasswordPolicyUseCase, *security.PasswordService, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock := newTestDB(t)
+	passwordService := security.NewPasswordService(&config.PasswordHashConfig{Algorithm: "bcrypt", BcryptCost: 4})
+	uc := NewPasswordPolicyUseCase(
+		repository.NewMembershipRepository(db),
+		repository.NewTenantRepository(db),
+		repository.NewPasswordHistoryRepository(db),
+		passwordService,
+		security.NewBreachedPasswordService(cfg),
+		cfg,
+	)
+	return uc, passwordService, mock
+}
+
+// expectMemberships makes the next membership lookup return the given tenants
+func expectMemberships(mock sqlmock.Sqlmock, userID int64, tenantIDs ...int64) {
+	rows := sqlmock.NewRows([]string{"id", "user_id", "tenant_id", "role_id"})
+	for i, tenantID := range tenantIDs {
+		rows.AddRow(i+1, userID, tenantID, 1)
+	}
+	mock.ExpectQuery(`FROM "memberships"`).WillReturnRows(rows)
+}
+
+// expectTenant makes the next tenant lookup return a tenant with the given config
+func expectTenant(mock sqlmock.Sqlmock, id int64, cfg string) {
+	mock.ExpectQuery(`FROM "tenants"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "config"}).AddRow(id, "Tenant", cfg))
+}
+
+func TestPasswordPolicyValidateNewUser(t *testing.T) {
+	uc, _, _ := newTestPasswordPolicyUseCase(t, &config.PasswordPolicyConfig{
+		MinLength:    10,
+		RequireDigit: true,
+		BannedWords:  []string{"Acme"},
+	})
+
+	tests := []struct {
+		name     string
+		password string
+		want     error
+	}{
+		{"valid", "correct horse 42", nil},
+		{"too short", "short 42", errors.ErrInvalidPasswordLength},
+		{"too long", strings.Repeat("a1", 37), errors.ErrInvalidPasswordLength},
+		{"missing digit", "correct horse battery", errors.ErrPasswordWeak},
+		{"banned word in any case", "welcome to ACME 42", errors.ErrPasswordBanned},
+		{"contains the name", "jonathan rules 42", errors.ErrPasswordBanned},
+		{"contains the email", "smithers forever 42", errors.ErrPasswordBanned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := uc.ValidateNewUser("Jonathan Li", "smithers@example.com", tt.password); err != tt.want {
+				t.Errorf("ValidateNewUser(%q) error = %v, want %v", tt.password, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyUserPolicy(t *testing.T) {
+	platform := &config.PasswordPolicyConfig{MinLength: 8, HistorySize: 2, MaxAge: 90 * 24 * time.Hour}
+
+	tests := []struct {
+		name   string
+		expect func(mock sqlmock.Sqlmock)
+		want   security.PasswordPolicy
+	}{
+		{
+			name:   "no tenant",
+			expect: func(mock sqlmock.Sqlmock) { expectMemberships(mock, 1) },
+			want:   security.PasswordPolicy{MinLength: 8, HistorySize: 2, MaxAge: 90 * 24 * time.Hour},
+		},
+		{
+			name: "tenant overrides",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectMemberships(mock, 1, 10)
+				expectTenant(mock, 10, `{"password_policy":{"min_length":12,"require_symbol":true,"max_age":"0"}}`)
+			},
+			want: security.PasswordPolicy{MinLength: 12, RequireSymbol: true, HistorySize: 2},
+		},
+		{
+			name: "strictest of several tenants",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectMemberships(mock, 1, 10, 20)
+				expectTenant(mock, 10, `{"password_policy":{"min_length":12,"history_size":5}}`)
+				expectTenant(mock, 20, `{"password_policy":{"min_length":10,"require_digit":true,"max_age":"720h"}}`)
+			},
+			want: security.PasswordPolicy{MinLength: 12, RequireDigit: true, HistorySize: 5, MaxAge: 720 * time.Hour},
+		},
+		{
+			name: "missing tenant is skipped",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectMemberships(mock, 1, 10, 20)
+				mock.ExpectQuery(`FROM "tenants"`).WillReturnError(gorm.ErrRecordNotFound)
+				expectTenant(mock, 20, `{"password_policy":{"require_uppercase":true}}`)
+			},
+			want: security.PasswordPolicy{MinLength: 8, RequireUppercase: true, HistorySize: 2, MaxAge: 90 * 24 * time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, _, mock := newTestPasswordPolicyUseCase(t, platform)
+			tt.expect(mock)
+
+			got, err := uc.UserPolicy(context.Background(), 1)
+			if err != nil {
+				t.Fatalf("UserPolicy() error = %v", err)
+			}
+			if got.MinLength != tt.want.MinLength || got.RequireUppercase != tt.want.RequireUppercase ||
+				got.RequireDigit != tt.want.RequireDigit || got.RequireSymbol != tt.want.RequireSymbol ||
+				got.HistorySize != tt.want.HistorySize || got.MaxAge != tt.want.MaxAge {
+				t.Errorf("UserPolicy() = %+v, want %+v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyValidateChange(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		password string
+		want     error
+	}{
+		{"new password", "brand new secret", nil},
+		{"current password", "current secret", errors.ErrPasswordReused},
+		{"previous password", "previous secret", errors.ErrPasswordReused},
+		{"policy still applies", "short", errors.ErrInvalidPasswordLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, passwords, mock := newTestPasswordPolicyUseCase(t, &config.PasswordPolicyConfig{MinLength: 8, HistorySize: 3})
+
+			current, _ := passwords.HashPassword("current secret")
+			previous, _ := passwords.HashPassword("previous secret")
+			user := &entity.User{ID: 1, Name: "Jane Doe", Email: "jane@example.com", Password: current}
+
+			expectMemberships(mock, 1)
+			if tt.want != errors.ErrInvalidPasswordLength && tt.password != "current secret" {
+				// The current password counts against the history size
+				mock.ExpectQuery(`FROM "password_history" WHERE user_id = \$1 ORDER BY created_at DESC, id DESC LIMIT \$2`).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "password_hash"}).AddRow(7, 1, previous))
+			}
+
+			if err := uc.ValidateChange(ctx, user, tt.password); err != tt.want {
+				t.Errorf("ValidateChange(%q) error = %v, want %v", tt.password, err, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyIsExpired(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.PasswordPolicyConfig{MaxAge: 24 * time.Hour}
+
+	tests := []struct {
+		name      string
+		user      *entity.User
+		lookupsDB bool
+		want      bool
+	}{
+		{"OAuth account", &entity.User{ID: 1, PasswordChangedAt: time.Now().Add(-48 * time.Hour)}, false, false},
+		{"recent password", &entity.User{ID: 1, Password: "hash", PasswordChangedAt: time.Now().Add(-time.Hour)}, true, false},
+		{"old password", &entity.User{ID: 1, Password: "hash", PasswordChangedAt: time.Now().Add(-48 * time.Hour)}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, _, mock := newTestPasswordPolicyUseCase(t, cfg)
+			if tt.lookupsDB {
+				expectMemberships(mock, tt.user.ID)
+			}
+
+			got, err := uc.IsExpired(ctx, tt.user)
+			if err != nil {
+				t.Fatalf("IsExpired() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsExpired() = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -22,8 +22,9 @@ type RegistrationUseCase struct {
 	tenantRepo           *repository.TenantRepository
 	tenantRoleRepo       *repository.TenantRoleRepository
 	membershipRepo       *repository.MembershipRepository
-	bcryptService        *security.BcryptService
+	passwordService      *security.PasswordService
 	kongClient           *kong.KongAdminClient
+	passwordPolicy       *PasswordPolicyUseCase
 }
 
 func NewRegistrationUseCase(
@@ -32,17 +33,19 @@ func NewRegistrationUseCase(
 	tenantRepo *repository.TenantRepository,
 	tenantRoleRepo *repository.TenantRoleRepository,
 	membershipRepo *repository.MembershipRepository,
-	bcryptService *security.BcryptService,
+	passwordService *security.PasswordService,
 	kongClient *kong.KongAdminClient,
+	passwordPolicy *PasswordPolicyUseCase,
 ) *RegistrationUseCase {
 	return &RegistrationUseCase{
-		db:             db,
-		userRepo:       userRepo,
-		tenantRepo:     tenantRepo,
-		tenantRoleRepo: tenantRoleRepo,
-		membershipRepo: membershipRepo,
-		bcryptService:  bcryptService,
-		kongClient:     kongClient,
+		db:              db,
+		userRepo:        userRepo,
+		tenantRepo:      tenantRepo,
+		tenantRoleRepo:  tenantRoleRepo,
+		membershipRepo:  membershipRepo,
+		passwordService: passwordService,
+		kongClient:      kongClient,
+		passwordPolicy:  passwordPolicy,
 	}
 }
 
@@ -59,6 +62,11 @@ func (uc *RegistrationUseCase) RegisterWithTenant(ctx context.Context, req *mode
 		return nil, errors.ErrEmailAlreadyExists
 	}
 
+	// The new tenant starts with the platform password policy
+	if err := uc.passwordPolicy.ValidateNewUser(req.Name, req.Email, req.Password); err != nil {
+		return nil, err
+	}
+
 	// Generate tenant slug from company name
 	tenantSlug := generateSlug(req.CompanyName)
 	
@@ -69,7 +77,7 @@ func (uc *RegistrationUseCase) RegisterWithTenant(ctx context.Context, req *mode
 	}
 
 	// Hash password
-	hashedPassword, err := uc.bcryptService.HashPassword(req.Password)
+	hashedPassword, err := uc.passwordService.HashPassword(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
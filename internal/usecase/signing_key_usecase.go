@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go-gin-clean/internal/entity"
+	"go-gin-clean/internal/gateway/security"
+	"go-gin-clean/internal/model"
+	"go-gin-clean/internal/repository"
+	"go-gin-clean/pkg/config"
+)
+
+// keyRingRefreshInterval is how often the key ring is reloaded from the database,
+// which picks up keys rotated by other replicas and checks whether rotation is due
+const keyRingRefreshInterval = time.Minute
+
+type SigningKeyUseCase struct {
+	signingKeyRepo *repository.SigningKeyRepository
+	keyRing        *security.KeyRing
+	aesService     *security.AESService
+	cfg            *config.JWTConfig
+}
+
+func NewSigningKeyUseCase(
+	signingKeyRepo *repository.SigningKeyRepository,
+	keyRing *security.KeyRing,
+	aesService *security.AESService,
+	cfg *config.JWTConfig,
+) *SigningKeyUseCase {
+	return &SigningKeyUseCase{
+		signingKeyRepo: signingKeyRepo,
+		keyRing:        keyRing,
+		aesService:     aesService,
+		cfg:            cfg,
+	}
+}
+
+// Initialize loads the key ring and creates the first key when none can sign yet
+func (uc *SigningKeyUseCase) Initialize(ctx context.Context) error {
+	if err := uc.Reload(ctx); err != nil {
+		return err
+	}
+
+	if _, err := uc.keyRing.Active(); err == nil {
+		return nil
+	}
+
+	now := time.Now()
+	if _, err := uc.rotate(ctx, now, now); err != nil {
+		return err
+	}
+	return uc.Reload(ctx)
+}
+
+// Reload replaces the in-memory key ring with the keys currently published in the database
+func (uc *SigningKeyUseCase) Reload(ctx context.Context) error {
+	records, err := uc.signingKeyRepo.FindPublished(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	keys := make([]*security.SigningKey, 0, len(records))
+	for _, record := range records {
+		privatePEM, err := uc.aesService.DecryptInternal(record.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt signing key %s: %w", record.KID, err)
+		}
+
+		privateKey, err := security.ParsePrivateKeyPEM(privatePEM)
+		if err != nil {
+			return fmt.Errorf("failed to parse signing key %s: %w", record.KID, err)
+		}
+
+		keys = append(keys, &security.SigningKey{
+			KID:         record.KID,
+			Algorithm:   record.Algorithm,
+			PrivateKey:  privateKey,
+			ActivatedAt: record.ActivatedAt,
+			RetiredAt:   record.RetiredAt,
+		})
+	}
+
+	uc.keyRing.Replace(keys)
+	return nil
+}
+
+// RotateIfDue publishes the next key once the active key is within the publish lead
+// of its rotation interval. The next key starts signing after the lead, so verifiers
+// have already fetched it from the JWKS by then.
+func (uc *SigningKeyUseCase) RotateIfDue(ctx context.Context) (bool, error) {
+	now := time.Now()
+	dueBefore := now.Add(-uc.cfg.KeyRotationInterval).Add(uc.cfg.KeyPublishLead)
+
+	// Cheap check against the loaded ring; the repository re-checks under a lock
+	if newest := uc.keyRing.NewestActivation(); !newest.IsZero() && newest.After(dueBefore) {
+		return false, nil
+	}
+
+	rotated, err := uc.rotate(ctx, now.Add(uc.cfg.KeyPublishLead), dueBefore)
+	if err != nil || !rotated {
+		return rotated, err
+	}
+
+	return true, uc.Reload(ctx)
+}
+
+// StartRotation keeps the key ring in sync and rotates keys on schedule until ctx is done
+func (uc *SigningKeyUseCase) StartRotation(ctx context.Context) {
+	ticker := time.NewTicker(keyRingRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if rotated, err := uc.RotateIfDue(ctx); err != nil {
+				log.Printf("Signing key rotation failed: %v", err)
+			} else if rotated {
+				log.Println("Published new signing key")
+			}
+
+			if err := uc.signingKeyRepo.DeleteExpired(ctx); err != nil {
+				log.Printf("Failed to delete expired signing keys: %v", err)
+			}
+
+			if err := uc.Reload(ctx); err != nil {
+				log.Printf("Failed to reload signing keys: %v", err)
+			}
+		}
+	}
+}
+
+// GetJWKS returns the public keys verifiers need to check our JWTs
+func (uc *SigningKeyUseCase) GetJWKS() *model.JWKS {
+	return uc.keyRing.JWKS()
+}
+
+// rotate generates a key activating at activateAt and stores it if the newest key
+// activated before dueBefore
+func (uc *SigningKeyUseCase) rotate(ctx context.Context, activateAt time.Time, dueBefore time.Time) (bool, error) {
+	privateKey, err := security.GenerateSigningKey(uc.cfg.SigningAlgorithm)
+	if err != nil {
+		return false, err
+	}
+
+	kid, err := security.KeyID(privateKey)
+	if err != nil {
+		return false, err
+	}
+
+	privatePEM, err := security.EncodePrivateKeyPEM(privateKey)
+	if err != nil {
+		return false, err
+	}
+
+	encryptedPEM, err := uc.aesService.EncryptInternal(privatePEM)
+	if err != nil {
+		return false, fmt.Errorf("failed to encrypt signing key: %w", err)
+	}
+
+	publicPEM, err := security.EncodePublicKeyPEM(privateKey)
+	if err != nil {
+		return false, err
+	}
+
+	return uc.signingKeyRepo.Rotate(ctx, &entity.SigningKey{
+		KID:         kid,
+		Algorithm:   uc.cfg.SigningAlgorithm,
+		PrivateKey:  encryptedPEM,
+		PublicKey:   publicPEM,
+		ActivatedAt: activateAt,
+	}, dueBefore, uc.cfg.KeyRetention)
+}
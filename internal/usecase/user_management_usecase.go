@@ -3,9 +3,11 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go-gin-clean/internal/entity"
 	"go-gin-clean/internal/gateway/security"
+	"go-gin-clean/internal/gateway/session"
 	"go-gin-clean/internal/model"
 	"go-gin-clean/internal/repository"
 	"go-gin-clean/pkg/errors"
@@ -14,13 +16,17 @@ import (
 )
 
 type UserManagementUseCase struct {
-	db             *gorm.DB
-	userRepo       *repository.UserRepository
-	tenantRepo     *repository.TenantRepository
-	tenantRoleRepo *repository.TenantRoleRepository
-	membershipRepo *repository.MembershipRepository
-	permissionRepo *repository.PermissionRepository
-	bcryptService  *security.BcryptService
+	db              *gorm.DB
+	userRepo        *repository.UserRepository
+	tenantRepo      *repository.TenantRepository
+	tenantRoleRepo  *repository.TenantRoleRepository
+	membershipRepo  *repository.MembershipRepository
+	permissionRepo  *repository.PermissionRepository
+	passwordService *security.PasswordService
+	sessionService  *session.SessionService
+	mfaRepo         *repository.MFARepository
+	lockoutUseCase  *LockoutUseCase
+	passwordPolicy  *PasswordPolicyUseCase
 }
 
 func NewUserManagementUseCase(
@@ -30,16 +36,24 @@ func NewUserManagementUseCase(
 	tenantRoleRepo *repository.TenantRoleRepository,
 	membershipRepo *repository.MembershipRepository,
 	permissionRepo *repository.PermissionRepository,
-	bcryptService *security.BcryptService,
+	passwordService *security.PasswordService,
+	sessionService *session.SessionService,
+	mfaRepo *repository.MFARepository,
+	lockoutUseCase *LockoutUseCase,
+	passwordPolicy *PasswordPolicyUseCase,
 ) *UserManagementUseCase {
 	return &UserManagementUseCase{
-		db:             db,
-		userRepo:       userRepo,
-		tenantRepo:     tenantRepo,
-		tenantRoleRepo: tenantRoleRepo,
-		membershipRepo: membershipRepo,
-		permissionRepo: permissionRepo,
-		bcryptService:  bcryptService,
+		db:              db,
+		userRepo:        userRepo,
+		tenantRepo:      tenantRepo,
+		tenantRoleRepo:  tenantRoleRepo,
+		membershipRepo:  membershipRepo,
+		permissionRepo:  permissionRepo,
+		passwordService: passwordService,
+		sessionService:  sessionService,
+		mfaRepo:         mfaRepo,
+		lockoutUseCase:  lockoutUseCase,
+		passwordPolicy:  passwordPolicy,
 	}
 }
 
@@ -113,8 +127,13 @@ func (uc *UserManagementUseCase) CreateUser(ctx context.Context, req *model.Crea
 		return nil, errors.ErrEmailAlreadyExists
 	}
 
+	// The user joins tenants later, so the platform policy applies
+	if err := uc.passwordPolicy.ValidateNewUser(req.Name, req.Email, req.Password); err != nil {
+		return nil, err
+	}
+
 	// Hash password
-	hashedPassword, err := uc.bcryptService.HashPassword(req.Password)
+	hashedPassword, err := uc.passwordService.HashPassword(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -219,6 +238,11 @@ func (uc *UserManagementUseCase) RemoveUserFromTenant(ctx context.Context, req *
 		return fmt.Errorf("failed to remove membership: %w", err)
 	}
 
+	// Access must end now, not when the user's sessions happen to expire
+	if _, err := uc.sessionService.DeleteUserTenantSessions(ctx, req.UserID, req.TenantID); err != nil {
+		return fmt.Errorf("membership removed but failed to revoke sessions: %w", err)
+	}
+
 	return nil
 }
 
@@ -247,6 +271,28 @@ func (uc *UserManagementUseCase) UpdateUserRole(ctx context.Context, req *model.
 		return fmt.Errorf("failed to update role: %w", err)
 	}
 
+	return uc.syncRoleToSessions(ctx, membership.UserID, membership.TenantID, role)
+}
+
+// syncRoleToSessions rewrites the user's live sessions in the tenant with the new role.
+// If they cannot be rewritten they are revoked instead, so stale permissions never linger.
+func (uc *UserManagementUseCase) syncRoleToSessions(ctx context.Context, userID int64, tenantID int64, role *entity.TenantRole) error {
+	permissionEntities, err := uc.permissionRepo.FindByRoleID(ctx, role.ID)
+	if err == nil {
+		permissions := make([]string, 0, len(permissionEntities))
+		for _, perm := range permissionEntities {
+			permissions = append(permissions, fmt.Sprintf("%s:%s", perm.Resource, perm.Action))
+		}
+
+		_, err = uc.sessionService.UpdateUserTenantSessions(ctx, userID, tenantID, []string{role.Name}, permissions, buildScope(permissions))
+		if err == nil {
+			return nil
+		}
+	}
+
+	if _, err := uc.sessionService.DeleteUserTenantSessions(ctx, userID, tenantID); err != nil {
+		return fmt.Errorf("role updated but failed to revoke sessions: %w", err)
+	}
 	return nil
 }
 
@@ -367,6 +413,247 @@ func (uc *UserManagementUseCase) UpdateTenant(ctx context.Context, tenantID int6
 	return nil
 }
 
+// UpdateMFAPolicy sets the tenant's MFA enforcement. Tightening the policy restarts
+// the grace period for members who have not enrolled yet.
+func (uc *UserManagementUseCase) UpdateMFAPolicy(ctx context.Context, tenantID int64, req *model.UpdateMFAPolicyRequest, requestorUserID int64) error {
+	// Verify requestor is owner of this tenant
+	if err := uc.verifyTenantOwner(ctx, requestorUserID, tenantID); err != nil {
+		return err
+	}
+
+	if !entity.IsValidMFAEnforcement(req.Enforcement) {
+		return fmt.Errorf("invalid MFA enforcement %q", req.Enforcement)
+	}
+	if req.GracePeriod != "" {
+		if d, err := time.ParseDuration(req.GracePeriod); err != nil || d < 0 {
+			return fmt.Errorf("invalid grace period %q", req.GracePeriod)
+		}
+	}
+
+	tenant, err := uc.tenantRepo.FindByID(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("tenant not found")
+	}
+
+	cfg := tenant.GetConfig()
+	previous := cfg.MFA
+	policy := &entity.TenantMFAConfig{
+		Enforcement: req.Enforcement,
+		GracePeriod: req.GracePeriod,
+	}
+	if previous != nil {
+		policy.EnforcedAt = previous.EnforcedAt
+	}
+	if mfaEnforcementRank(req.Enforcement) > mfaEnforcementRank(mfaEnforcementLevel(previous)) {
+		now := time.Now()
+		policy.EnforcedAt = &now
+	}
+	cfg.MFA = policy
+
+	if err := tenant.SetConfig(cfg); err != nil {
+		return fmt.Errorf("failed to encode tenant config: %w", err)
+	}
+	if err := uc.db.Save(tenant).Error; err != nil {
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	return nil
+}
+
+// GetPasswordPolicy returns the password policy in effect for the tenant
+func (uc *UserManagementUseCase) GetPasswordPolicy(ctx context.Context, tenantID int64, requestorUserID int64) (*model.PasswordPolicyResponse, error) {
+	// Verify requestor is a member of this tenant
+	if err := uc.verifyTenantMember(ctx, requestorUserID, tenantID); err != nil {
+		return nil, err
+	}
+
+	tenant, err := uc.tenantRepo.FindByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found")
+	}
+
+	policy := uc.passwordPolicy.TenantPolicy(tenant)
+	response := &model.PasswordPolicyResponse{
+		TenantID:         tenant.ID,
+		MinLength:        policy.MinLength,
+		MaxLength:        security.MaxPasswordBytes,
+		RequireUppercase: policy.RequireUppercase,
+		RequireLowercase: policy.RequireLowercase,
+		RequireDigit:     policy.RequireDigit,
+		RequireSymbol:    policy.RequireSymbol,
+		HistorySize:      policy.HistorySize,
+	}
+	if policy.MaxAge > 0 {
+		response.MaxAge = policy.MaxAge.String()
+	}
+
+	return response, nil
+}
+
+// UpdatePasswordPolicy replaces the tenant's overrides of the platform password policy.
+// Members' current passwords are not checked again; the rules apply to the next change,
+// and a shorter maximum age takes effect at the next login.
+func (uc *UserManagementUseCase) UpdatePasswordPolicy(ctx context.Context, tenantID int64, req *model.UpdatePasswordPolicyRequest, requestorUserID int64) error {
+	// Verify requestor is owner of this tenant
+	if err := uc.verifyTenantOwner(ctx, requestorUserID, tenantID); err != nil {
+		return err
+	}
+
+	if req.MaxAge != "" {
+		if d, err := time.ParseDuration(req.MaxAge); err != nil || d < 0 {
+			return fmt.Errorf("invalid max age %q", req.MaxAge)
+		}
+	}
+
+	tenant, err := uc.tenantRepo.FindByID(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("tenant not found")
+	}
+
+	cfg := tenant.GetConfig()
+	cfg.PasswordPolicy = &entity.TenantPasswordPolicy{
+		MinLength:        req.MinLength,
+		RequireUppercase: req.RequireUppercase,
+		RequireLowercase: req.RequireLowercase,
+		RequireDigit:     req.RequireDigit,
+		RequireSymbol:    req.RequireSymbol,
+		BannedWords:      req.BannedWords,
+		HistorySize:      req.HistorySize,
+		MaxAge:           req.MaxAge,
+	}
+
+	if err := tenant.SetConfig(cfg); err != nil {
+		return fmt.Errorf("failed to encode tenant config: %w", err)
+	}
+	if err := uc.db.Save(tenant).Error; err != nil {
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	return nil
+}
+
+// GetMFACompliance lists the tenant's members with their MFA enrollment and whether
+// they comply with the tenant's policy. unenrolledOnly drops members with MFA enabled.
+func (uc *UserManagementUseCase) GetMFACompliance(ctx context.Context, tenantID int64, requestorUserID int64, unenrolledOnly bool) (*model.MFAComplianceResponse, error) {
+	// Verify requestor is an admin of this tenant
+	if err := uc.verifyTenantAdmin(ctx, requestorUserID, tenantID); err != nil {
+		return nil, err
+	}
+
+	tenant, err := uc.tenantRepo.FindByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found")
+	}
+
+	policy := tenant.GetConfig().MFA
+	if policy == nil {
+		policy = &entity.TenantMFAConfig{Enforcement: entity.MFAEnforcementOff}
+	}
+
+	// Get all memberships for this tenant
+	var memberships []entity.Membership
+	if err := uc.db.Where("tenant_id = ?", tenantID).Find(&memberships).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch memberships: %w", err)
+	}
+
+	userIDs := make([]int64, 0, len(memberships))
+	for _, m := range memberships {
+		userIDs = append(userIDs, m.UserID)
+	}
+	enrolled, err := uc.mfaRepo.FindEnabledUserIDs(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch MFA enrollment: %w", err)
+	}
+
+	now := time.Now()
+	members := make([]model.MFAComplianceMember, 0, len(memberships))
+	for _, m := range memberships {
+		if unenrolledOnly && enrolled[m.UserID] {
+			continue
+		}
+
+		user, err := uc.userRepo.FindByID(ctx, m.UserID)
+		if err != nil {
+			continue
+		}
+
+		role, err := uc.tenantRoleRepo.FindByID(ctx, m.RoleID)
+		if err != nil {
+			continue
+		}
+
+		member := model.MFAComplianceMember{
+			UserID:    user.ID,
+			UserCode:  user.Code,
+			UserName:  user.Name,
+			UserEmail: user.Email,
+			RoleName:  role.Name,
+			Enrolled:  enrolled[m.UserID],
+			Required:  policy.RequiresMFA(role.Name),
+			Compliant: true,
+		}
+		if member.Required && !member.Enrolled {
+			deadline := policy.EnrollmentDeadline(m.CreatedAt)
+			member.Deadline = deadline.Unix()
+			member.Compliant = now.Before(deadline)
+		}
+		members = append(members, member)
+	}
+
+	resp := &model.MFAComplianceResponse{
+		TenantID:    tenant.ID,
+		Enforcement: mfaEnforcementLevel(policy),
+		GracePeriod: policy.GracePeriod,
+		Members:     members,
+	}
+	if policy.EnforcedAt != nil {
+		enforcedAt := policy.EnforcedAt.Unix()
+		resp.EnforcedAt = &enforcedAt
+	}
+
+	return resp, nil
+}
+
+// mfaEnforcementLevel returns the policy's enforcement, treating a missing policy as off
+// UnlockMember lifts a member's login lockout before it expires on its own
+func (uc *UserManagementUseCase) UnlockMember(ctx context.Context, tenantID int64, userID int64, requestorUserID int64) error {
+	// Verify requestor has permission
+	if err := uc.verifyTenantAdmin(ctx, requestorUserID, tenantID); err != nil {
+		return err
+	}
+
+	// Admins may only unlock members of their own tenant
+	if _, err := uc.membershipRepo.FindByUserAndTenant(ctx, userID, tenantID); err != nil {
+		return fmt.Errorf("user is not a member of this tenant")
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.ErrUserNotFound
+	}
+
+	return uc.lockoutUseCase.Unlock(ctx, user.Email)
+}
+
+func mfaEnforcementLevel(policy *entity.TenantMFAConfig) string {
+	if policy == nil || policy.Enforcement == "" {
+		return entity.MFAEnforcementOff
+	}
+	return policy.Enforcement
+}
+
+// mfaEnforcementRank orders enforcement levels from weakest to strictest
+func mfaEnforcementRank(level string) int {
+	switch level {
+	case entity.MFAEnforcementAdmins:
+		return 1
+	case entity.MFAEnforcementAll:
+		return 2
+	default:
+		return 0
+	}
+}
+
 // Helper functions for authorization checks
 
 func (uc *UserManagementUseCase) verifyTenantMember(ctx context.Context, userID int64, tenantID int64) error {
@@ -397,7 +684,7 @@ func (uc *UserManagementUseCase) verifyTenantAdmin(ctx context.Context, userID i
 				continue
 			}
 			// Admin roles: Tenant Owner, Administrator, Super Administrator
-			if role.Name == "Tenant Owner" || role.Name == "Administrator" || role.Name == "Super Administrator" {
+			if entity.IsAdminRoleName(role.Name) {
 				return nil
 			}
 		}
@@ -2,6 +2,9 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 	"log"
 
@@ -10,6 +13,7 @@ import (
 	"go-gin-clean/internal/gateway/media"
 	"go-gin-clean/internal/gateway/messaging"
 	"go-gin-clean/internal/gateway/security"
+	"go-gin-clean/internal/gateway/session"
 	"go-gin-clean/internal/model"
 	"go-gin-clean/internal/repository"
 	"go-gin-clean/pkg/config"
@@ -21,14 +25,19 @@ import (
 type UserUseCase struct {
 	userRepo         *repository.UserRepository
 	refreshTokenRepo *repository.RefreshTokenRepository
+	mfaRepo          *repository.MFARepository
 
 	jwtService          *security.JWTService
-	bcryptService       *security.BcryptService
+	passwordService     *security.PasswordService
 	oauthService        *security.OAuthService
 	aesService          *security.AESService
 	cloudinaryService   *media.CloudinaryService
 	localStorageService *media.LocalStorageService
 	redisService        *cache.RedisService
+	sessionService      *session.SessionService
+	lockoutUseCase      *LockoutUseCase
+	passwordPolicy      *PasswordPolicyUseCase
+	oauthCfg            *config.OAuthConfig
 
 	UserPublisher *messaging.UserPublisher
 }
@@ -36,25 +45,35 @@ type UserUseCase struct {
 func NewUserUseCase(
 	userRepo *repository.UserRepository,
 	refreshTokenRepo *repository.RefreshTokenRepository,
+	mfaRepo *repository.MFARepository,
 	jwtService *security.JWTService,
-	bcryptService *security.BcryptService,
+	passwordService *security.PasswordService,
 	oauthService *security.OAuthService,
 	aesService *security.AESService,
 	cloudinaryService *media.CloudinaryService,
 	localStorageService *media.LocalStorageService,
 	redisService *cache.RedisService,
+	sessionService *session.SessionService,
+	lockoutUseCase *LockoutUseCase,
+	passwordPolicy *PasswordPolicyUseCase,
+	oauthCfg *config.OAuthConfig,
 
 	UserPublisher *messaging.UserPublisher,
 ) *UserUseCase {
 	return &UserUseCase{
 		userRepo:          userRepo,
 		refreshTokenRepo:  refreshTokenRepo,
+		mfaRepo:           mfaRepo,
 		jwtService:        jwtService,
-		bcryptService:     bcryptService,
+		passwordService:   passwordService,
 		oauthService:      oauthService,
 		aesService:        aesService,
 		cloudinaryService: cloudinaryService,
 		redisService:      redisService,
+		sessionService:    sessionService,
+		lockoutUseCase:    lockoutUseCase,
+		passwordPolicy:    passwordPolicy,
+		oauthCfg:          oauthCfg,
 		UserPublisher:     UserPublisher,
 	}
 }
@@ -71,35 +90,105 @@ func formatUserInfo(user *entity.User) *model.UserInfo {
 	}
 }
 
+// ListOAuthProviders returns the identity providers users can log in with
+func (u *UserUseCase) ListOAuthProviders() *model.OAuthProvidersResponse {
+	return &model.OAuthProvidersResponse{
+		Providers: u.oauthService.Providers(),
+	}
+}
+
+// GetOAuthLoginURL starts a login at an external identity provider. The state, PKCE
+// verifier and nonce are kept in Redis until the provider redirects back. The returned
+// login nonce must accompany the login code, so a code obtained by someone else and
+// planted in the browser's callback URL cannot log the browser in (login CSRF).
 func (u *UserUseCase) GetOAuthLoginURL(ctx context.Context, provider string, appID string) (*model.OAuthUrlResponse, error) {
-	var authURL string
+	stateValue, err := u.oauthService.NewState(provider, appID)
+	if err != nil {
+		return nil, err
+	}
 
-	switch provider {
-	case "google":
-		authURL = u.oauthService.GetGoogleAuthURL(appID)
-	default:
-		return nil, errors.ErrInvalidOAuthProvider
+	loginNonce, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	stateValue.LoginNonceHash = hashLoginNonce(loginNonce)
+
+	ttl := u.oauthCfg.StateTTL
+	if ttl == 0 {
+		ttl = session.DefaultOAuthStateTTL
+	}
+
+	state, err := u.sessionService.CreateOAuthState(ctx, stateValue, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := u.oauthService.GetAuthURL(ctx, state, stateValue)
+	if err != nil {
+		return nil, err
 	}
 
 	return &model.OAuthUrlResponse{
-		AuthURL: authURL,
+		AuthURL:    authURL,
+		LoginNonce: loginNonce,
 	}, nil
 }
 
-func (u *UserUseCase) HandleOAuthCallback(ctx context.Context, req *model.OAuthCallbackRequest) (*model.LoginResponse, string, error) {
-	var user *entity.User
-	var err error
-	var appID string
+// hashLoginNonce returns the form of a login nonce kept in Redis
+func hashLoginNonce(loginNonce string) string {
+	sum := sha256.Sum256([]byte(loginNonce))
+	return hex.EncodeToString(sum[:])
+}
+
+// loginNonceMatches reports whether loginNonce is the one the external login was started with
+func loginNonceMatches(login *model.OAuthLoginValue, loginNonce string) bool {
+	if login.LoginNonceHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashLoginNonce(loginNonce)), []byte(login.LoginNonceHash)) == 1
+}
+
+// HandleOAuthCallback finishes a login at an external identity provider and returns
+// where to send the browser: the app's frontend with a one-time login code, which is
+// exchanged for a session at /auth/oauth2/exchange, or with an error. The state is
+// consumed first, so a replayed callback fails even if the code exchange does.
+func (u *UserUseCase) HandleOAuthCallback(ctx context.Context, req *model.OAuthCallbackRequest) string {
+	stateValue, err := u.sessionService.ConsumeOAuthState(ctx, req.State, req.Provider)
+	appID := ""
+	if err == nil {
+		appID = stateValue.AppID
+	}
+	callbackURL := u.oauthService.GetFrontendURL(appID) + u.oauthCfg.FrontendCallbackPath
+
+	fail := func(code, description string) string {
+		return appendQuery(callbackURL, map[string]string{
+			"error":             code,
+			"error_description": description,
+		})
+	}
 
-	switch req.Provider {
-	case "google":
-		user, appID, err = u.oauthService.HandleGoogleCallback(ctx, req.State, req.Code)
-	default:
-		return nil, appID, errors.ErrInvalidOAuthProvider
+	if err == errors.ErrOAuthStateInvalid {
+		return fail("access_denied", err.Error())
+	}
+	if err != nil {
+		log.Printf("Failed to consume OAuth state: %v", err)
+		return fail("server_error", "")
+	}
+	if req.Error != "" {
+		return fail("access_denied", "login at "+req.Provider+" failed: "+req.Error)
 	}
 
+	user, err := u.oauthService.HandleCallback(ctx, stateValue, req.Code)
+	if err == errors.ErrInvalidOAuthProvider ||
+		err == errors.ErrOAuthEmailMissing ||
+		err == errors.ErrOAuthEmailNotVerified ||
+		err == errors.ErrOAuthEmailDomainNotAllowed ||
+		err == errors.ErrOAuthIDTokenInvalid {
+		return fail("access_denied", err.Error())
+	}
 	if err != nil {
-		return nil, appID, fmt.Errorf("OAuth callback error: %w", err)
+		log.Printf("OAuth callback error from %s: %v", req.Provider, err)
+		return fail("server_error", "")
 	}
 
 	existingUser, err := u.userRepo.FindByOAuthID(ctx, req.Provider, user.OAuthID)
@@ -108,6 +197,12 @@ func (u *UserUseCase) HandleOAuthCallback(ctx context.Context, req *model.OAuthC
 	} else {
 		existingUserByEmail, err := u.userRepo.FindByEmail(ctx, user.Email)
 		if err == nil {
+			// Linking by email hands the account to whoever controls the address at
+			// the provider, so only providers trusted to own their emails may do it
+			if !u.oauthService.TrustsEmail(req.Provider) {
+				return fail("access_denied", errors.ErrOAuthEmailInUse.Error())
+			}
+
 			err = u.userRepo.UpdateOAuthInfo(
 				ctx,
 				existingUserByEmail.ID,
@@ -115,48 +210,50 @@ func (u *UserUseCase) HandleOAuthCallback(ctx context.Context, req *model.OAuthC
 				user.OAuthID,
 			)
 			if err != nil {
-				return nil, "", fmt.Errorf("failed to link OAuth to existing account: %w", err)
+				log.Printf("Failed to link OAuth to existing account: %v", err)
+				return fail("server_error", "")
 			}
 
 			user = existingUserByEmail
 		} else {
 			user, err = u.userRepo.Create(ctx, user)
 			if err != nil {
-				return nil, "", fmt.Errorf("failed to create user: %w", err)
+				log.Printf("Failed to create user: %v", err)
+				return fail("server_error", "")
 			}
 		}
 	}
 
-	accessToken, _, err := u.jwtService.GenerateAccessToken(user)
-	if err != nil {
-		return nil, "", err
+	if !user.IsActive {
+		return fail("access_denied", errors.ErrUserInactive.Error())
 	}
 
-refreshToken, expiryAt, err := u.jwtService.GenerateRefreshToken(user.ID)
-	if err != nil {
-		return nil, "", err
+	ttl := u.oauthCfg.LoginCodeTTL
+	if ttl == 0 {
+		ttl = session.DefaultOAuthLoginCodeTTL
 	}
 
-	hashedRefreshToken, err := u.aesService.EncryptInternal(refreshToken)
+	code, err := u.sessionService.CreateOAuthLogin(ctx, &model.OAuthLoginValue{
+		UserID:         user.ID,
+		Provider:       req.Provider,
+		LoginNonceHash: stateValue.LoginNonceHash,
+	}, ttl)
 	if err != nil {
-		return nil, "", err
+		log.Printf("Failed to store OAuth login: %v", err)
+		return fail("server_error", "")
 	}
 
-	tokenData := entity.NewRefreshToken(user.ID, hashedRefreshToken, expiryAt, false, *user)
-
-	if err := u.refreshTokenRepo.Save(ctx, tokenData); err != nil {
-		return nil, "", err
-	}
-
-	return &model.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: hashedRefreshToken,
-	}, appID, nil
+	return appendQuery(callbackURL, map[string]string{"code": code})
 }
 
 func (u *UserUseCase) Login(ctx context.Context, req *model.LoginRequest) (*model.LoginResponse, error) {
+	if err := u.lockoutUseCase.Check(ctx, req.Email, req.ClientIP); err != nil {
+		return nil, err
+	}
+
 	user, err := u.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
+		u.lockoutUseCase.RecordFailure(ctx, req.Email, req.ClientIP)
 		return nil, errors.ErrUserNotFound
 	}
 
@@ -172,9 +269,30 @@ func (u *UserUseCase) Login(ctx context.Context, req *model.LoginRequest) (*mode
 		return nil, errors.ErrEmailNotVerified
 	}
 
-	if err := u.bcryptService.ValidatePassword(req.Password, user.Password); err != nil {
+	if err := u.passwordService.ValidatePassword(req.Password, user.Password); err != nil {
+		u.lockoutUseCase.RecordFailure(ctx, req.Email, req.ClientIP)
 		return nil, errors.ErrPasswordNotMatch
 	}
+	u.lockoutUseCase.RecordSuccess(ctx, req.Email)
+	upgradePasswordHash(ctx, u.userRepo, u.passwordService, user, req.Password)
+
+	// The legacy JWT login cannot issue a limited session, the password must be reset instead
+	expired, err := u.passwordPolicy.IsExpired(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, errors.ErrPasswordExpired
+	}
+
+	// The legacy JWT login has no second step, so it must not bypass TOTP or passkeys
+	mfaEnabled, err := u.mfaRepo.HasSecondFactor(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfaEnabled {
+		return nil, errors.ErrMFARequired
+	}
 
 	accessToken, _, err := u.jwtService.GenerateAccessToken(user)
 	if err != nil {
@@ -208,7 +326,11 @@ func (u *UserUseCase) Register(ctx context.Context, req *model.RegisterRequest)
 		return errors.ErrEmailAlreadyExists
 	}
 
-	hashedPassword, err := u.bcryptService.HashPassword(req.Password)
+	if err := u.passwordPolicy.ValidateNewUser(req.Name, req.Email, req.Password); err != nil {
+		return err
+	}
+
+	hashedPassword, err := u.passwordService.HashPassword(req.Password)
 	if err != nil {
 		return err
 	}
@@ -442,15 +564,24 @@ func (u *UserUseCase) ResetPassword(ctx context.Context, req *model.ResetPasswor
 		return errors.ErrUserNotFound
 	}
 
-	hashedPassword, err := u.bcryptService.HashPassword(req.NewPassword)
+	if err := u.passwordPolicy.ValidateChange(ctx, user, req.NewPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := u.passwordService.HashPassword(req.NewPassword)
 	if err != nil {
 		return err
 	}
 
+	previousHash := user.Password
 	user.SetPassword(hashedPassword)
 
-	_, err = u.userRepo.Update(ctx, user, user.Code)
-	return err
+	if _, err := u.userRepo.Update(ctx, user, user.Code); err != nil {
+		return err
+	}
+
+	u.passwordPolicy.RecordChange(ctx, user.ID, previousHash)
+	return nil
 }
 
 func (u *UserUseCase) GetAllUsers(ctx context.Context, page, pageSize int, search string) (*model.PaginationResponse[model.UserInfo], error) {
@@ -482,7 +613,11 @@ func (u *UserUseCase) CreateUser(ctx context.Context, req *model.CreateUserReque
 		return nil, errors.ErrEmailAlreadyExists
 	}
 
-	hashedPassword, err := u.bcryptService.HashPassword(req.Password)
+	if err := u.passwordPolicy.ValidateNewUser(req.Name, req.Email, req.Password); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := u.passwordService.HashPassword(req.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -549,19 +684,35 @@ func (u *UserUseCase) ChangePassword(ctx context.Context, userPKID int64, req *m
 		return errors.ErrUserNotFound
 	}
 
-	if err := u.bcryptService.ValidatePassword(req.OldPassword, user.Password); err != nil {
+	// A stolen session must not become a way to guess the password
+	if err := u.lockoutUseCase.Check(ctx, user.Email, ""); err != nil {
+		return err
+	}
+
+	if err := u.passwordService.ValidatePassword(req.OldPassword, user.Password); err != nil {
+		u.lockoutUseCase.RecordFailure(ctx, user.Email, "")
 		return errors.ErrPasswordNotMatch
 	}
+	u.lockoutUseCase.RecordSuccess(ctx, user.Email)
+
+	if err := u.passwordPolicy.ValidateChange(ctx, user, req.NewPassword); err != nil {
+		return err
+	}
 
-	hashedPassword, err := u.bcryptService.HashPassword(req.NewPassword)
+	hashedPassword, err := u.passwordService.HashPassword(req.NewPassword)
 	if err != nil {
 		return err
 	}
 
+	previousHash := user.Password
 	user.SetPassword(hashedPassword)
 
-	_, err = u.userRepo.Update(ctx, user, user.Code)
-	return err
+	if _, err := u.userRepo.Update(ctx, user, user.Code); err != nil {
+		return err
+	}
+
+	u.passwordPolicy.RecordChange(ctx, user.ID, previousHash)
+	return nil
 }
 
 func (u *UserUseCase) ChangeStatus(ctx context.Context, code string, req model.ChangeUserStatusRequest) error {
@@ -573,7 +724,16 @@ func (u *UserUseCase) ChangeStatus(ctx context.Context, code string, req model.C
 	user.IsActive = req.IsActive
 
 	_, err = u.userRepo.Update(ctx, user, user.Code)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// A deactivated user loses access immediately, not when their sessions expire
+	if !user.IsActive {
+		return u.sessionService.DeleteAllUserSessions(ctx, user.ID)
+	}
+
+	return nil
 }
 
 func (u *UserUseCase) DeleteUser(ctx context.Context, code string) error {
@@ -587,5 +747,10 @@ func (u *UserUseCase) DeleteUser(ctx context.Context, code string) error {
 		return err
 	}
 
+	err = u.sessionService.DeleteAllUserSessions(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
 	return u.userRepo.Delete(ctx, user.Code)
 }
@@ -0,0 +1,6 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_signing_keys_expires_at;
+DROP INDEX IF EXISTS idx_signing_keys_activated_at;
+
+-- Drop signing_keys table
+DROP TABLE IF EXISTS signing_keys;
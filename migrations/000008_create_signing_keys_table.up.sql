@@ -0,0 +1,19 @@
+-- Create signing_keys table (asymmetric key ring for JWTs)
+CREATE TABLE signing_keys (
+    id BIGSERIAL PRIMARY KEY,
+    kid VARCHAR(64) UNIQUE NOT NULL,
+    algorithm VARCHAR(16) NOT NULL,
+    private_key TEXT NOT NULL,
+    public_key TEXT NOT NULL,
+    activated_at TIMESTAMP NOT NULL,
+    retired_at TIMESTAMP DEFAULT NULL,
+    expires_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    is_deleted BOOLEAN DEFAULT FALSE
+);
+
+-- Create indexes for better query performance
+CREATE INDEX idx_signing_keys_activated_at ON signing_keys(activated_at);
+CREATE INDEX idx_signing_keys_expires_at ON signing_keys(expires_at);
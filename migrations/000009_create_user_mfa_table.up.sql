@@ -0,0 +1,28 @@
+-- Create user_mfa table (TOTP second factor, one row per enrolled user)
+CREATE TABLE user_mfa (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT UNIQUE NOT NULL,
+    totp_secret TEXT NOT NULL,
+    totp_enabled BOOLEAN DEFAULT FALSE NOT NULL,
+    totp_confirmed_at TIMESTAMP DEFAULT NULL,
+    totp_last_step BIGINT DEFAULT 0 NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    is_deleted BOOLEAN DEFAULT FALSE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Create mfa_recovery_codes table (one-time codes, stored as SHA-256 hashes)
+CREATE TABLE mfa_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(user_id, code_hash)
+);
+
+-- Create indexes
+CREATE INDEX idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_webauthn_credentials_user_id;
+
+-- Drop WebAuthn table
+DROP TABLE IF EXISTS webauthn_credentials;
@@ -0,0 +1,24 @@
+-- Create webauthn_credentials table (FIDO2 passkeys, a user can register several)
+CREATE TABLE webauthn_credentials (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    credential_id BYTEA UNIQUE NOT NULL,
+    public_key BYTEA NOT NULL,
+    attestation_type VARCHAR(50) NOT NULL DEFAULT '',
+    transports VARCHAR(255) NOT NULL DEFAULT '',
+    aaguid BYTEA,
+    sign_count BIGINT DEFAULT 0 NOT NULL,
+    clone_warning BOOLEAN DEFAULT FALSE NOT NULL,
+    backup_eligible BOOLEAN DEFAULT FALSE NOT NULL,
+    backup_state BOOLEAN DEFAULT FALSE NOT NULL,
+    last_used_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    is_deleted BOOLEAN DEFAULT FALSE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Create indexes
+CREATE INDEX idx_webauthn_credentials_user_id ON webauthn_credentials(user_id);
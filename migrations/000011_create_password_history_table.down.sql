@@ -0,0 +1,8 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_password_history_user_id;
+
+-- Drop password history table
+DROP TABLE IF EXISTS password_history;
+
+-- Drop password age column
+ALTER TABLE users DROP COLUMN IF EXISTS password_changed_at;
@@ -0,0 +1,14 @@
+-- Track when each password was set, for the password policy's maximum age
+ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL;
+
+-- Create password_history table (replaced password hashes, for the policy's reuse check)
+CREATE TABLE password_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Create indexes
+CREATE INDEX idx_password_history_user_id ON password_history(user_id, created_at DESC);
@@ -0,0 +1,6 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_impersonation_logs_impersonator_id;
+DROP INDEX IF EXISTS idx_impersonation_logs_user_id;
+
+-- Drop impersonation logs table
+DROP TABLE IF EXISTS impersonation_logs;
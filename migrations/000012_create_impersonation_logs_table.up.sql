@@ -0,0 +1,20 @@
+-- Create impersonation_logs table (audit trail of support staff logging in as a user)
+CREATE TABLE impersonation_logs (
+    id BIGSERIAL PRIMARY KEY,
+    impersonator_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    tenant_id BIGINT NOT NULL,
+    reason VARCHAR(500) NOT NULL,
+    session_id VARCHAR(64) NOT NULL,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent VARCHAR(500) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (impersonator_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+);
+
+-- Create indexes
+CREATE INDEX idx_impersonation_logs_user_id ON impersonation_logs(user_id, created_at DESC);
+CREATE INDEX idx_impersonation_logs_impersonator_id ON impersonation_logs(impersonator_id);
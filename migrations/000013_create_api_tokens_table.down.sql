@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_api_tokens_user_id;
+
+-- Drop api tokens table
+DROP TABLE IF EXISTS api_tokens;
@@ -0,0 +1,21 @@
+-- Create api_tokens table (personal access tokens of integrations, bound to one tenant)
+CREATE TABLE api_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    token_hint VARCHAR(16) NOT NULL,
+    permissions TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    is_deleted BOOLEAN DEFAULT FALSE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE
+);
+
+-- Create indexes
+CREATE INDEX idx_api_tokens_user_id ON api_tokens(user_id);
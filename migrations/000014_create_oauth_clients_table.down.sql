@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_oauth_clients_tenant_id;
+
+-- Drop oauth clients table
+DROP TABLE IF EXISTS oauth_clients;
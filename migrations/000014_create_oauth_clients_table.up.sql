@@ -0,0 +1,22 @@
+-- Create oauth_clients table (registered OAuth clients, e.g. ERP service accounts)
+CREATE TABLE oauth_clients (
+    id BIGSERIAL PRIMARY KEY,
+    client_id VARCHAR(64) UNIQUE NOT NULL,
+    client_secret VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(500) NOT NULL DEFAULT '',
+    redirect_uris TEXT NOT NULL DEFAULT '',
+    scopes TEXT NOT NULL DEFAULT '',
+    tenant_id BIGINT DEFAULT NULL,
+    is_active BOOLEAN DEFAULT TRUE NOT NULL,
+    created_by BIGINT DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    is_deleted BOOLEAN DEFAULT FALSE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Create indexes
+CREATE INDEX idx_oauth_clients_tenant_id ON oauth_clients(tenant_id);
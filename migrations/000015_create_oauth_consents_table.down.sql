@@ -0,0 +1,8 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_oauth_consents_client_id;
+
+-- Drop oauth consents table
+DROP TABLE IF EXISTS oauth_consents;
+
+-- Drop public client flag
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS is_public;
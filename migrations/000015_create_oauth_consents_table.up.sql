@@ -0,0 +1,20 @@
+-- Public clients (e.g. single-page ERP frontends) have no secret and rely on PKCE alone
+ALTER TABLE oauth_clients ADD COLUMN is_public BOOLEAN DEFAULT FALSE NOT NULL;
+
+-- Create oauth_consents table (scopes a user approved for an OpenID Connect client)
+CREATE TABLE oauth_consents (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    client_id BIGINT NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    is_deleted BOOLEAN DEFAULT FALSE,
+    UNIQUE (user_id, client_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (client_id) REFERENCES oauth_clients(id) ON DELETE CASCADE
+);
+
+-- Create indexes
+CREATE INDEX idx_oauth_consents_client_id ON oauth_consents(client_id);
@@ -5,20 +5,31 @@ import (
 	"go-gin-clean/pkg/utils"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Server     ServerConfig
-	Database   DatabaseConfig
-	JWT        JWTConfig
-	OAuth      OAuthConfig
-	Mailer     MailerConfig
-	AES        AESConfig
-	RabbitMQ   RabbitMQConfig
-	Cloudinary CloudinaryConfig
-	Redis      RedisConfig
-	Kong       KongConfig
+	Server        ServerConfig
+	Database      DatabaseConfig
+	JWT           JWTConfig
+	OAuth         OAuthConfig
+	Mailer        MailerConfig
+	AES           AESConfig
+	RabbitMQ      RabbitMQConfig
+	Cloudinary    CloudinaryConfig
+	Redis         RedisConfig
+	Kong          KongConfig
+	Session       SessionConfig
+	Introspection IntrospectionConfig
+	MFA           MFAConfig
+	WebAuthn      WebAuthnConfig
+	Lockout       LockoutConfig
+	RateLimit     RateLimitConfig
+	Password      PasswordPolicyConfig
+	PasswordHash  PasswordHashConfig
+	APIToken      APITokenConfig
+	OAuthServer   OAuthServerConfig
 }
 
 type ServerConfig struct {
@@ -28,6 +39,7 @@ type ServerConfig struct {
 	AppUrl         string
 	Timeout        int
 	AllowedOrigins []string
+	TrustedProxies []string // CIDRs whose X-Forwarded-For is believed (Kong), none by default
 }
 
 type DatabaseConfig struct {
@@ -46,17 +58,54 @@ type JWTConfig struct {
 	RefreshTokenSecret string
 	AccessTokenExpiry  time.Duration
 	RefreshTokenExpiry time.Duration
+
+	// Short-lived JWTs minted from phantom sessions for upstream ERP services
+	SessionTokenExpiry   time.Duration
+	SessionTokenAudience string
+
+	// Asymmetric signing key ring, published at /.well-known/jwks.json
+	SigningAlgorithm    string        // RS256, ES256 or EdDSA
+	KeyRotationInterval time.Duration // How long a key signs before the next one takes over
+	KeyPublishLead      time.Duration // How long a new key is published before it signs
+	KeyRetention        time.Duration // How long a retired key stays published for verification
 }
 
 type OAuthConfig struct {
-	GoogleClientID       string
-	GoogleClientSecret   string
-	GoogleRedirectURL    string
-	GoogleAllowedOrigins []string
+	Providers    []OAuthProviderConfig // External identity providers users can log in with
+	StateTTL     time.Duration         // How long a login at a provider may take
+	LoginCodeTTL time.Duration         // How long the frontend has to exchange the login code
+
+	FrontendURLs         map[string]string
+	FrontendCallbackPath string // Frontend page receiving the login code, appended to the app's URL
+	DefaultAppID         string
+}
+
+// OAuthProviderConfig describes an external identity provider. OpenID Connect providers
+// only need an issuer, their endpoints are discovered; plain OAuth2 providers set the
+// endpoint URLs instead. Claims name the user info fields holding the user's details.
+type OAuthProviderConfig struct {
+	Name         string // Used in the login request and the callback path
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	AuthURL     string // Optional, overrides discovery
+	TokenURL    string // Optional, overrides discovery
+	UserInfoURL string // Optional, overrides discovery
+
+	SubjectClaim string
+	EmailClaim   string
+	NameClaim    string
+	PictureClaim string
+
+	AllowedDomains []string // Email domains allowed to log in, empty allows any
+	AllowedOrigins []string
 
-	OAuthStateString string
-	FrontendURLs     map[string]string
-	DefaultAppID     string
+	// TrustEmail accepts emails without an email_verified claim and links them to
+	// existing accounts. Only for providers that own the addresses they issue.
+	TrustEmail bool
 }
 
 type MailerConfig struct {
@@ -96,6 +145,107 @@ type KongConfig struct {
 	Timeout  int
 }
 
+type SessionConfig struct {
+	IdleTimeout      time.Duration // Sliding expiry, extended while the session is in use
+	MaxLifetime      time.Duration // Absolute limit since login, never extended
+	RefreshTokenTTL  time.Duration
+	ImpersonationTTL time.Duration // Lifetime of sessions of an admin acting as a user, never extended
+}
+
+type IntrospectionConfig struct {
+	Issuer   string
+	Clients  map[string]string // Resource servers allowed to call /oauth2/introspect (client_id -> secret)
+	IssueJWT bool              // Mint a signed session JWT for Kong to forward upstream
+
+	// Optional in-process cache of hot reference tokens, invalidated via Redis pub/sub
+	CacheEnabled bool
+	CacheSize    int
+	CacheTTL     time.Duration
+}
+
+type MFAConfig struct {
+	Issuer            string        // Shown as the account issuer in authenticator apps
+	ChallengeTTL      time.Duration // How long a password-verified login may wait for its second factor
+	MaxAttempts       int           // Wrong codes allowed per challenge before it is discarded
+	RecoveryCodeCount int
+}
+
+type WebAuthnConfig struct {
+	RPID          string        // Relying party ID, the domain passkeys are bound to (no scheme or port)
+	RPDisplayName string        // Shown by the browser during registration
+	RPOrigins     []string      // Frontend origins allowed to run the ceremonies
+	CeremonyTTL   time.Duration // How long a registration or login ceremony may take
+}
+
+type LockoutConfig struct {
+	MaxAccountFailures int           // Wrong passwords for one account before it is locked
+	MaxIPFailures      int           // Wrong passwords from one IP, across accounts, before it is locked
+	FailureWindow      time.Duration // Failures are forgotten after this long without another one
+	LockoutDuration    time.Duration // Locks lift automatically after this
+	BaseDelay          time.Duration // Wait after the first failure, doubled for every further one
+	MaxDelay           time.Duration
+}
+
+// PasswordPolicyConfig is the platform password policy; tenants may override it
+type PasswordPolicyConfig struct {
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	BannedWords      []string      // Passwords containing one of these, in any case, are refused
+	HistorySize      int           // How many previous passwords may not be reused, 0 allows reuse
+	MaxAge           time.Duration // Older passwords must be changed at the next login, 0 never expires
+	BreachedCorpus   string        // HIBP-format file or directory of breached password hashes, empty turns screening off
+	BreachedMinCount int           // Breached passwords seen fewer times than this are still allowed
+}
+
+// PasswordHashConfig selects how new passwords are hashed. Hashes made with another
+// algorithm or weaker parameters are upgraded at the next successful login.
+type PasswordHashConfig struct {
+	Algorithm         string // argon2id or bcrypt
+	Argon2Memory      uint32 // KiB
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	BcryptCost        int
+}
+
+// OAuthServerConfig configures the portal acting as an OAuth server and OpenID Connect
+// provider for registered clients
+type OAuthServerConfig struct {
+	ClientTokenTTL time.Duration // Lifetime of client credentials access tokens
+
+	Issuer                  string        // Public base URL of the portal, the iss of ID tokens
+	ConsentURL              string        // Frontend page where users log in and approve clients
+	AuthorizationRequestTTL time.Duration // How long a user has to approve a client
+	AuthorizationCodeTTL    time.Duration
+	IDTokenTTL              time.Duration
+}
+
+type APITokenConfig struct {
+	DefaultTTL time.Duration // Expiry of tokens created without one
+	MaxTTL     time.Duration
+	MaxPerUser int
+}
+
+// RateLimitRule allows Limit requests per Window. A zero limit turns the rule off.
+type RateLimitRule struct {
+	Limit  int
+	Window time.Duration
+}
+
+type RateLimitConfig struct {
+	Enabled      bool
+	Login        RateLimitRule // Per client IP on login and second factor endpoints
+	LoginAccount RateLimitRule // Per email on the same endpoints
+	Register     RateLimitRule // Per client IP on sign-up
+	Email        RateLimitRule // Per client IP on endpoints that send email
+	EmailAccount RateLimitRule // Per recipient on the same endpoints
+	Token        RateLimitRule // Per client IP on refresh, verification and reset token endpoints
+	Introspect   RateLimitRule // Per introspected token for introspection clients (Kong calls them on every request), per client IP otherwise
+	Tenant       RateLimitRule // Per tenant on authenticated management endpoints
+}
+
 func Load() (*Config, error) {
 	return &Config{
 		Server: ServerConfig{
@@ -105,6 +255,7 @@ func Load() (*Config, error) {
 			AppUrl:         getEnv("FRONTEND_URL", "http://localhost:8080"),
 			Timeout:        getEnvAsInt("TIMEOUT", 30),
 			AllowedOrigins: utils.ParseAllowedOrigins(getEnv("ALLOWED_ORIGINS", "*")),
+			TrustedProxies: utils.ParseAllowedOrigins(getEnv("TRUSTED_PROXIES", "")),
 		},
 		Database: DatabaseConfig{
 			Host:         getEnv("DB_HOST", "localhost"),
@@ -121,16 +272,23 @@ func Load() (*Config, error) {
 			RefreshTokenSecret: getEnv("JWT_REFRESH_SECRET", "your-refresh-secret-key"),
 			AccessTokenExpiry:  getEnvAsDuration("JWT_ACCESS_EXPIRY", 1*time.Hour),
 			RefreshTokenExpiry: getEnvAsDuration("JWT_REFRESH_EXPIRY", 7*24*time.Hour),
+
+			SessionTokenExpiry:   getEnvAsDuration("JWT_SESSION_EXPIRY", 5*time.Minute),
+			SessionTokenAudience: getEnv("JWT_SESSION_AUDIENCE", "erp-services"),
+
+			SigningAlgorithm:    getEnv("JWT_SIGNING_ALG", "RS256"),
+			KeyRotationInterval: getEnvAsDuration("JWT_KEY_ROTATION_INTERVAL", 30*24*time.Hour),
+			KeyPublishLead:      getEnvAsDuration("JWT_KEY_PUBLISH_LEAD", 1*time.Hour),
+			KeyRetention:        getEnvAsDuration("JWT_KEY_RETENTION", 24*time.Hour),
 		},
 		OAuth: OAuthConfig{
-			GoogleClientID:       getEnv("GOOGLE_CLIENT_ID", "your-google-client-id"),
-			GoogleClientSecret:   getEnv("GOOGLE_CLIENT_SECRET", "your-google-client-secret"),
-			GoogleRedirectURL:    getEnv("GOOGLE_REDIRECT_URL", "http://localhost:3000/oauth/callback/google"),
-			GoogleAllowedOrigins: utils.ParseAllowedOrigins(getEnv("GOOGLE_ALLOWED_ORIGINS", "http://localhost:5000")),
+			Providers:    loadOAuthProviders(),
+			StateTTL:     getEnvAsDuration("OAUTH_STATE_TTL", 10*time.Minute),
+			LoginCodeTTL: getEnvAsDuration("OAUTH_LOGIN_CODE_TTL", 1*time.Minute),
 
-			OAuthStateString: getEnv("OAUTH_STATE_STRING", "your-random-state-string"),
-			FrontendURLs:     utils.ParseFrontendURLs(getEnv("FRONTEND_URL", "http://localhost:5000:default")),
-			DefaultAppID:     getEnv("DEFAULT_APP_ID", "default"),
+			FrontendURLs:         utils.ParseFrontendURLs(getEnv("FRONTEND_URL", "http://localhost:5000:default")),
+			FrontendCallbackPath: getEnv("OAUTH_FRONTEND_CALLBACK_PATH", "/oauth/callback"),
+			DefaultAppID:         getEnv("DEFAULT_APP_ID", "default"),
 		},
 		Mailer: MailerConfig{
 			Host:     getEnv("MAILER_HOST", "smtp.example.com"),
@@ -162,6 +320,84 @@ func Load() (*Config, error) {
 			AdminURL: getEnv("KONG_ADMIN_URL", "http://localhost:8001"),
 			Timeout:  getEnvAsInt("KONG_TIMEOUT", 30),
 		},
+		Session: SessionConfig{
+			IdleTimeout:      getEnvAsDuration("SESSION_IDLE_TIMEOUT", 30*time.Minute),
+			MaxLifetime:      getEnvAsDuration("SESSION_MAX_LIFETIME", 24*time.Hour),
+			RefreshTokenTTL:  getEnvAsDuration("SESSION_REFRESH_TTL", 7*24*time.Hour),
+			ImpersonationTTL: getEnvAsDuration("SESSION_IMPERSONATION_TTL", 30*time.Minute),
+		},
+		Introspection: IntrospectionConfig{
+			Issuer:   getEnv("INTROSPECTION_ISSUER", "erp-portal"),
+			Clients:  utils.ParseClientCredentials(getEnv("INTROSPECTION_CLIENTS", "")),
+			IssueJWT: getEnvAsBool("INTROSPECTION_ISSUE_JWT", false),
+
+			CacheEnabled: getEnvAsBool("INTROSPECTION_CACHE_ENABLED", false),
+			CacheSize:    getEnvAsInt("INTROSPECTION_CACHE_SIZE", 10000),
+			CacheTTL:     getEnvAsDuration("INTROSPECTION_CACHE_TTL", 5*time.Second),
+		},
+		MFA: MFAConfig{
+			Issuer:            getEnv("MFA_ISSUER", "ERP Portal"),
+			ChallengeTTL:      getEnvAsDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+			MaxAttempts:       getEnvAsInt("MFA_MAX_ATTEMPTS", 5),
+			RecoveryCodeCount: getEnvAsInt("MFA_RECOVERY_CODE_COUNT", 10),
+		},
+		WebAuthn: WebAuthnConfig{
+			RPID:          getEnv("WEBAUTHN_RP_ID", "localhost"),
+			RPDisplayName: getEnv("WEBAUTHN_RP_NAME", "ERP Portal"),
+			RPOrigins:     utils.ParseAllowedOrigins(getEnv("WEBAUTHN_RP_ORIGINS", "http://localhost:3000")),
+			CeremonyTTL:   getEnvAsDuration("WEBAUTHN_CEREMONY_TTL", 5*time.Minute),
+		},
+		Lockout: LockoutConfig{
+			MaxAccountFailures: getEnvAsInt("LOGIN_MAX_ACCOUNT_FAILURES", 5),
+			MaxIPFailures:      getEnvAsInt("LOGIN_MAX_IP_FAILURES", 50),
+			FailureWindow:      getEnvAsDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+			LockoutDuration:    getEnvAsDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+			BaseDelay:          getEnvAsDuration("LOGIN_BASE_DELAY", 1*time.Second),
+			MaxDelay:           getEnvAsDuration("LOGIN_MAX_DELAY", 30*time.Second),
+		},
+		Password: PasswordPolicyConfig{
+			MinLength:        getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+			RequireUppercase: getEnvAsBool("PASSWORD_REQUIRE_UPPERCASE", true),
+			RequireLowercase: getEnvAsBool("PASSWORD_REQUIRE_LOWERCASE", true),
+			RequireDigit:     getEnvAsBool("PASSWORD_REQUIRE_DIGIT", true),
+			RequireSymbol:    getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false),
+			BannedWords:      utils.ParseAllowedOrigins(getEnv("PASSWORD_BANNED_WORDS", "password,qwerty,letmein,welcome,123456")),
+			HistorySize:      getEnvAsInt("PASSWORD_HISTORY_SIZE", 5),
+			MaxAge:           getEnvAsDuration("PASSWORD_MAX_AGE", 0),
+			BreachedCorpus:   getEnv("PASSWORD_BREACHED_CORPUS", ""),
+			BreachedMinCount: getEnvAsInt("PASSWORD_BREACHED_MIN_COUNT", 1),
+		},
+		PasswordHash: PasswordHashConfig{
+			Algorithm:         getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			Argon2Memory:      uint32(getEnvAsInt("PASSWORD_ARGON2_MEMORY", 64*1024)),
+			Argon2Iterations:  uint32(getEnvAsInt("PASSWORD_ARGON2_ITERATIONS", 3)),
+			Argon2Parallelism: uint8(getEnvAsInt("PASSWORD_ARGON2_PARALLELISM", 4)),
+			BcryptCost:        getEnvAsInt("PASSWORD_BCRYPT_COST", 10),
+		},
+		APIToken: APITokenConfig{
+			DefaultTTL: getEnvAsDuration("API_TOKEN_DEFAULT_TTL", 90*24*time.Hour),
+			MaxTTL:     getEnvAsDuration("API_TOKEN_MAX_TTL", 365*24*time.Hour),
+			MaxPerUser: getEnvAsInt("API_TOKEN_MAX_PER_USER", 20),
+		},
+		OAuthServer: OAuthServerConfig{
+			ClientTokenTTL:          getEnvAsDuration("OAUTH_CLIENT_TOKEN_TTL", time.Hour),
+			Issuer:                  strings.TrimSuffix(getEnv("OIDC_ISSUER", "http://localhost:8080"), "/"),
+			ConsentURL:              getEnv("OIDC_CONSENT_URL", "http://localhost:5000/oauth/consent"),
+			AuthorizationRequestTTL: getEnvAsDuration("OIDC_AUTHORIZATION_REQUEST_TTL", 10*time.Minute),
+			AuthorizationCodeTTL:    getEnvAsDuration("OIDC_AUTHORIZATION_CODE_TTL", time.Minute),
+			IDTokenTTL:              getEnvAsDuration("OIDC_ID_TOKEN_TTL", time.Hour),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:      getEnvAsBool("RATE_LIMIT_ENABLED", true),
+			Login:        getEnvAsRateLimit("RATE_LIMIT_LOGIN", 30, time.Minute),
+			LoginAccount: getEnvAsRateLimit("RATE_LIMIT_LOGIN_ACCOUNT", 10, time.Minute),
+			Register:     getEnvAsRateLimit("RATE_LIMIT_REGISTER", 5, time.Hour),
+			Email:        getEnvAsRateLimit("RATE_LIMIT_EMAIL", 10, time.Hour),
+			EmailAccount: getEnvAsRateLimit("RATE_LIMIT_EMAIL_ACCOUNT", 3, time.Hour),
+			Token:        getEnvAsRateLimit("RATE_LIMIT_TOKEN", 30, time.Minute),
+			Introspect:   getEnvAsRateLimit("RATE_LIMIT_INTROSPECT", 6000, time.Minute),
+			Tenant:       getEnvAsRateLimit("RATE_LIMIT_TENANT", 600, time.Minute),
+		},
 	}, nil
 }
 
@@ -182,6 +418,58 @@ func GetAppURL() string {
 	return getEnv("FRONTEND_URL", "http://localhost:5000")
 }
 
+// loadOAuthProviders reads the identity providers named in OAUTH_PROVIDERS, each
+// configured by OAUTH_<NAME>_* variables. Google keeps its built-in issuer and the
+// older GOOGLE_* variables as defaults.
+func loadOAuthProviders() []OAuthProviderConfig {
+	var providers []OAuthProviderConfig
+	for _, name := range strings.Split(getEnv("OAUTH_PROVIDERS", "google"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OAUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+		defaults := OAuthProviderConfig{
+			RedirectURL:    "http://localhost:8000/api/v1/auth/oauth2/" + name + "/callback",
+			AllowedOrigins: []string{"http://localhost:5000"},
+		}
+		if name == "google" {
+			defaults = OAuthProviderConfig{
+				Issuer:         "https://accounts.google.com",
+				ClientID:       getEnv("GOOGLE_CLIENT_ID", "your-google-client-id"),
+				ClientSecret:   getEnv("GOOGLE_CLIENT_SECRET", "your-google-client-secret"),
+				RedirectURL:    getEnv("GOOGLE_REDIRECT_URL", "http://localhost:3000/oauth/callback/google"),
+				AllowedOrigins: utils.ParseAllowedOrigins(getEnv("GOOGLE_ALLOWED_ORIGINS", "http://localhost:5000")),
+			}
+		}
+
+		providers = append(providers, OAuthProviderConfig{
+			Name:         name,
+			Issuer:       strings.TrimSuffix(getEnv(prefix+"ISSUER", defaults.Issuer), "/"),
+			ClientID:     getEnv(prefix+"CLIENT_ID", defaults.ClientID),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", defaults.ClientSecret),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", defaults.RedirectURL),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+
+			AuthURL:     getEnv(prefix+"AUTH_URL", ""),
+			TokenURL:    getEnv(prefix+"TOKEN_URL", ""),
+			UserInfoURL: getEnv(prefix+"USERINFO_URL", ""),
+
+			SubjectClaim: getEnv(prefix+"SUBJECT_CLAIM", "sub"),
+			EmailClaim:   getEnv(prefix+"EMAIL_CLAIM", "email"),
+			NameClaim:    getEnv(prefix+"NAME_CLAIM", "name"),
+			PictureClaim: getEnv(prefix+"PICTURE_CLAIM", "picture"),
+
+			AllowedDomains: utils.ParseAllowedOrigins(strings.ToLower(getEnv(prefix+"ALLOWED_DOMAINS", ""))),
+			AllowedOrigins: utils.ParseAllowedOrigins(getEnv(prefix+"ALLOWED_ORIGINS", strings.Join(defaults.AllowedOrigins, ","))),
+
+			TrustEmail: getEnvAsBool(prefix+"TRUST_EMAIL", false),
+		})
+	}
+	return providers
+}
+
 // Helper
 func getEnv(key string, defaultValue string) string {
 	if os.Getenv(key) != "" {
@@ -199,6 +487,15 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -207,3 +504,17 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// getEnvAsRateLimit reads a rule written as "<limit>/<window>", e.g. "10/1m"
+func getEnvAsRateLimit(key string, defaultLimit int, defaultWindow time.Duration) RateLimitRule {
+	if value := os.Getenv(key); value != "" {
+		limitStr, windowStr, found := strings.Cut(value, "/")
+		limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+		if found && err == nil {
+			if window, err := time.ParseDuration(strings.TrimSpace(windowStr)); err == nil && window > 0 {
+				return RateLimitRule{Limit: limit, Window: window}
+			}
+		}
+	}
+	return RateLimitRule{Limit: defaultLimit, Window: defaultWindow}
+}
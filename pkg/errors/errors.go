@@ -42,8 +42,12 @@ var (
 	ErrPasswordNotMatch       = errors.New("password does not match")
 	ErrInvalidEmail           = errors.New("invalid email format")
 	ErrInvalidEmailLength     = errors.New("email length must be between 5 and 254 characters")
-	ErrInvalidPasswordLength  = errors.New("password must be at least 8 characters long")
-	ErrPasswordWeak           = errors.New("password must contain at least one special character")
+	ErrInvalidPasswordLength  = errors.New("password length does not meet the password policy")
+	ErrPasswordWeak           = errors.New("password must include the character types required by the password policy")
+	ErrPasswordBanned         = errors.New("password contains a common word or your name or email")
+	ErrPasswordReused         = errors.New("password was used recently, choose a different one")
+	ErrPasswordExpired        = errors.New("password has expired and must be changed")
+	ErrPasswordBreached       = errors.New("password has appeared in a data breach, choose a different one")
 	ErrInvalidGender          = errors.New("gener invalid value")
 	ErrInvalidPhone           = errors.New("invalid phone number")
 	ErrPhoneAlreadyExists     = errors.New("phone number already exists")
@@ -56,6 +60,14 @@ var (
 	ErrOAuthCodeExchange      = errors.New("failed to exchange OAuth code for token")
 	ErrOAuthUserInfo          = errors.New("failed to get user info from OAuth provider")
 	ErrOAuthUserUseOAuthLogin = errors.New("user registered via OAuth, please use OAuth login")
+
+	// External identity provider errors
+	ErrOAuthEmailMissing          = errors.New("identity provider did not return an email address")
+	ErrOAuthEmailNotVerified      = errors.New("identity provider has not verified the email address")
+	ErrOAuthEmailDomainNotAllowed = errors.New("email domain is not allowed for this identity provider")
+	ErrOAuthIDTokenInvalid        = errors.New("identity provider returned an invalid ID token")
+	ErrOAuthLoginCodeInvalid      = errors.New("invalid or expired login code")
+	ErrOAuthEmailInUse            = errors.New("an account with this email already exists, sign in to it with its own login method")
 	
 	ErrValidationFailed       = errors.New("validation failed")
 	
@@ -68,4 +80,50 @@ var (
 	ErrUserInactive           = errors.New("user account is inactive")
 	ErrSessionNotFound        = errors.New("session not found or expired")
 	ErrSessionExpired         = errors.New("session has expired")
+	ErrRefreshTokenInvalid    = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused     = errors.New("refresh token reuse detected, all sessions of this login were revoked")
+	ErrSessionLifetimeExceeded = errors.New("session reached its maximum lifetime, please log in again")
+	ErrAccountLocked           = errors.New("too many failed login attempts, the account is temporarily locked")
+	ErrTooManyLoginAttempts    = errors.New("too many failed login attempts, please wait before trying again")
+	ErrAccountNotLocked        = errors.New("account is not locked")
+	ErrNotPlatformAdmin        = errors.New("only platform administrators can impersonate users")
+	ErrCannotImpersonate       = errors.New("this user cannot be impersonated")
+	ErrImpersonationNotAllowed = errors.New("not allowed while impersonating a user")
+
+	// Multi-factor authentication errors
+	ErrMFAChallengeInvalid   = errors.New("MFA challenge is invalid or expired, please log in again")
+	ErrMFACodeInvalid        = errors.New("invalid verification code")
+	ErrMFAAlreadyEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled         = errors.New("two-factor authentication is not enabled")
+	ErrMFANotEnrolling       = errors.New("no pending two-factor enrollment, start enrollment first")
+	ErrMFARequired           = errors.New("two-factor authentication is enabled for this account, please use the phantom login")
+	ErrMFAEnrollmentRequired = errors.New("this organization requires two-factor authentication, enroll a second factor to continue")
+
+	// Passkey (WebAuthn) errors
+	ErrPasskeyCeremonyInvalid = errors.New("passkey request is invalid or expired, please try again")
+	ErrPasskeyInvalid         = errors.New("passkey verification failed")
+	ErrPasskeyNotFound        = errors.New("passkey not found")
+	ErrPasskeyExists          = errors.New("passkey is already registered")
+
+	// API token errors
+	ErrAPITokenNotFound         = errors.New("API token not found")
+	ErrAPITokenLimitReached     = errors.New("maximum number of API tokens reached, revoke one first")
+	ErrAPITokenPermissionDenied = errors.New("API tokens can only carry permissions you hold in the tenant")
+	ErrAPITokenExpiryTooLong    = errors.New("API token expiry exceeds the allowed maximum")
+
+	// OAuth client errors
+	ErrOAuthClientNotFound      = errors.New("OAuth client not found")
+	ErrOAuthClientInvalid       = errors.New("client authentication failed")
+	ErrOAuthScopeInvalid        = errors.New("requested scope is not allowed for this client")
+	ErrOAuthGrantUnsupported    = errors.New("grant type is not supported")
+	ErrOAuthClientScopesInvalid = errors.New("scopes must be separated by spaces and may not contain commas")
+	ErrOAuthPublicClient        = errors.New("public clients have no secret")
+	ErrOAuthRedirectURIRequired = errors.New("public clients need at least one redirect URI")
+
+	// OpenID Connect provider errors
+	ErrOAuthRedirectURIInvalid          = errors.New("redirect_uri is not registered for this client")
+	ErrOAuthAuthorizationRequestInvalid = errors.New("authorization request is invalid or expired, please start again from the application")
+	ErrOAuthGrantInvalid                = errors.New("authorization code or refresh token is invalid or expired")
+	ErrOAuthAccessDenied                = errors.New("you are not a member of the organization this application belongs to")
+	ErrOAuthTokenInvalid                = errors.New("access token is invalid or expired")
 )
@@ -123,6 +123,20 @@ func ParseFrontendURLs(urlsStr string) map[string]string {
 	return frontendURLs
 }
 
+// ParseClientCredentials parses "client_id:secret" pairs separated by commas
+func ParseClientCredentials(credentialsStr string) map[string]string {
+	credentials := make(map[string]string)
+
+	for _, pair := range strings.Split(credentialsStr, ",") {
+		clientID, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && clientID != "" && secret != "" {
+			credentials[clientID] = secret
+		}
+	}
+
+	return credentials
+}
+
 func FormatPKIDToStr(pkid int64) string {
 	return strconv.FormatInt(pkid, 10)
 }
@@ -0,0 +1,64 @@
+package utils
+
+import "strings"
+
+// uaMatcher maps a User-Agent substring to a readable name.
+// Order matters: more specific tokens must come before generic ones.
+type uaMatcher struct {
+	token string
+	name  string
+}
+
+var browserMatchers = []uaMatcher{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"PostmanRuntime/", "Postman"},
+	{"curl/", "curl"},
+	{"okhttp/", "OkHttp"},
+	{"Dart/", "Dart"},
+}
+
+var osMatchers = []uaMatcher{
+	{"iPhone", "iPhone"},
+	{"iPad", "iPad"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// ParseDeviceLabel turns a User-Agent header into a short label such as
+// "Chrome on Windows". Unknown parts are left out; an empty or unrecognised
+// User-Agent yields "Unknown device".
+func ParseDeviceLabel(userAgent string) string {
+	browser := matchUserAgent(userAgent, browserMatchers)
+	os := matchUserAgent(userAgent, osMatchers)
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	default:
+		return "Unknown device"
+	}
+}
+
+func matchUserAgent(userAgent string, matchers []uaMatcher) string {
+	for _, m := range matchers {
+		if strings.Contains(userAgent, m.token) {
+			return m.name
+		}
+	}
+	return ""
+}
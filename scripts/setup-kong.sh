@@ -3,8 +3,12 @@ set -e
 
 # Configuration
 KONG_ADMIN="${KONG_ADMIN_URL:-http://localhost:3602}"
+KONG_PROXY="${KONG_PROXY_URL:-http://localhost:3600}"
 # Portal service uses internal Docker network name
 UPSTREAM_URL="${PORTAL_SERVICE_URL:-http://portal-service:3000}"
+# Kong's introspection client credentials (client_id:secret, one of INTROSPECTION_CLIENTS)
+INTROSPECTION_CLIENT="${KONG_INTROSPECTION_CLIENT:-kong:change-me}"
+INTROSPECTION_BASIC=$(printf '%s' "$INTROSPECTION_CLIENT" | base64 | tr -d '\n')
 
 echo "Targeting Kong: $KONG_ADMIN"
 echo "Upstream Service: $UPSTREAM_URL"
@@ -29,6 +33,13 @@ curl -s -X PUT "$KONG_ADMIN/services/portal-service/routes/portal-auth-route" \
     --data "paths[]=/api/v1/auth" \
     --data "strip_path=false" > /dev/null
 
+# Route A2: Public OAuth2 / OpenID Connect provider and discovery (No plugins)
+echo "Configuring Route: Public OAuth2..."
+curl -s -X PUT "$KONG_ADMIN/services/portal-service/routes/portal-oauth2-route" \
+    --data "paths[]=/api/v1/oauth2" \
+    --data "paths[]=/.well-known" \
+    --data "strip_path=false" > /dev/null
+
 # Route B: Protected Users (Will have plugins)
 echo "Configuring Route: Protected Users..."
 # We retrieve the ID because we need it to manage the plugin cleanly
@@ -36,6 +47,9 @@ PROTECTED_ROUTE_ID=$(curl -s -X PUT "$KONG_ADMIN/services/portal-service/routes/
     --data "paths[]=/api/v1/users" \
     --data "paths[]=/api/v1/memberships" \
     --data "paths[]=/api/v1/tenants" \
+    --data "paths[]=/api/v1/mfa" \
+    --data "paths[]=/api/v1/profile" \
+    --data "paths[]=/api/v1/admin" \
     --data "strip_path=false" | grep -o '"id":"[^"]*"' | head -1 | sed 's/"id":"\([^"]*\)"/\1/')
 
 # 4. Lua Logic for Phantom Token
@@ -45,9 +59,10 @@ local cjson = require "cjson"
 
 local LOG_PREFIX = "[PhantomAuth] "
 local INTROSPECT_URL = "'$UPSTREAM_URL'/api/v1/auth/introspect"
+local INTROSPECT_AUTH = "Basic '$INTROSPECTION_BASIC'"
 
 -- 1. Security: Sanitize incoming headers to prevent spoofing
-local headers_to_clear = {"X-Tenant-ID", "X-User-ID", "X-Role-ID", "X-Role-Name", "X-Permissions", "X-Authenticated"}
+local headers_to_clear = {"X-Tenant-ID", "X-User-ID", "X-Role-ID", "X-Role-Name", "X-Permissions", "X-Authenticated", "X-Impersonated-By", "X-API-Token-ID", "X-Client-ID", "X-Session-Restriction"}
 for _, h in ipairs(headers_to_clear) do
     kong.service.request.clear_header(h)
 end
@@ -58,6 +73,7 @@ if not auth_header then
     kong.log.warn(LOG_PREFIX, "Request denied: Missing Authorization header")
     return kong.response.exit(401, { message = "Missing Authorization header" })
 end
+local token = auth_header:gsub("^[Bb]earer%s+", "")
 
 -- 3. Perform Introspection
 local httpc = http.new()
@@ -68,7 +84,8 @@ local start_time = kong.request.get_start_time()
 
 local res, err = httpc:request_uri(INTROSPECT_URL, {
     method = "POST",
-    headers = { ["Authorization"] = auth_header, ["Content-Type"] = "application/json" },
+    headers = { ["Authorization"] = INTROSPECT_AUTH, ["Content-Type"] = "application/json" },
+    body = cjson.encode({ token = token }),
 })
 
 -- Calculate latency
@@ -102,6 +119,11 @@ end
 -- 7. Header Injection
 kong.service.request.clear_header("Authorization") -- Hide token from upstream
 
+-- Signed session JWT (INTROSPECTION_ISSUE_JWT) that upstream verifies offline
+if body.token then
+    kong.service.request.set_header("Authorization", "Bearer " .. body.token)
+end
+
 local safe_headers = {
     ["X-Tenant-ID"]   = res.headers["X-Tenant-ID"] or tostring(body.tenant_id or ""),
     ["X-User-ID"]     = res.headers["X-User-ID"] or tostring(body.user_id or ""),
@@ -113,6 +135,26 @@ for k, v in pairs(safe_headers) do
     kong.service.request.set_header(k, v)
 end
 
+-- Sessions of a platform admin acting as the user
+if body.impersonated_by then
+    kong.service.request.set_header("X-Impersonated-By", res.headers["X-Impersonated-By"] or tostring(body.impersonated_by))
+end
+
+-- Personal access tokens of integrations (portal endpoints refuse them)
+if body.api_token_id then
+    kong.service.request.set_header("X-API-Token-ID", res.headers["X-API-Token-ID"] or tostring(body.api_token_id))
+end
+
+-- Limited sessions (e.g. mfa_enrollment, password_change), only accepted by the routes meant for them
+if body.restriction then
+    kong.service.request.set_header("X-Session-Restriction", res.headers["X-Session-Restriction"] or body.restriction)
+end
+
+-- Service accounts (OAuth client credentials, no user behind the request)
+if body.client_id then
+    kong.service.request.set_header("X-Client-ID", res.headers["X-Client-ID"] or body.client_id)
+end
+
 kong.log.notice(LOG_PREFIX, "Access Granted | User: ", safe_headers["X-User-ID"], " | Role: ", safe_headers["X-Role-Name"])
 '
 
@@ -133,5 +175,20 @@ curl -s -X POST "$KONG_ADMIN/routes/$PROTECTED_ROUTE_ID/plugins" \
     --data "name=pre-function" \
     --data-urlencode "config.access[1]=$LUA_CODE" > /dev/null
 
+# 6. Smoke Check
+# Protected endpoints must reach the plugin (401 without a token); 404 means Kong has no route for them
+echo "Checking protected routes..."
+for path in /api/v1/users /api/v1/tenants /api/v1/mfa /api/v1/profile /api/v1/profile/change-password /api/v1/profile/impersonations /api/v1/profile/api-tokens /api/v1/admin/impersonations; do
+    for attempt in 1 2 3 4 5; do
+        STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$KONG_PROXY$path" || true)
+        [ "$STATUS" = "401" ] && break
+        sleep 1 # Kong rebuilds its router asynchronously
+    done
+    if [ "$STATUS" != "401" ]; then
+        echo "Route check failed: $path returned $STATUS through $KONG_PROXY, expected 401"
+        exit 1
+    fi
+done
+
 echo "Configuration Complete."
 echo "Logs can be viewed via: docker logs <kong_container_name> | grep '\[PhantomAuth\]'"
\ No newline at end of file